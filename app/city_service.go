@@ -2,34 +2,466 @@
 package app
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+
 	"github.com/softstone1/woc/domain"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// maxConcurrentCityResolutions bounds how many cities of a batch are looked up in the
+	// repository, or geocoded, at the same time.
+	maxConcurrentCityResolutions = 8
+	// maxConcurrentCityFetches bounds how many cities of a batch are fetched at the same time
+	// from a client that cannot fetch them in a single call.
+	maxConcurrentCityFetches = 8
+	// importMatchRadiusKm is how close an imported city has to be to a stored city of the same name
+	// and country to be taken for it, as the sources of their IDs may name the administrative area
+	// differently, or only by a code.
+	importMatchRadiusKm = 25
 )
+
 type WeatherService interface {
-	GetWeatherByCity(ctx context.Context, cityName string) (*domain.Weather, error)
+	GetWeatherByCity(ctx context.Context, cityName string, units domain.Units) (*domain.Weather, error)
+	GetForecastByCity(ctx context.Context, cityName string, hours int, units domain.Units) (*domain.Forecast, error)
+	GetDailyForecastByCity(ctx context.Context, cityName string, days int, units domain.Units) (*domain.DailyForecast, error)
+	GetConsensusWeatherByCity(ctx context.Context, cityName string, units domain.Units, aggregation domain.Aggregation) (*domain.ConsensusWeather, error)
+	GetWeatherByCoordinates(ctx context.Context, latitude, longitude float64, units domain.Units) (*domain.LocationWeather, error)
+	GetWeatherByCities(ctx context.Context, cityNames []string, units domain.Units) ([]domain.CityWeather, error)
 	GetAllCities() ([]domain.City, error)
 }
 
 type weatherService struct {
-	client domain.WeatherClient
-	cityRepository domain.CityRepository
+	client          domain.WeatherClient
+	consensusClient domain.ConsensusClient
+	geocoder        domain.Geocoder
+	cityRepository  domain.CityRepository
+}
+
+// ServiceOption configures optional capabilities of the weather service.
+type ServiceOption func(*weatherService)
+
+// WithConsensus enables consensus weather across several providers.
+func WithConsensus(consensusClient domain.ConsensusClient) ServiceOption {
+	return func(s *weatherService) {
+		s.consensusClient = consensusClient
+	}
+}
+
+// WithGeocoder looks up places missing from the city repository with the geocoder and adds them
+// to the repository, so later requests find them.
+func WithGeocoder(geocoder domain.Geocoder) ServiceOption {
+	return func(s *weatherService) {
+		s.geocoder = geocoder
+	}
 }
 
-func NewWeatherService(weatherClient domain.WeatherClient, cityRepository domain.CityRepository) *weatherService {
-	return &weatherService{
-		client: weatherClient,
+func NewWeatherService(weatherClient domain.WeatherClient, cityRepository domain.CityRepository, opts ...ServiceOption) *weatherService {
+	s := &weatherService{
+		client:         weatherClient,
 		cityRepository: cityRepository,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *weatherService) GetWeatherByCity(ctx context.Context, cityName string) (*domain.Weather, error) {
-	city, err := s.cityRepository.GetCity(cityName)
+func (s *weatherService) GetWeatherByCity(ctx context.Context, cityName string, units domain.Units) (*domain.Weather, error) {
+	city, err := s.resolveCity(ctx, cityName)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.FetchWeatherByCity(ctx, *city, units)
+}
+
+func (s *weatherService) GetForecastByCity(ctx context.Context, cityName string, hours int, units domain.Units) (*domain.Forecast, error) {
+	city, err := s.resolveCity(ctx, cityName)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.FetchForecastByCity(ctx, *city, hours, units)
+}
+
+func (s *weatherService) GetDailyForecastByCity(ctx context.Context, cityName string, days int, units domain.Units) (*domain.DailyForecast, error) {
+	city, err := s.resolveCity(ctx, cityName)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.FetchDailyForecastByCity(ctx, *city, days, units)
+}
+
+func (s *weatherService) GetConsensusWeatherByCity(ctx context.Context, cityName string, units domain.Units, aggregation domain.Aggregation) (*domain.ConsensusWeather, error) {
+	if s.consensusClient == nil {
+		return nil, fmt.Errorf("%w: consensus mode is not enabled", domain.ErrInvalidInput)
+	}
+	city, err := s.resolveCity(ctx, cityName)
 	if err != nil {
 		return nil, err
 	}
-	return s.client.FetchWeatherByCity(ctx, *city)
+	return s.consensusClient.FetchConsensusWeatherByCity(ctx, *city, units, aggregation)
+}
+
+// GetWeatherByCities returns the current weather of each city, or why it is missing, in the order
+// the cities were given. The cities are resolved concurrently and their weather is fetched with
+// as few upstream calls as the client allows. Only a batch of the wrong size fails as a whole.
+func (s *weatherService) GetWeatherByCities(ctx context.Context, cityNames []string, units domain.Units) ([]domain.CityWeather, error) {
+	if len(cityNames) == 0 || len(cityNames) > domain.MaxBatchCities {
+		return nil, fmt.Errorf("%w: between 1 and %d cities must be given, got %d", domain.ErrInvalidInput, domain.MaxBatchCities, len(cityNames))
+	}
+	results := make([]domain.CityWeather, len(cityNames))
+	cities := make([]*domain.City, len(cityNames))
+	var g errgroup.Group
+	g.SetLimit(maxConcurrentCityResolutions)
+	for i, name := range cityNames {
+		results[i].City = name
+		g.Go(func() error {
+			cities[i], results[i].Err = s.resolveCity(ctx, name)
+			return nil
+		})
+	}
+	g.Wait()
+
+	var resolved []domain.City
+	var indexes []int
+	for i, city := range cities {
+		if results[i].Err == nil {
+			resolved = append(resolved, *city)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(resolved) == 0 {
+		return results, nil
+	}
+	weathers, errs := domain.FetchWeatherByCities(ctx, s.client, resolved, units, maxConcurrentCityFetches)
+	for j, i := range indexes {
+		results[i].Weather, results[i].Err = weathers[j], errs[j]
+	}
+	return results, nil
 }
 
 func (s *weatherService) GetAllCities() ([]domain.City, error) {
 	return s.cityRepository.GetAllCities()
-}
\ No newline at end of file
+}
+
+// GetWeatherByCoordinates returns the current weather at an ad-hoc location, which does not need to be
+// in the city repository, together with the nearest known city.
+func (s *weatherService) GetWeatherByCoordinates(ctx context.Context, latitude, longitude float64, units domain.Units) (*domain.LocationWeather, error) {
+	location, err := domain.NewLocation(latitude, longitude)
+	if err != nil {
+		return nil, err
+	}
+	nearest, err := s.nearestCity(location)
+	if err != nil {
+		return nil, err
+	}
+	weather, err := s.client.FetchWeatherByCity(ctx, location, units)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.LocationWeather{
+		Latitude:    location.Latitude,
+		Longitude:   location.Longitude,
+		NearestCity: nearest,
+		Weather:     weather,
+	}, nil
+}
+
+// nearestCity returns the known city closest to location, nil if there are no cities.
+func (s *weatherService) nearestCity(location domain.City) (*domain.NearbyCity, error) {
+	cities, err := s.cityRepository.Nearest(location.Latitude, location.Longitude, 1)
+	if err != nil || len(cities) == 0 {
+		return nil, err
+	}
+	roundDistances(cities)
+	return &cities[0], nil
+}
+
+// resolveCity finds the city in the repository or, with a geocoder, geocodes it. A name like
+// "Paris, US" is disambiguated by the country code or administrative area after the comma.
+// Geocoded cities are added to the repository with the name as given as an alias, see
+// cacheGeocodedCity.
+func (s *weatherService) resolveCity(ctx context.Context, cityName string) (*domain.City, error) {
+	city, err := s.cityRepository.GetCity(cityName)
+	if !errors.Is(err, domain.ErrCityNotFound) {
+		return city, err
+	}
+	name, qualifiers := domain.ParsePlaceQuery(cityName)
+	if name == "" {
+		return nil, domain.ErrCityNotFound
+	}
+	if len(qualifiers) > 0 {
+		cities, err := s.cityRepository.FindCities(name)
+		if err != nil {
+			return nil, err
+		}
+		if city, ok := domain.SelectCity(cities, qualifiers); ok {
+			return &city, nil
+		}
+	}
+	if s.geocoder == nil {
+		return nil, domain.ErrCityNotFound
+	}
+	places, err := s.geocoder.Geocode(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	place, ok := domain.SelectPlace(places, qualifiers)
+	if !ok {
+		return nil, domain.ErrCityNotFound
+	}
+	geocoded, err := place.City(name)
+	if err != nil {
+		return nil, err
+	}
+	city, err = s.cacheGeocodedCity(geocoded, name)
+	if err != nil {
+		// the place is still usable, it is geocoded again next time
+		slog.Warn("caching geocoded city failed", "city", geocoded.ID, "error", err)
+		return &geocoded, nil
+	}
+	return city, nil
+}
+
+// cacheGeocodedCity adds the geocoded city to the repository and returns it. A place already in
+// the repository under another name gets the name as an alias instead, so that it is not geocoded
+// again, and the stored city is returned.
+func (s *weatherService) cacheGeocodedCity(geocoded domain.City, name string) (*domain.City, error) {
+	err := s.cityRepository.CreateCity(geocoded)
+	if !errors.Is(err, domain.ErrCityExists) {
+		return &geocoded, err
+	}
+	existing, err := s.cityRepository.GetCity(geocoded.ID)
+	if err != nil {
+		return nil, err
+	}
+	existing.Aliases = append(slices.Clone(existing.Aliases), name)
+	updated, err := existing.Normalized()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cityRepository.UpdateCity(updated.ID, updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// CityService manages the cities weather can be requested for.
+type CityService interface {
+	GetCity(name string) (*domain.City, error)
+	GetAllCities() ([]domain.City, error)
+	CreateCity(city domain.City) (*domain.City, error)
+	UpdateCity(name string, city domain.City) (*domain.City, error)
+	DeleteCity(name string) error
+	SearchCities(query string, limit int) ([]domain.City, error)
+	NearestCities(latitude, longitude float64, limit int) ([]domain.NearbyCity, error)
+	CitiesWithinRadius(latitude, longitude, radiusKm float64) ([]domain.NearbyCity, error)
+	ImportCities(records []domain.CityRecord, opts domain.ImportOptions) (*domain.ImportReport, error)
+}
+
+type cityService struct {
+	cityRepository domain.CityRepository
+}
+
+func NewCityService(cityRepository domain.CityRepository) *cityService {
+	return &cityService{
+		cityRepository: cityRepository,
+	}
+}
+
+func (s *cityService) GetCity(name string) (*domain.City, error) {
+	return s.cityRepository.GetCity(name)
+}
+
+// GetAllCities returns all cities ordered by name.
+func (s *cityService) GetAllCities() ([]domain.City, error) {
+	cities, err := s.cityRepository.GetAllCities()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(cities, func(a, b domain.City) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return cities, nil
+}
+
+// CreateCity adds the city. A city without an ID gets one derived from its name, administrative
+// area and country code.
+func (s *cityService) CreateCity(city domain.City) (*domain.City, error) {
+	city, err := city.Normalized()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cityRepository.CreateCity(city); err != nil {
+		return nil, err
+	}
+	return &city, nil
+}
+
+// UpdateCity replaces the city found by ID or name. An empty city name or ID keeps the current
+// one, so a renamed city keeps its ID.
+func (s *cityService) UpdateCity(name string, city domain.City) (*domain.City, error) {
+	current, err := s.cityRepository.GetCity(name)
+	if err != nil {
+		return nil, err
+	}
+	city.Name = cmp.Or(city.Name, current.Name)
+	city.ID = cmp.Or(city.ID, current.ID)
+	city, err = city.Normalized()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cityRepository.UpdateCity(current.ID, city); err != nil {
+		return nil, err
+	}
+	return &city, nil
+}
+
+func (s *cityService) DeleteCity(name string) error {
+	return s.cityRepository.DeleteCity(name)
+}
+
+// SearchCities returns up to limit cities matching query, best match first.
+func (s *cityService) SearchCities(query string, limit int) ([]domain.City, error) {
+	if domain.NormalizeCityName(query) == "" {
+		return nil, fmt.Errorf("%w: search query is required", domain.ErrInvalidInput)
+	}
+	if limit < 1 || limit > domain.MaxCitySearchLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidInput, domain.MaxCitySearchLimit)
+	}
+	return s.cityRepository.SearchCities(query, limit)
+}
+
+// NearestCities returns the limit cities closest to the point, nearest first.
+func (s *cityService) NearestCities(latitude, longitude float64, limit int) ([]domain.NearbyCity, error) {
+	location, err := domain.NewLocation(latitude, longitude)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 || limit > domain.MaxNearestCities {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidInput, domain.MaxNearestCities)
+	}
+	cities, err := s.cityRepository.Nearest(location.Latitude, location.Longitude, limit)
+	if err != nil {
+		return nil, err
+	}
+	roundDistances(cities)
+	return cities, nil
+}
+
+// CitiesWithinRadius returns the cities at most radiusKm kilometres from the point, nearest first.
+func (s *cityService) CitiesWithinRadius(latitude, longitude, radiusKm float64) ([]domain.NearbyCity, error) {
+	location, err := domain.NewLocation(latitude, longitude)
+	if err != nil {
+		return nil, err
+	}
+	if !(radiusKm > 0) || math.IsInf(radiusKm, 1) {
+		return nil, fmt.Errorf("%w: radius must be a positive number of kilometres, got %g", domain.ErrInvalidInput, radiusKm)
+	}
+	cities, err := s.cityRepository.WithinRadius(location.Latitude, location.Longitude, radiusKm)
+	if err != nil {
+		return nil, err
+	}
+	roundDistances(cities)
+	return cities, nil
+}
+
+// ImportCities adds the valid records with at least the minimum population to the repository.
+// Of records sharing an ID, a name in the same administrative area and country, the most populous
+// is imported. Cities already in the repository, under the same ID or as a nearby city of the same
+// name and country, are left unchanged. The cities are added in a
+// single transaction, so a repository failure leaves the repository unchanged.
+func (s *cityService) ImportCities(records []domain.CityRecord, opts domain.ImportOptions) (*domain.ImportReport, error) {
+	report := &domain.ImportReport{Read: len(records), DryRun: opts.DryRun}
+	var accepted []domain.City
+	for _, record := range records {
+		if record.Err == nil {
+			record.City, record.Err = record.City.Normalized()
+		}
+		switch {
+		case record.Err != nil:
+			report.Invalid++
+			if len(report.Errors) < domain.MaxImportErrors {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", record.Position, record.Err))
+			}
+		case record.City.Population < opts.MinPopulation:
+			report.BelowMinPopulation++
+		default:
+			accepted = append(accepted, record.City)
+		}
+	}
+	slices.SortStableFunc(accepted, func(a, b domain.City) int {
+		return cmp.Compare(b.Population, a.Population)
+	})
+	taken := map[string]bool{}
+	var cities []domain.City
+	for _, city := range accepted {
+		if taken[city.ID] {
+			report.Duplicates++
+			continue
+		}
+		taken[city.ID] = true
+		cities = append(cities, city)
+	}
+	var missing []domain.City
+	for _, city := range cities {
+		exists, err := s.cityExists(city)
+		if err != nil {
+			return report, fmt.Errorf("importing city %q: %w", city.ID, err)
+		}
+		if exists {
+			report.Existing++
+		} else {
+			missing = append(missing, city)
+		}
+	}
+	if opts.DryRun {
+		report.Imported = len(missing)
+		return report, nil
+	}
+	created, err := s.cityRepository.CreateCities(missing)
+	if err != nil {
+		return report, fmt.Errorf("importing cities: %w", err)
+	}
+	// CreateCities skips cities added since they were looked up
+	report.Imported, report.Existing = created, report.Existing+len(missing)-created
+	return report, nil
+}
+
+// cityExists reports whether the city is in the repository, under its ID or as a city of the same
+// name and country at most importMatchRadiusKm away. GetCity also finds cities by name, which may
+// be other cities.
+func (s *cityService) cityExists(city domain.City) (bool, error) {
+	existing, err := s.cityRepository.GetCity(city.ID)
+	if err != nil && !errors.Is(err, domain.ErrCityNotFound) {
+		return false, err
+	}
+	if err == nil && existing.ID == city.ID {
+		return true, nil
+	}
+	namesakes, err := s.cityRepository.FindCities(city.Name)
+	if err != nil {
+		return false, err
+	}
+	for _, namesake := range namesakes {
+		if namesake.CountryCode == city.CountryCode &&
+			domain.DistanceKm(namesake.Latitude, namesake.Longitude, city.Latitude, city.Longitude) <= importMatchRadiusKm {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// roundDistances rounds the distances to the metre, more precision than the coordinates have.
+func roundDistances(cities []domain.NearbyCity) {
+	for i := range cities {
+		cities[i].DistanceKm = math.Round(cities[i].DistanceKm*1000) / 1000
+	}
+}
@@ -3,10 +3,15 @@ package app
 import (
 	"context"
 	"errors"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/softstone1/woc/domain"
+	"github.com/softstone1/woc/infra/db"
+	"github.com/softstone1/woc/infra/importer"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -19,20 +24,20 @@ func TestWeatherService_GetWeatherByCity(t *testing.T) {
 	service := NewWeatherService(mockWeatherClient, mockCityRepository)
 
 	tests := []struct {
-		name          string
-		cityName      string
-		setupMocks    func()
+		name            string
+		cityName        string
+		setupMocks      func()
 		expectedWeather *domain.Weather
-		expectedErr   error
+		expectedErr     error
 	}{
 		{
 			name:     "successful weather fetch",
 			cityName: "Berlin",
 			setupMocks: func() {
-				mockCity := &domain.City{Name: "Berlin", Latitude: "52.5200", Longitude: "13.4050"}
+				mockCity := &domain.City{Name: "Berlin", Latitude: 52.5200, Longitude: 13.4050}
 				mockWeather := &domain.Weather{City: "Berlin", Temperature: 20.5, WindSpeed: 5.0}
 				mockCityRepository.EXPECT().GetCity("Berlin").Return(mockCity, nil)
-				mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), *mockCity).Return(mockWeather, nil)
+				mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), *mockCity, domain.Imperial).Return(mockWeather, nil)
 			},
 			expectedWeather: &domain.Weather{City: "Berlin", Temperature: 20.5, WindSpeed: 5.0},
 			expectedErr:     nil,
@@ -54,7 +59,7 @@ func TestWeatherService_GetWeatherByCity(t *testing.T) {
 				tc.setupMocks()
 			}
 
-			weather, err := service.GetWeatherByCity(context.Background(), tc.cityName)
+			weather, err := service.GetWeatherByCity(context.Background(), tc.cityName, domain.Imperial)
 			if !reflect.DeepEqual(err, tc.expectedErr) {
 				t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedErr, err)
 			}
@@ -65,6 +70,199 @@ func TestWeatherService_GetWeatherByCity(t *testing.T) {
 	}
 }
 
+func TestWeatherService_GetForecastByCity(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWeatherClient := domain.NewMockWeatherClient(mockCtrl)
+	mockCityRepository := domain.NewMockCityRepository(mockCtrl)
+	service := NewWeatherService(mockWeatherClient, mockCityRepository)
+
+	forecast := &domain.Forecast{
+		City: "Berlin",
+		Hourly: []domain.HourlyWeather{
+			{Time: time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC), Temperature: 20.5, WindSpeed: 5.0},
+			{Time: time.Date(2024, 5, 1, 15, 0, 0, 0, time.UTC), Temperature: 21.0, WindSpeed: 4.2},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		cityName         string
+		hours            int
+		setupMocks       func()
+		expectedForecast *domain.Forecast
+		expectedErr      error
+	}{
+		{
+			name:     "successful forecast fetch",
+			cityName: "Berlin",
+			hours:    2,
+			setupMocks: func() {
+				mockCity := &domain.City{Name: "Berlin", Latitude: 52.5200, Longitude: 13.4050}
+				mockCityRepository.EXPECT().GetCity("Berlin").Return(mockCity, nil)
+				mockWeatherClient.EXPECT().FetchForecastByCity(gomock.Any(), *mockCity, 2, domain.Imperial).Return(forecast, nil)
+			},
+			expectedForecast: forecast,
+			expectedErr:      nil,
+		},
+		{
+			name:     "city not found error",
+			cityName: "Unknown",
+			hours:    2,
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Unknown").Return(nil, errors.New("city not found"))
+			},
+			expectedForecast: nil,
+			expectedErr:      errors.New("city not found"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupMocks != nil {
+				tc.setupMocks()
+			}
+
+			got, err := service.GetForecastByCity(context.Background(), tc.cityName, tc.hours, domain.Imperial)
+			if !reflect.DeepEqual(err, tc.expectedErr) {
+				t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(got, tc.expectedForecast) {
+				t.Errorf("%s: expected forecast %v, got %v", tc.name, tc.expectedForecast, got)
+			}
+		})
+	}
+}
+
+func TestWeatherService_GetDailyForecastByCity(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWeatherClient := domain.NewMockWeatherClient(mockCtrl)
+	mockCityRepository := domain.NewMockCityRepository(mockCtrl)
+	service := NewWeatherService(mockWeatherClient, mockCityRepository)
+
+	forecast := &domain.DailyForecast{
+		City: "Berlin",
+		Daily: []domain.DailyWeather{
+			{Date: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), TemperatureMin: 9.5, TemperatureMax: 20.5},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		cityName         string
+		days             int
+		setupMocks       func()
+		expectedForecast *domain.DailyForecast
+		expectedErr      error
+	}{
+		{
+			name:     "successful daily forecast fetch",
+			cityName: "Berlin",
+			days:     1,
+			setupMocks: func() {
+				mockCity := &domain.City{Name: "Berlin", Latitude: 52.5200, Longitude: 13.4050}
+				mockCityRepository.EXPECT().GetCity("Berlin").Return(mockCity, nil)
+				mockWeatherClient.EXPECT().FetchDailyForecastByCity(gomock.Any(), *mockCity, 1, domain.Imperial).Return(forecast, nil)
+			},
+			expectedForecast: forecast,
+			expectedErr:      nil,
+		},
+		{
+			name:     "city not found error",
+			cityName: "Unknown",
+			days:     7,
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Unknown").Return(nil, errors.New("city not found"))
+			},
+			expectedForecast: nil,
+			expectedErr:      errors.New("city not found"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupMocks != nil {
+				tc.setupMocks()
+			}
+
+			got, err := service.GetDailyForecastByCity(context.Background(), tc.cityName, tc.days, domain.Imperial)
+			if !reflect.DeepEqual(err, tc.expectedErr) {
+				t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(got, tc.expectedForecast) {
+				t.Errorf("%s: expected forecast %v, got %v", tc.name, tc.expectedForecast, got)
+			}
+		})
+	}
+}
+
+func TestWeatherService_GetConsensusWeatherByCity(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWeatherClient := domain.NewMockWeatherClient(mockCtrl)
+	mockConsensusClient := domain.NewMockConsensusClient(mockCtrl)
+	mockCityRepository := domain.NewMockCityRepository(mockCtrl)
+	service := NewWeatherService(mockWeatherClient, mockCityRepository, WithConsensus(mockConsensusClient))
+
+	consensus := &domain.ConsensusWeather{
+		Aggregation: domain.AggregateMedian,
+		Weather:     domain.Weather{City: "Berlin", Temperature: 20.5},
+		Providers:   []domain.ProviderWeather{{Provider: "open-meteo", Weather: &domain.Weather{City: "Berlin", Temperature: 20.5}}},
+	}
+
+	tests := []struct {
+		name              string
+		cityName          string
+		setupMocks        func()
+		expectedConsensus *domain.ConsensusWeather
+		expectedErr       error
+	}{
+		{
+			name:     "successful consensus fetch",
+			cityName: "Berlin",
+			setupMocks: func() {
+				mockCity := &domain.City{Name: "Berlin", Latitude: 52.5200, Longitude: 13.4050}
+				mockCityRepository.EXPECT().GetCity("Berlin").Return(mockCity, nil)
+				mockConsensusClient.EXPECT().FetchConsensusWeatherByCity(gomock.Any(), *mockCity, domain.Metric, domain.AggregateMedian).Return(consensus, nil)
+			},
+			expectedConsensus: consensus,
+		},
+		{
+			name:     "city not found error",
+			cityName: "Unknown",
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Unknown").Return(nil, domain.ErrCityNotFound)
+			},
+			expectedErr: domain.ErrCityNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMocks()
+
+			result, err := service.GetConsensusWeatherByCity(context.Background(), tc.cityName, domain.Metric, domain.AggregateMedian)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(result, tc.expectedConsensus) {
+				t.Errorf("%s: expected consensus %v, got %v", tc.name, tc.expectedConsensus, result)
+			}
+		})
+	}
+
+	t.Run("consensus not enabled", func(t *testing.T) {
+		service := NewWeatherService(mockWeatherClient, mockCityRepository)
+		if _, err := service.GetConsensusWeatherByCity(context.Background(), "Berlin", domain.Metric, domain.AggregateMedian); !errors.Is(err, domain.ErrInvalidInput) {
+			t.Errorf("expected invalid input error, got %v", err)
+		}
+	})
+}
+
 func TestWeatherService_GetAllCities(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -81,10 +279,10 @@ func TestWeatherService_GetAllCities(t *testing.T) {
 		{
 			name: "get all cities successfully",
 			setupMocks: func() {
-				expectedCities := []domain.City{{Name: "Berlin", Latitude: "52.5200", Longitude: "13.4050"}}
+				expectedCities := []domain.City{{Name: "Berlin", Latitude: 52.5200, Longitude: 13.4050}}
 				mockCityRepository.EXPECT().GetAllCities().Return(expectedCities, nil)
 			},
-			expectedCities: []domain.City{{Name: "Berlin", Latitude: "52.5200", Longitude: "13.4050"}},
+			expectedCities: []domain.City{{Name: "Berlin", Latitude: 52.5200, Longitude: 13.4050}},
 			expectedErr:    nil,
 		},
 		{
@@ -114,3 +312,744 @@ func TestWeatherService_GetAllCities(t *testing.T) {
 	}
 }
 
+func TestCityService_GetAllCities(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCityRepository := domain.NewMockCityRepository(mockCtrl)
+	service := NewCityService(mockCityRepository)
+
+	mockCityRepository.EXPECT().GetAllCities().Return([]domain.City{{Name: "Tokyo"}, {Name: "London"}, {Name: "Paris"}}, nil)
+	cities, err := service.GetAllCities()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []domain.City{{Name: "London"}, {Name: "Paris"}, {Name: "Tokyo"}}
+	if !reflect.DeepEqual(cities, expected) {
+		t.Errorf("expected cities %v, got %v", expected, cities)
+	}
+}
+
+func TestCityService_CreateCity(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCityRepository := domain.NewMockCityRepository(mockCtrl)
+	service := NewCityService(mockCityRepository)
+	berlin := domain.City{Name: "Berlin", CountryCode: "de", Admin1: "Land Berlin", Latitude: 52.5200, Longitude: 13.4050, Timezone: "Europe/Berlin"}
+	created := domain.City{ID: "berlin-land-berlin-de", Name: "Berlin", CountryCode: "DE", Admin1: "Land Berlin", Latitude: 52.5200, Longitude: 13.4050, Timezone: "Europe/Berlin"}
+
+	tests := []struct {
+		name         string
+		city         domain.City
+		setupMocks   func()
+		expectedCity *domain.City
+		expectedErr  error
+	}{
+		{
+			name: "city created with a derived ID",
+			city: berlin,
+			setupMocks: func() {
+				mockCityRepository.EXPECT().CreateCity(created).Return(nil)
+			},
+			expectedCity: &created,
+		},
+		{
+			name: "city created with its own ID",
+			city: domain.City{ID: "ber", Name: "Berlin", Latitude: 52.52, Longitude: 13.405},
+			setupMocks: func() {
+				mockCityRepository.EXPECT().CreateCity(domain.City{ID: "ber", Name: "Berlin", Latitude: 52.52, Longitude: 13.405}).Return(nil)
+			},
+			expectedCity: &domain.City{ID: "ber", Name: "Berlin", Latitude: 52.52, Longitude: 13.405},
+		},
+		{
+			name: "city already exists",
+			city: berlin,
+			setupMocks: func() {
+				mockCityRepository.EXPECT().CreateCity(created).Return(domain.ErrCityExists)
+			},
+			expectedErr: domain.ErrCityExists,
+		},
+		{
+			name:        "malformed ID is rejected",
+			city:        domain.City{ID: "Berlin DE", Name: "Berlin", Latitude: 52.52, Longitude: 13.405},
+			setupMocks:  func() {},
+			expectedErr: domain.ErrInvalidInput,
+		},
+		{
+			name:        "unknown time zone is rejected",
+			city:        domain.City{Name: "Berlin", Latitude: 52.52, Longitude: 13.405, Timezone: "Europe/Atlantis"},
+			setupMocks:  func() {},
+			expectedErr: domain.ErrInvalidInput,
+		},
+		{
+			name:        "invalid city is not stored",
+			city:        domain.City{Name: "Berlin", Latitude: 152.52, Longitude: 13.405},
+			setupMocks:  func() {},
+			expectedErr: domain.ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMocks()
+
+			city, err := service.CreateCity(tc.city)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(city, tc.expectedCity) {
+				t.Errorf("%s: expected city %v, got %v", tc.name, tc.expectedCity, city)
+			}
+		})
+	}
+}
+
+func TestCityService_UpdateCity(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCityRepository := domain.NewMockCityRepository(mockCtrl)
+	service := NewCityService(mockCityRepository)
+
+	tests := []struct {
+		name         string
+		cityName     string
+		city         domain.City
+		setupMocks   func()
+		expectedCity *domain.City
+		expectedErr  error
+	}{
+		{
+			name:     "name defaults to the current one",
+			cityName: "berlin",
+			city:     domain.City{Latitude: 52.52, Longitude: 13.405},
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("berlin").Return(&domain.City{ID: "berlin-de", Name: "Berlin", Latitude: 52.5, Longitude: 13.4}, nil)
+				mockCityRepository.EXPECT().UpdateCity("berlin-de", domain.City{ID: "berlin-de", Name: "Berlin", Latitude: 52.52, Longitude: 13.405}).Return(nil)
+			},
+			expectedCity: &domain.City{ID: "berlin-de", Name: "Berlin", Latitude: 52.52, Longitude: 13.405},
+		},
+		{
+			name:     "renamed city keeps its ID",
+			cityName: "Berlin",
+			city:     domain.City{Name: "Berlin-Mitte", Latitude: 52.52, Longitude: 13.405},
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Berlin").Return(&domain.City{ID: "berlin-de", Name: "Berlin"}, nil)
+				mockCityRepository.EXPECT().UpdateCity("berlin-de", domain.City{ID: "berlin-de", Name: "Berlin-Mitte", Latitude: 52.52, Longitude: 13.405}).Return(nil)
+			},
+			expectedCity: &domain.City{ID: "berlin-de", Name: "Berlin-Mitte", Latitude: 52.52, Longitude: 13.405},
+		},
+		{
+			name:     "ID changed",
+			cityName: "berlin-de",
+			city:     domain.City{ID: "berlin", Name: "Berlin", Latitude: 52.52, Longitude: 13.405},
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("berlin-de").Return(&domain.City{ID: "berlin-de", Name: "Berlin"}, nil)
+				mockCityRepository.EXPECT().UpdateCity("berlin-de", domain.City{ID: "berlin", Name: "Berlin", Latitude: 52.52, Longitude: 13.405}).Return(domain.ErrCityExists)
+			},
+			expectedErr: domain.ErrCityExists,
+		},
+		{
+			name:     "aliases deduplicated",
+			cityName: "Berlin",
+			city:     domain.City{Name: "Berlin", Latitude: 52.52, Longitude: 13.405, Aliases: []string{" BER", "berlin", "ber"}},
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Berlin").Return(&domain.City{ID: "berlin-de", Name: "Berlin"}, nil)
+				mockCityRepository.EXPECT().UpdateCity("berlin-de", domain.City{ID: "berlin-de", Name: "Berlin", Latitude: 52.52, Longitude: 13.405, Aliases: []string{"BER"}}).Return(nil)
+			},
+			expectedCity: &domain.City{ID: "berlin-de", Name: "Berlin", Latitude: 52.52, Longitude: 13.405, Aliases: []string{"BER"}},
+		},
+		{
+			name:     "city not found",
+			cityName: "Unknown",
+			city:     domain.City{Latitude: 0, Longitude: 0},
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Unknown").Return(nil, domain.ErrCityNotFound)
+			},
+			expectedErr: domain.ErrCityNotFound,
+		},
+		{
+			name:     "invalid city is not stored",
+			cityName: "Berlin",
+			city:     domain.City{Name: "Berlin", Latitude: 52.52, Longitude: 213.405},
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Berlin").Return(&domain.City{ID: "berlin-de", Name: "Berlin"}, nil)
+			},
+			expectedErr: domain.ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMocks()
+
+			city, err := service.UpdateCity(tc.cityName, tc.city)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(city, tc.expectedCity) {
+				t.Errorf("%s: expected city %v, got %v", tc.name, tc.expectedCity, city)
+			}
+		})
+	}
+}
+
+func TestCityService_SearchCities(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCityRepository := domain.NewMockCityRepository(mockCtrl)
+	service := NewCityService(mockCityRepository)
+
+	tests := []struct {
+		name           string
+		query          string
+		limit          int
+		setupMocks     func()
+		expectedCities []domain.City
+		expectedErr    error
+	}{
+		{
+			name:  "cities found",
+			query: "lon",
+			limit: 5,
+			setupMocks: func() {
+				mockCityRepository.EXPECT().SearchCities("lon", 5).Return([]domain.City{{Name: "London"}}, nil)
+			},
+			expectedCities: []domain.City{{Name: "London"}},
+		},
+		{
+			name:        "blank query",
+			query:       "  ",
+			limit:       5,
+			setupMocks:  func() {},
+			expectedErr: domain.ErrInvalidInput,
+		},
+		{
+			name:        "limit too large",
+			query:       "lon",
+			limit:       domain.MaxCitySearchLimit + 1,
+			setupMocks:  func() {},
+			expectedErr: domain.ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMocks()
+
+			cities, err := service.SearchCities(tc.query, tc.limit)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(cities, tc.expectedCities) {
+				t.Errorf("%s: expected cities %v, got %v", tc.name, tc.expectedCities, cities)
+			}
+		})
+	}
+}
+
+func TestCityService_NearbyCities(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCityRepository := domain.NewMockCityRepository(mockCtrl)
+	service := NewCityService(mockCityRepository)
+	london := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+
+	tests := []struct {
+		name           string
+		latitude       float64
+		longitude      float64
+		limit          int
+		radiusKm       float64
+		setupMocks     func()
+		expectedCities []domain.NearbyCity
+		expectedErr    error
+	}{
+		{
+			name:      "nearest cities",
+			latitude:  51.500001,
+			longitude: -0.12,
+			limit:     1,
+			setupMocks: func() {
+				mockCityRepository.EXPECT().Nearest(51.5, -0.12, 1).Return([]domain.NearbyCity{{City: london, DistanceKm: 0.98012}}, nil)
+			},
+			expectedCities: []domain.NearbyCity{{City: london, DistanceKm: 0.98}},
+		},
+		{
+			name:      "cities within radius",
+			latitude:  51.5,
+			longitude: -0.12,
+			radiusKm:  25,
+			setupMocks: func() {
+				mockCityRepository.EXPECT().WithinRadius(51.5, -0.12, 25.0).Return([]domain.NearbyCity{{City: london, DistanceKm: 0.98012}}, nil)
+			},
+			expectedCities: []domain.NearbyCity{{City: london, DistanceKm: 0.98}},
+		},
+		{
+			name:        "invalid latitude",
+			latitude:    -91,
+			longitude:   -0.12,
+			limit:       1,
+			setupMocks:  func() {},
+			expectedErr: domain.ErrInvalidInput,
+		},
+		{
+			name:        "limit too large",
+			latitude:    51.5,
+			longitude:   -0.12,
+			limit:       domain.MaxNearestCities + 1,
+			setupMocks:  func() {},
+			expectedErr: domain.ErrInvalidInput,
+		},
+		{
+			name:        "negative radius",
+			latitude:    51.5,
+			longitude:   -0.12,
+			radiusKm:    -5,
+			setupMocks:  func() {},
+			expectedErr: domain.ErrInvalidInput,
+		},
+		{
+			name:        "infinite radius",
+			latitude:    51.5,
+			longitude:   -0.12,
+			radiusKm:    math.Inf(1),
+			setupMocks:  func() {},
+			expectedErr: domain.ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMocks()
+
+			var cities []domain.NearbyCity
+			var err error
+			if tc.limit != 0 {
+				cities, err = service.NearestCities(tc.latitude, tc.longitude, tc.limit)
+			} else {
+				cities, err = service.CitiesWithinRadius(tc.latitude, tc.longitude, tc.radiusKm)
+			}
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(cities, tc.expectedCities) {
+				t.Errorf("%s: expected cities %v, got %v", tc.name, tc.expectedCities, cities)
+			}
+		})
+	}
+}
+
+func TestCityService_ImportCities(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCityRepository := domain.NewMockCityRepository(mockCtrl)
+	service := NewCityService(mockCityRepository)
+
+	records := []domain.CityRecord{
+		{Position: "line 1", City: domain.City{Name: "Brandenburg", CountryCode: "DE", Admin1: "BB", Latitude: 52.4125, Longitude: 12.5316,
+			Population: 72040, Aliases: []string{"BER", "Brandenburg an der Havel"}}},
+		{Position: "line 2", City: domain.City{Name: "Berlin", CountryCode: "DE", Admin1: "BE", Latitude: 52.52, Longitude: 13.405, Population: 3644826, Aliases: []string{"BER"}}},
+		{Position: "line 3", City: domain.City{Name: "berlin", CountryCode: "de", Admin1: "BE", Latitude: 52.5, Longitude: 13.4, Population: 10000}},
+		{Position: "line 4", City: domain.City{Name: "Hamlet", Latitude: 50, Longitude: 10, Population: 120}},
+		{Position: "line 5", City: domain.City{Name: "Atlantis", Latitude: 95, Longitude: 0, Population: 5000}},
+		{Position: "line 6", Err: errors.New("invalid input: population must be a whole number, got \"many\"")},
+		{Position: "line 7", City: domain.City{Name: "London", CountryCode: "GB", Admin1: "ENG", Latitude: 51.5074, Longitude: -0.1278, Population: 8961989}},
+		{Position: "line 8", City: domain.City{Name: "Berlin", CountryCode: "US", Admin1: "NH", Latitude: 44.4686, Longitude: -71.1851, Population: 10000}},
+	}
+	brandenburg := domain.City{ID: "brandenburg-bb-de", Name: "Brandenburg", CountryCode: "DE", Admin1: "BB", Latitude: 52.4125, Longitude: 12.5316,
+		Population: 72040, Aliases: []string{"BER", "Brandenburg an der Havel"}}
+	london := domain.City{ID: "london-eng-gb", Name: "London", CountryCode: "GB", Admin1: "ENG", Latitude: 51.5074, Longitude: -0.1278, Population: 8961989}
+	berlinUS := domain.City{ID: "berlin-nh-us", Name: "Berlin", CountryCode: "US", Admin1: "NH", Latitude: 44.4686, Longitude: -71.1851, Population: 10000}
+	// London is stored under its ID, Berlin under an ID naming its state in full
+	storedBerlin := domain.City{ID: "berlin-berlin-de", Name: "Berlin", CountryCode: "DE", Admin1: "Berlin", Latitude: 52.5244, Longitude: 13.4105}
+	expectLookups := func() {
+		mockCityRepository.EXPECT().GetCity("london-eng-gb").Return(&london, nil)
+		mockCityRepository.EXPECT().GetCity("berlin-be-de").Return(nil, domain.ErrCityNotFound)
+		mockCityRepository.EXPECT().FindCities("Berlin").Return([]domain.City{storedBerlin}, nil)
+		mockCityRepository.EXPECT().GetCity("brandenburg-bb-de").Return(nil, domain.ErrCityNotFound)
+		mockCityRepository.EXPECT().FindCities("Brandenburg").Return(nil, nil)
+		// a city found by name is another city
+		mockCityRepository.EXPECT().GetCity("berlin-nh-us").Return(&storedBerlin, nil)
+		mockCityRepository.EXPECT().FindCities("Berlin").Return([]domain.City{storedBerlin}, nil)
+	}
+	invalid := []string{
+		"line 5: invalid input: latitude must be between -90 and 90, got 95",
+		"line 6: invalid input: population must be a whole number, got \"many\"",
+	}
+
+	tests := []struct {
+		name           string
+		opts           domain.ImportOptions
+		setupMocks     func()
+		expectedReport *domain.ImportReport
+		expectErr      bool
+	}{
+		{
+			name: "import",
+			opts: domain.ImportOptions{MinPopulation: 1000},
+			setupMocks: func() {
+				expectLookups()
+				// one of the cities was added since it was looked up
+				mockCityRepository.EXPECT().CreateCities([]domain.City{brandenburg, berlinUS}).Return(1, nil)
+			},
+			expectedReport: &domain.ImportReport{Read: 8, Imported: 1, Existing: 3, Duplicates: 1, BelowMinPopulation: 1, Invalid: 2, Errors: invalid},
+		},
+		{
+			name:           "dry run",
+			opts:           domain.ImportOptions{MinPopulation: 1000, DryRun: true},
+			setupMocks:     expectLookups,
+			expectedReport: &domain.ImportReport{Read: 8, DryRun: true, Imported: 2, Existing: 2, Duplicates: 1, BelowMinPopulation: 1, Invalid: 2, Errors: invalid},
+		},
+		{
+			name: "repository failure",
+			opts: domain.ImportOptions{MinPopulation: 1000},
+			setupMocks: func() {
+				expectLookups()
+				mockCityRepository.EXPECT().CreateCities([]domain.City{brandenburg, berlinUS}).Return(0, errors.New("disk full"))
+			},
+			expectedReport: &domain.ImportReport{Read: 8, Existing: 2, Duplicates: 1, BelowMinPopulation: 1, Invalid: 2, Errors: invalid},
+			expectErr:      true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMocks()
+
+			report, err := service.ImportCities(records, tc.opts)
+			if (err != nil) != tc.expectErr {
+				t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectErr, err)
+			}
+			if !reflect.DeepEqual(report, tc.expectedReport) {
+				t.Errorf("%s: expected report %+v, got %+v", tc.name, tc.expectedReport, report)
+			}
+		})
+	}
+}
+
+func TestCityService_ImportCities_StoredUnderAnotherID(t *testing.T) {
+	service := NewCityService(db.NewInMemoryCityRepository())
+	// GeoNames gives Paris the admin1 code 11, the seeded Paris is named after Île-de-France
+	line := strings.Join([]string{"2988507", "Paris", "Paris", "", "48.85341", "2.3488", "P", "PPLC", "FR", "", "11", "75", "", "",
+		"2138551", "", "42", "Europe/Paris", "2024-01-01"}, "\t")
+	records, err := importer.Read(strings.NewReader(line), importer.FormatGeoNames, importer.DefaultFields, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	report, err := service.ImportCities(records, domain.ImportOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := &domain.ImportReport{Read: 1, Existing: 1}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("Expected report %+v, but got %+v", expected, report)
+	}
+	city, err := service.GetCity("Paris")
+	if err != nil || city.ID != "paris-ile-de-france-fr" {
+		t.Errorf("Expected the seeded Paris, but got %+v, %v", city, err)
+	}
+}
+
+func TestWeatherService_GeocodesUnknownCities(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWeatherClient := domain.NewMockWeatherClient(mockCtrl)
+	mockCityRepository := domain.NewMockCityRepository(mockCtrl)
+	mockGeocoder := domain.NewMockGeocoder(mockCtrl)
+	service := NewWeatherService(mockWeatherClient, mockCityRepository, WithGeocoder(mockGeocoder))
+
+	places := []domain.Place{
+		{Name: "Paris", Latitude: 48.85341, Longitude: 2.3488, Country: "France", CountryCode: "FR", Admin1: "Île-de-France"},
+		{Name: "Paris", Latitude: 33.66094, Longitude: -95.55551, Country: "United States", CountryCode: "US", Admin1: "Texas"},
+	}
+	city := domain.City{ID: "paris-ile-de-france-fr", Name: "Paris", CountryCode: "FR", Admin1: "Île-de-France", Latitude: 48.8534, Longitude: 2.3488}
+	parisTexas := domain.City{ID: "paris-texas-us", Name: "Paris", CountryCode: "US", Admin1: "Texas", Latitude: 33.6609, Longitude: -95.5555}
+	weather := &domain.Weather{City: "Paris", Temperature: 20.5}
+
+	tests := []struct {
+		name            string
+		cityName        string
+		setupMocks      func()
+		expectedWeather *domain.Weather
+		expectedErr     error
+	}{
+		{
+			name:     "city in the repository is not geocoded",
+			cityName: "Berlin",
+			setupMocks: func() {
+				city := &domain.City{Name: "Berlin", Latitude: 52.52, Longitude: 13.405}
+				mockCityRepository.EXPECT().GetCity("Berlin").Return(city, nil)
+				mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), *city, domain.Metric).Return(weather, nil)
+			},
+			expectedWeather: weather,
+		},
+		{
+			name:     "geocoded city is cached in the repository",
+			cityName: "Paris",
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Paris").Return(nil, domain.ErrCityNotFound)
+				mockGeocoder.EXPECT().Geocode(gomock.Any(), "Paris").Return(places, nil)
+				mockCityRepository.EXPECT().CreateCity(city).Return(nil)
+				mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).Return(weather, nil)
+			},
+			expectedWeather: weather,
+		},
+		{
+			name:     "city in the repository is disambiguated by country",
+			cityName: "Paris, us",
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Paris, us").Return(nil, domain.ErrCityNotFound)
+				mockCityRepository.EXPECT().FindCities("Paris").Return([]domain.City{city, parisTexas}, nil)
+				mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), parisTexas, domain.Metric).Return(weather, nil)
+			},
+			expectedWeather: weather,
+		},
+		{
+			name:     "geocoded city is disambiguated by country",
+			cityName: "Paris, US",
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Paris, US").Return(nil, domain.ErrCityNotFound)
+				mockCityRepository.EXPECT().FindCities("Paris").Return([]domain.City{city}, nil)
+				mockGeocoder.EXPECT().Geocode(gomock.Any(), "Paris").Return(places, nil)
+				mockCityRepository.EXPECT().CreateCity(parisTexas).Return(nil)
+				mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), parisTexas, domain.Metric).Return(weather, nil)
+			},
+			expectedWeather: weather,
+		},
+		{
+			name:     "geocoded city already in the repository gets the name as an alias",
+			cityName: "Lutèce",
+			setupMocks: func() {
+				stored := domain.City{ID: "paris-ile-de-france-fr", Name: "Paris", CountryCode: "FR", Admin1: "Île-de-France",
+					Latitude: 48.8566, Longitude: 2.3522, Population: 2138551, Aliases: []string{"Paname"}}
+				aliased := stored
+				aliased.Aliases = []string{"Paname", "Lutèce"}
+				geocoded := city
+				geocoded.Aliases = []string{"Lutèce"}
+				mockCityRepository.EXPECT().GetCity("Lutèce").Return(nil, domain.ErrCityNotFound)
+				mockGeocoder.EXPECT().Geocode(gomock.Any(), "Lutèce").Return(places, nil)
+				mockCityRepository.EXPECT().CreateCity(geocoded).Return(domain.ErrCityExists)
+				mockCityRepository.EXPECT().GetCity("paris-ile-de-france-fr").Return(&stored, nil)
+				mockCityRepository.EXPECT().UpdateCity("paris-ile-de-france-fr", aliased).Return(nil)
+				mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), aliased, domain.Metric).Return(weather, nil)
+			},
+			expectedWeather: weather,
+		},
+		{
+			name:     "weather is returned when caching fails",
+			cityName: "Paris",
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Paris").Return(nil, domain.ErrCityNotFound)
+				mockGeocoder.EXPECT().Geocode(gomock.Any(), "Paris").Return(places, nil)
+				mockCityRepository.EXPECT().CreateCity(city).Return(errors.New("disk full"))
+				mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).Return(weather, nil)
+			},
+			expectedWeather: weather,
+		},
+		{
+			name:     "no place matches the country",
+			cityName: "Paris, Canada",
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Paris, Canada").Return(nil, domain.ErrCityNotFound)
+				mockCityRepository.EXPECT().FindCities("Paris").Return(nil, nil)
+				mockGeocoder.EXPECT().Geocode(gomock.Any(), "Paris").Return(places, nil)
+			},
+			expectedErr: domain.ErrCityNotFound,
+		},
+		{
+			name:     "unknown place",
+			cityName: "Nowhere",
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Nowhere").Return(nil, domain.ErrCityNotFound)
+				mockGeocoder.EXPECT().Geocode(gomock.Any(), "Nowhere").Return([]domain.Place{}, nil)
+			},
+			expectedErr: domain.ErrCityNotFound,
+		},
+		{
+			name:     "geocoder failure",
+			cityName: "Paris",
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Paris").Return(nil, domain.ErrCityNotFound)
+				mockGeocoder.EXPECT().Geocode(gomock.Any(), "Paris").Return(nil, domain.ErrUpstreamUnavailable)
+			},
+			expectedErr: domain.ErrUpstreamUnavailable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMocks()
+
+			result, err := service.GetWeatherByCity(context.Background(), tc.cityName, domain.Metric)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(result, tc.expectedWeather) {
+				t.Errorf("%s: expected weather %v, got %v", tc.name, tc.expectedWeather, result)
+			}
+		})
+	}
+}
+
+func TestWeatherService_GetWeatherByCoordinates(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWeatherClient := domain.NewMockWeatherClient(mockCtrl)
+	mockCityRepository := domain.NewMockCityRepository(mockCtrl)
+	service := NewWeatherService(mockWeatherClient, mockCityRepository)
+
+	paris := domain.City{Name: "Paris", Latitude: 48.8566, Longitude: 2.3522}
+	location := domain.City{ID: "48-9-2-35", Name: "48.9,2.35", Latitude: 48.9, Longitude: 2.35}
+	weather := &domain.Weather{City: location.Name, Temperature: 20.5}
+
+	tests := []struct {
+		name            string
+		latitude        float64
+		longitude       float64
+		setupMocks      func()
+		expectedWeather *domain.LocationWeather
+		expectedErr     error
+	}{
+		{
+			name:      "weather with the nearest city",
+			latitude:  48.90001,
+			longitude: 2.35,
+			setupMocks: func() {
+				mockCityRepository.EXPECT().Nearest(48.9, 2.35, 1).Return([]domain.NearbyCity{{City: paris, DistanceKm: 4.828547}}, nil)
+				mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), location, domain.Metric).Return(weather, nil)
+			},
+			expectedWeather: &domain.LocationWeather{
+				Latitude:    48.9,
+				Longitude:   2.35,
+				NearestCity: &domain.NearbyCity{City: paris, DistanceKm: 4.829},
+				Weather:     weather,
+			},
+		},
+		{
+			name:      "no known cities",
+			latitude:  48.9,
+			longitude: 2.35,
+			setupMocks: func() {
+				mockCityRepository.EXPECT().Nearest(48.9, 2.35, 1).Return([]domain.NearbyCity{}, nil)
+				mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), location, domain.Metric).Return(weather, nil)
+			},
+			expectedWeather: &domain.LocationWeather{Latitude: 48.9, Longitude: 2.35, Weather: weather},
+		},
+		{
+			name:        "invalid coordinates",
+			latitude:    95,
+			longitude:   2.35,
+			setupMocks:  func() {},
+			expectedErr: domain.ErrInvalidInput,
+		},
+		{
+			name:      "provider failure",
+			latitude:  48.9,
+			longitude: 2.35,
+			setupMocks: func() {
+				mockCityRepository.EXPECT().Nearest(48.9, 2.35, 1).Return([]domain.NearbyCity{{City: paris, DistanceKm: 4.828547}}, nil)
+				mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), location, domain.Metric).Return(nil, domain.ErrUpstreamUnavailable)
+			},
+			expectedErr: domain.ErrUpstreamUnavailable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMocks()
+
+			result, err := service.GetWeatherByCoordinates(context.Background(), tc.latitude, tc.longitude, domain.Metric)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(result, tc.expectedWeather) {
+				t.Errorf("%s: expected weather %+v, got %+v", tc.name, tc.expectedWeather, result)
+			}
+		})
+	}
+}
+
+func TestWeatherService_GetWeatherByCities(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWeatherClient := domain.NewMockBatchWeatherClient(mockCtrl)
+	mockCityRepository := domain.NewMockCityRepository(mockCtrl)
+	service := NewWeatherService(mockWeatherClient, mockCityRepository)
+
+	tokyo := domain.City{ID: "tokyo-jp", Name: "Tokyo", Latitude: 35.6895, Longitude: 139.6917}
+	london := domain.City{ID: "london-england-gb", Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	tokyoWeather := &domain.Weather{City: "Tokyo", Temperature: 21}
+
+	tests := []struct {
+		name            string
+		cityNames       []string
+		setupMocks      func()
+		expectedResults []domain.CityWeather
+		expectedErr     error
+	}{
+		{
+			name:      "resolved cities are fetched in one batch",
+			cityNames: []string{"tokyo", "Atlantis", "LONDON"},
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("tokyo").Return(&tokyo, nil)
+				mockCityRepository.EXPECT().GetCity("Atlantis").Return(nil, domain.ErrCityNotFound)
+				mockCityRepository.EXPECT().GetCity("LONDON").Return(&london, nil)
+				mockWeatherClient.EXPECT().FetchWeatherByCities(gomock.Any(), []domain.City{tokyo, london}, domain.Metric).
+					Return([]*domain.Weather{tokyoWeather, nil}, []error{nil, domain.ErrUpstreamTimeout})
+			},
+			expectedResults: []domain.CityWeather{
+				{City: "tokyo", Weather: tokyoWeather},
+				{City: "Atlantis", Err: domain.ErrCityNotFound},
+				{City: "LONDON", Err: domain.ErrUpstreamTimeout},
+			},
+		},
+		{
+			name:      "nothing is fetched when no city is found",
+			cityNames: []string{"Atlantis"},
+			setupMocks: func() {
+				mockCityRepository.EXPECT().GetCity("Atlantis").Return(nil, domain.ErrCityNotFound)
+			},
+			expectedResults: []domain.CityWeather{{City: "Atlantis", Err: domain.ErrCityNotFound}},
+		},
+		{
+			name:        "no cities",
+			setupMocks:  func() {},
+			expectedErr: domain.ErrInvalidInput,
+		},
+		{
+			name:        "too many cities",
+			cityNames:   make([]string, domain.MaxBatchCities+1),
+			setupMocks:  func() {},
+			expectedErr: domain.ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMocks()
+
+			results, err := service.GetWeatherByCities(context.Background(), tc.cityNames, domain.Metric)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedErr, err)
+			}
+			if len(results) != len(tc.expectedResults) {
+				t.Fatalf("%s: expected %d results, got %d", tc.name, len(tc.expectedResults), len(results))
+			}
+			for i, expected := range tc.expectedResults {
+				result := results[i]
+				if result.City != expected.City || !reflect.DeepEqual(result.Weather, expected.Weather) || !errors.Is(result.Err, expected.Err) {
+					t.Errorf("%s: expected result %+v, got %+v", tc.name, expected, result)
+				}
+			}
+		})
+	}
+}
@@ -55,17 +55,249 @@ func (mr *MockWeatherServiceMockRecorder) GetAllCities() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllCities", reflect.TypeOf((*MockWeatherService)(nil).GetAllCities))
 }
 
+// GetConsensusWeatherByCity mocks base method.
+func (m *MockWeatherService) GetConsensusWeatherByCity(ctx context.Context, cityName string, units domain.Units, aggregation domain.Aggregation) (*domain.ConsensusWeather, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConsensusWeatherByCity", ctx, cityName, units, aggregation)
+	ret0, _ := ret[0].(*domain.ConsensusWeather)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConsensusWeatherByCity indicates an expected call of GetConsensusWeatherByCity.
+func (mr *MockWeatherServiceMockRecorder) GetConsensusWeatherByCity(ctx, cityName, units, aggregation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConsensusWeatherByCity", reflect.TypeOf((*MockWeatherService)(nil).GetConsensusWeatherByCity), ctx, cityName, units, aggregation)
+}
+
+// GetDailyForecastByCity mocks base method.
+func (m *MockWeatherService) GetDailyForecastByCity(ctx context.Context, cityName string, days int, units domain.Units) (*domain.DailyForecast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDailyForecastByCity", ctx, cityName, days, units)
+	ret0, _ := ret[0].(*domain.DailyForecast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDailyForecastByCity indicates an expected call of GetDailyForecastByCity.
+func (mr *MockWeatherServiceMockRecorder) GetDailyForecastByCity(ctx, cityName, days, units any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDailyForecastByCity", reflect.TypeOf((*MockWeatherService)(nil).GetDailyForecastByCity), ctx, cityName, days, units)
+}
+
+// GetForecastByCity mocks base method.
+func (m *MockWeatherService) GetForecastByCity(ctx context.Context, cityName string, hours int, units domain.Units) (*domain.Forecast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForecastByCity", ctx, cityName, hours, units)
+	ret0, _ := ret[0].(*domain.Forecast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForecastByCity indicates an expected call of GetForecastByCity.
+func (mr *MockWeatherServiceMockRecorder) GetForecastByCity(ctx, cityName, hours, units any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForecastByCity", reflect.TypeOf((*MockWeatherService)(nil).GetForecastByCity), ctx, cityName, hours, units)
+}
+
+// GetWeatherByCities mocks base method.
+func (m *MockWeatherService) GetWeatherByCities(ctx context.Context, cityNames []string, units domain.Units) ([]domain.CityWeather, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWeatherByCities", ctx, cityNames, units)
+	ret0, _ := ret[0].([]domain.CityWeather)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWeatherByCities indicates an expected call of GetWeatherByCities.
+func (mr *MockWeatherServiceMockRecorder) GetWeatherByCities(ctx, cityNames, units any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWeatherByCities", reflect.TypeOf((*MockWeatherService)(nil).GetWeatherByCities), ctx, cityNames, units)
+}
+
 // GetWeatherByCity mocks base method.
-func (m *MockWeatherService) GetWeatherByCity(ctx context.Context, cityName string) (*domain.Weather, error) {
+func (m *MockWeatherService) GetWeatherByCity(ctx context.Context, cityName string, units domain.Units) (*domain.Weather, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetWeatherByCity", ctx, cityName)
+	ret := m.ctrl.Call(m, "GetWeatherByCity", ctx, cityName, units)
 	ret0, _ := ret[0].(*domain.Weather)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetWeatherByCity indicates an expected call of GetWeatherByCity.
-func (mr *MockWeatherServiceMockRecorder) GetWeatherByCity(ctx, cityName any) *gomock.Call {
+func (mr *MockWeatherServiceMockRecorder) GetWeatherByCity(ctx, cityName, units any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWeatherByCity", reflect.TypeOf((*MockWeatherService)(nil).GetWeatherByCity), ctx, cityName, units)
+}
+
+// GetWeatherByCoordinates mocks base method.
+func (m *MockWeatherService) GetWeatherByCoordinates(ctx context.Context, latitude, longitude float64, units domain.Units) (*domain.LocationWeather, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWeatherByCoordinates", ctx, latitude, longitude, units)
+	ret0, _ := ret[0].(*domain.LocationWeather)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWeatherByCoordinates indicates an expected call of GetWeatherByCoordinates.
+func (mr *MockWeatherServiceMockRecorder) GetWeatherByCoordinates(ctx, latitude, longitude, units any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWeatherByCoordinates", reflect.TypeOf((*MockWeatherService)(nil).GetWeatherByCoordinates), ctx, latitude, longitude, units)
+}
+
+// MockCityService is a mock of CityService interface.
+type MockCityService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCityServiceMockRecorder
+}
+
+// MockCityServiceMockRecorder is the mock recorder for MockCityService.
+type MockCityServiceMockRecorder struct {
+	mock *MockCityService
+}
+
+// NewMockCityService creates a new mock instance.
+func NewMockCityService(ctrl *gomock.Controller) *MockCityService {
+	mock := &MockCityService{ctrl: ctrl}
+	mock.recorder = &MockCityServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCityService) EXPECT() *MockCityServiceMockRecorder {
+	return m.recorder
+}
+
+// CitiesWithinRadius mocks base method.
+func (m *MockCityService) CitiesWithinRadius(latitude, longitude, radiusKm float64) ([]domain.NearbyCity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CitiesWithinRadius", latitude, longitude, radiusKm)
+	ret0, _ := ret[0].([]domain.NearbyCity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CitiesWithinRadius indicates an expected call of CitiesWithinRadius.
+func (mr *MockCityServiceMockRecorder) CitiesWithinRadius(latitude, longitude, radiusKm any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CitiesWithinRadius", reflect.TypeOf((*MockCityService)(nil).CitiesWithinRadius), latitude, longitude, radiusKm)
+}
+
+// CreateCity mocks base method.
+func (m *MockCityService) CreateCity(city domain.City) (*domain.City, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCity", city)
+	ret0, _ := ret[0].(*domain.City)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCity indicates an expected call of CreateCity.
+func (mr *MockCityServiceMockRecorder) CreateCity(city any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCity", reflect.TypeOf((*MockCityService)(nil).CreateCity), city)
+}
+
+// DeleteCity mocks base method.
+func (m *MockCityService) DeleteCity(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCity", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCity indicates an expected call of DeleteCity.
+func (mr *MockCityServiceMockRecorder) DeleteCity(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCity", reflect.TypeOf((*MockCityService)(nil).DeleteCity), name)
+}
+
+// GetAllCities mocks base method.
+func (m *MockCityService) GetAllCities() ([]domain.City, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllCities")
+	ret0, _ := ret[0].([]domain.City)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllCities indicates an expected call of GetAllCities.
+func (mr *MockCityServiceMockRecorder) GetAllCities() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllCities", reflect.TypeOf((*MockCityService)(nil).GetAllCities))
+}
+
+// GetCity mocks base method.
+func (m *MockCityService) GetCity(name string) (*domain.City, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCity", name)
+	ret0, _ := ret[0].(*domain.City)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCity indicates an expected call of GetCity.
+func (mr *MockCityServiceMockRecorder) GetCity(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCity", reflect.TypeOf((*MockCityService)(nil).GetCity), name)
+}
+
+// ImportCities mocks base method.
+func (m *MockCityService) ImportCities(records []domain.CityRecord, opts domain.ImportOptions) (*domain.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportCities", records, opts)
+	ret0, _ := ret[0].(*domain.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportCities indicates an expected call of ImportCities.
+func (mr *MockCityServiceMockRecorder) ImportCities(records, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportCities", reflect.TypeOf((*MockCityService)(nil).ImportCities), records, opts)
+}
+
+// NearestCities mocks base method.
+func (m *MockCityService) NearestCities(latitude, longitude float64, limit int) ([]domain.NearbyCity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NearestCities", latitude, longitude, limit)
+	ret0, _ := ret[0].([]domain.NearbyCity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NearestCities indicates an expected call of NearestCities.
+func (mr *MockCityServiceMockRecorder) NearestCities(latitude, longitude, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NearestCities", reflect.TypeOf((*MockCityService)(nil).NearestCities), latitude, longitude, limit)
+}
+
+// SearchCities mocks base method.
+func (m *MockCityService) SearchCities(query string, limit int) ([]domain.City, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchCities", query, limit)
+	ret0, _ := ret[0].([]domain.City)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchCities indicates an expected call of SearchCities.
+func (mr *MockCityServiceMockRecorder) SearchCities(query, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchCities", reflect.TypeOf((*MockCityService)(nil).SearchCities), query, limit)
+}
+
+// UpdateCity mocks base method.
+func (m *MockCityService) UpdateCity(name string, city domain.City) (*domain.City, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCity", name, city)
+	ret0, _ := ret[0].(*domain.City)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateCity indicates an expected call of UpdateCity.
+func (mr *MockCityServiceMockRecorder) UpdateCity(name, city any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWeatherByCity", reflect.TypeOf((*MockWeatherService)(nil).GetWeatherByCity), ctx, cityName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCity", reflect.TypeOf((*MockCityService)(nil).UpdateCity), name, city)
 }
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/softstone1/woc/app"
+	"github.com/softstone1/woc/config"
+	"github.com/softstone1/woc/domain"
+	"github.com/softstone1/woc/infra/importer"
+)
+
+const citiesUsage = "usage: woc cities import [flags] FILE"
+
+// runCities runs a cities subcommand and returns the exit code.
+func runCities(args []string) int {
+	if len(args) == 0 || args[0] != "import" {
+		fmt.Fprintln(os.Stderr, citiesUsage)
+		return 2
+	}
+	return importCities(args[1:])
+}
+
+// importCities imports the cities of a file into the configured city repository and prints a summary.
+func importCities(args []string) int {
+	flags := flag.NewFlagSet("woc cities import", flag.ContinueOnError)
+	format := flags.String("format", "", "file format, geonames, csv or geojson, by default told by the file extension")
+	minPopulation := flags.Int("min-population", 0, "skip cities with a smaller population")
+	dryRun := flags.Bool("dry-run", false, "report what would be imported without storing anything")
+	admin1Codes := flags.String("admin1-codes", "", "GeoNames admin1CodesASCII.txt naming the states or regions of a GeoNames dump")
+	fields := importer.DefaultFields
+	flags.StringVar(&fields.Name, "name-field", fields.Name, "CSV column or GeoJSON property holding the city name")
+	flags.StringVar(&fields.Latitude, "latitude-field", fields.Latitude, "CSV column holding the latitude")
+	flags.StringVar(&fields.Longitude, "longitude-field", fields.Longitude, "CSV column holding the longitude")
+	flags.StringVar(&fields.CountryCode, "country-code-field", fields.CountryCode, "CSV column or GeoJSON property holding the ISO 3166-1 alpha-2 country code")
+	flags.StringVar(&fields.Admin1, "admin1-field", fields.Admin1, "CSV column or GeoJSON property holding the state or region")
+	flags.StringVar(&fields.Elevation, "elevation-field", fields.Elevation, "CSV column or GeoJSON property holding the elevation in metres")
+	flags.StringVar(&fields.Timezone, "timezone-field", fields.Timezone, "CSV column or GeoJSON property holding the IANA time zone")
+	flags.StringVar(&fields.Population, "population-field", fields.Population, "CSV column or GeoJSON property holding the population")
+	flags.StringVar(&fields.Aliases, "aliases-field", fields.Aliases, "CSV column or GeoJSON property holding aliases separated by semicolons")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), citiesUsage)
+		fmt.Fprintln(flags.Output(), "\nImports a GeoNames dump such as cities15000.txt, a CSV file or a GeoJSON FeatureCollection into the city repository.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	path := flags.Arg(0)
+
+	fileFormat, err := importer.FormatFromPath(path)
+	if *format != "" {
+		fileFormat, err = importer.ParseFormat(*format)
+	}
+	if err != nil {
+		slog.Error("error importing cities", "error", err)
+		return 1
+	}
+	if config.GetEnv().CityRepository() == "memory" && !*dryRun {
+		slog.Error("cities imported into the in-memory repository are lost on exit, set CITY_REPOSITORY=sqlite")
+		return 1
+	}
+	var admin1 importer.Admin1Names
+	if *admin1Codes != "" {
+		if admin1, err = readAdmin1Names(*admin1Codes); err != nil {
+			slog.Error("error reading admin1 codes", "file", *admin1Codes, "error", err)
+			return 1
+		}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		slog.Error("error opening city file", "error", err)
+		return 1
+	}
+	defer file.Close()
+	records, err := importer.Read(file, fileFormat, fields, admin1)
+	if err != nil {
+		slog.Error("error reading city file", "file", path, "error", err)
+		return 1
+	}
+
+	cityRepo, closeCityRepo, err := newCityRepository()
+	if err != nil {
+		slog.Error("error creating city repository", "error", err)
+		return 1
+	}
+	defer closeCityRepo()
+	report, err := app.NewCityService(cityRepo).ImportCities(records, domain.ImportOptions{
+		MinPopulation: *minPopulation,
+		DryRun:        *dryRun,
+	})
+	if report != nil {
+		printImportReport(os.Stdout, report)
+	}
+	if err != nil {
+		slog.Error("error importing cities", "error", err)
+		return 1
+	}
+	return 0
+}
+
+func readAdmin1Names(path string) (importer.Admin1Names, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return importer.ReadAdmin1Names(file)
+}
+
+func printImportReport(w io.Writer, report *domain.ImportReport) {
+	imported := "imported"
+	if report.DryRun {
+		imported = "would import"
+	}
+	fmt.Fprintf(w, "%-22s %d\n", "read", report.Read)
+	fmt.Fprintf(w, "%-22s %d\n", imported, report.Imported)
+	fmt.Fprintf(w, "%-22s %d\n", "already present", report.Existing)
+	fmt.Fprintf(w, "%-22s %d\n", "duplicates", report.Duplicates)
+	fmt.Fprintf(w, "%-22s %d\n", "below min population", report.BelowMinPopulation)
+	fmt.Fprintf(w, "%-22s %d\n", "invalid", report.Invalid)
+	for _, message := range report.Errors {
+		fmt.Fprintf(w, "  %s\n", message)
+	}
+	if n := report.Invalid - len(report.Errors); n > 0 {
+		fmt.Fprintf(w, "  and %d more\n", n)
+	}
+}
@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
+	"expvar"
+	"fmt"
 	"log/slog"
 	"os"
+	// city time zones are validated and shown in local time, and the alpine image has no zoneinfo
+	_ "time/tzdata"
 
 	"github.com/softstone1/woc/app"
 	"github.com/softstone1/woc/config"
+	"github.com/softstone1/woc/domain"
 	"github.com/softstone1/woc/infra/client"
 	"github.com/softstone1/woc/infra/db"
 	"github.com/softstone1/woc/infra/handler"
@@ -14,36 +19,127 @@ import (
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run starts the server, or the subcommand given, and returns the exit code. Deferred cleanups,
+// such as closing the city database, run before main exits.
+func run() int {
 	// Set up default logger to slog with JSON format
 	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
 	// Load the environment variables
 	slog.Info("loading environment variables")
 	config.LoadEnv()
+	if len(os.Args) > 1 && os.Args[1] == "cities" {
+		return runCities(os.Args[2:])
+	}
 
 	/* Dependency injection */
 
-	// Create a new weather client using the OpenMeteo API
-	weatherClient := client.NewOpenMeteo(config.GetEnv().WeatherBaseURL())
-	// Create in-memory city repository
-	cityRepo := db.NewInMemoryCityRepository()
+	// Create the weather providers in failover order, each behind its own circuit breaker
+	registry := client.NewRegistry()
+	baseURLs := map[string]string{
+		client.ProviderOpenMeteo: config.GetEnv().WeatherBaseURL(),
+		client.ProviderMetNorway: config.GetEnv().WeatherMetNorwayBaseURL(),
+	}
+	retry := client.WithRetry(client.RetryPolicy{
+		MaxAttempts: config.GetEnv().WeatherRetryMaxAttempts(),
+		BaseBackoff: config.GetEnv().WeatherRetryBaseBackoff(),
+		MaxBackoff:  config.GetEnv().WeatherRetryMaxBackoff(),
+	})
+	var providers []client.Provider
+	for _, name := range config.GetEnv().WeatherProviders() {
+		provider, err := registry.New(name, baseURLs[name], retry)
+		if err != nil {
+			slog.Error("error creating weather provider", "error", err)
+			return 1
+		}
+		// Fail fast while the provider is down
+		if threshold := config.GetEnv().WeatherBreakerFailureThreshold(); threshold > 0 {
+			provider = client.NewCircuitBreaker(provider, client.BreakerConfig{
+				FailureThreshold: threshold,
+				CoolDown:         config.GetEnv().WeatherBreakerCoolDown(),
+			})
+		}
+		providers = append(providers, client.Provider{Name: name, Client: provider})
+	}
+	if len(providers) == 0 {
+		slog.Error("no weather provider configured")
+		return 1
+	}
+	var weatherClient domain.WeatherClient = providers[0].Client
+	if len(providers) > 1 {
+		weatherClient = client.NewFailover(providers...)
+	}
+	health := map[string]domain.HealthReporter{}
+	var healthOpts []handler.HealthOption
+	if reporter, ok := weatherClient.(domain.HealthReporter); ok {
+		health["weatherProvider"] = reporter
+	}
+	// Cache weather responses in memory
+	if ttl := config.GetEnv().WeatherCacheTTL(); ttl > 0 {
+		cache := client.NewCache(weatherClient, client.CacheConfig{
+			TTL:          ttl,
+			MaxEntries:   config.GetEnv().WeatherCacheMaxEntries(),
+			StaleTTL:     config.GetEnv().WeatherCacheStaleTTL(),
+			RefreshAhead: config.GetEnv().WeatherCacheRefreshAhead(),
+		})
+		expvar.Publish("weatherCache", expvar.Func(func() any { return cache.Stats() }))
+		healthOpts = append(healthOpts, handler.WithCacheStats(func() any { return cache.Stats() }))
+		weatherClient = cache
+	}
+	// Create the city repository
+	cityRepo, closeCityRepo, err := newCityRepository()
+	if err != nil {
+		slog.Error("error creating city repository", "error", err)
+		return 1
+	}
+	defer closeCityRepo()
 
 	// Create a new weather service
-	weatherService := app.NewWeatherService(weatherClient, cityRepo)
+	serviceOpts := []app.ServiceOption{app.WithConsensus(client.NewConsensus(providers...))}
+	// Look up cities missing from the repository by name
+	if config.GetEnv().GeocodingEnabled() {
+		serviceOpts = append(serviceOpts, app.WithGeocoder(client.NewOpenMeteoGeocoder(config.GetEnv().GeocodingBaseURL(), retry)))
+	}
+	weatherService := app.NewWeatherService(weatherClient, cityRepo, serviceOpts...)
 
 	// Create weather handler
 	weatherHandler := handler.NewWeather(weatherService)
 
+	// Create health handler
+	healthHandler := handler.NewHealth(health, healthOpts...)
+
+	// Create city management service and handler
+	citiesHandler := handler.NewCities(app.NewCityService(cityRepo))
+
 	// Create a new server
-	server, err := server.NewMux(config.GetEnv(), weatherHandler)
+	server, err := server.NewMux(config.GetEnv(), weatherHandler, healthHandler, citiesHandler)
 	if err != nil {
 		slog.Error("error creating server", "error", err)
-		os.Exit(1)
+		return 1
 	}
 
 	// Run the server with graceful shutdown
 	if err := server.Run(context.Background()); err != nil {
 		slog.Error("server error", "error", err)
-		os.Exit(1)
+		return 1
 	}
+	return 0
+}
 
+// newCityRepository creates the city repository selected by the configuration, and a function closing it.
+func newCityRepository() (domain.CityRepository, func() error, error) {
+	switch kind := config.GetEnv().CityRepository(); kind {
+	case "memory":
+		return db.NewInMemoryCityRepository(), func() error { return nil }, nil
+	case "sqlite":
+		repo, err := db.NewSQLiteCityRepository(config.GetEnv().CityDatabasePath())
+		if err != nil {
+			return nil, nil, err
+		}
+		return repo, repo.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown city repository %q", kind)
+	}
 }
@@ -1,37 +1,154 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 const (
-	serverPort = "SERVER_PORT"
-	weatherBaseURL = "WEATHER_BASE_URL"
-	enableProfiling = "ENABLE_PROFILING"
+	serverPort             = "SERVER_PORT"
+	weatherBaseURL         = "WEATHER_BASE_URL"
+	weatherProviders       = "WEATHER_PROVIDERS"
+	weatherMetNorwayURL    = "WEATHER_METNO_BASE_URL"
+	enableProfiling        = "ENABLE_PROFILING"
+	cityRepository         = "CITY_REPOSITORY"
+	cityDatabasePath       = "CITY_DATABASE_PATH"
+	adminToken             = "ADMIN_TOKEN"
+	geocodingEnabled       = "GEOCODING_ENABLED"
+	geocodingBaseURL       = "GEOCODING_BASE_URL"
+	weatherCacheTTL        = "WEATHER_CACHE_TTL"
+	weatherCacheMaxEntries = "WEATHER_CACHE_MAX_ENTRIES"
+	weatherCacheStaleTTL   = "WEATHER_CACHE_STALE_TTL"
+	weatherCacheRefresh    = "WEATHER_CACHE_REFRESH_AHEAD"
+	weatherRetryAttempts   = "WEATHER_RETRY_MAX_ATTEMPTS"
+	weatherRetryBase       = "WEATHER_RETRY_BASE_BACKOFF"
+	weatherRetryMax        = "WEATHER_RETRY_MAX_BACKOFF"
+	weatherBreakerFailures = "WEATHER_BREAKER_FAILURE_THRESHOLD"
+	weatherBreakerCoolDown = "WEATHER_BREAKER_COOLDOWN"
 )
 
 type Env struct {
-	ServerPort func() string	
+	ServerPort      func() string
 	EnableProfiling func() bool
-	WeatherBaseURL func() string
+	// CityRepository selects where cities are stored, "memory" or "sqlite"
+	CityRepository   func() string
+	CityDatabasePath func() string
+	// AdminToken is the bearer token required to manage cities, empty disables city management
+	AdminToken func() string
+	// GeocodingEnabled looks up unknown cities with the geocoding API and stores them in the city repository
+	GeocodingEnabled func() bool
+	GeocodingBaseURL func() string
+	WeatherBaseURL   func() string
+	// WeatherProviders lists the weather providers to use, in failover order
+	WeatherProviders        func() []string
+	WeatherMetNorwayBaseURL func() string
+	// WeatherCacheTTL is how long weather responses are cached, 0 disables the cache
+	WeatherCacheTTL        func() time.Duration
+	WeatherCacheMaxEntries func() int
+	// WeatherCacheStaleTTL is how long past the TTL stale weather is served when the provider fails, 0 disables it
+	WeatherCacheStaleTTL func() time.Duration
+	// WeatherCacheRefreshAhead is how long before expiry cached responses are refreshed in the background, 0 disables it
+	WeatherCacheRefreshAhead func() time.Duration
+	// WeatherRetryMaxAttempts is the total number of attempts per upstream request, 1 disables retries
+	WeatherRetryMaxAttempts func() int
+	WeatherRetryBaseBackoff func() time.Duration
+	WeatherRetryMaxBackoff  func() time.Duration
+	// WeatherBreakerFailureThreshold is the number of consecutive upstream failures that
+	// opens the circuit breaker, 0 disables the breaker
+	WeatherBreakerFailureThreshold func() int
+	WeatherBreakerCoolDown         func() time.Duration
 }
 
 func GetEnv() Env {
 	return Env{
 		ServerPort: func() string {
 			return viper.GetString(serverPort)
-			},
+		},
 		EnableProfiling: func() bool {
 			return viper.GetBool(enableProfiling)
-			},
+		},
+		CityRepository: func() string {
+			return viper.GetString(cityRepository)
+		},
+		CityDatabasePath: func() string {
+			return viper.GetString(cityDatabasePath)
+		},
+		AdminToken: func() string {
+			return viper.GetString(adminToken)
+		},
+		GeocodingEnabled: func() bool {
+			return viper.GetBool(geocodingEnabled)
+		},
+		GeocodingBaseURL: func() string {
+			return viper.GetString(geocodingBaseURL)
+		},
 		WeatherBaseURL: func() string {
 			return viper.GetString(weatherBaseURL)
 		},
+		WeatherProviders: func() []string {
+			var providers []string
+			for _, name := range strings.Split(viper.GetString(weatherProviders), ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					providers = append(providers, name)
+				}
+			}
+			return providers
+		},
+		WeatherMetNorwayBaseURL: func() string {
+			return viper.GetString(weatherMetNorwayURL)
+		},
+		WeatherCacheTTL: func() time.Duration {
+			return viper.GetDuration(weatherCacheTTL)
+		},
+		WeatherCacheMaxEntries: func() int {
+			return viper.GetInt(weatherCacheMaxEntries)
+		},
+		WeatherCacheStaleTTL: func() time.Duration {
+			return viper.GetDuration(weatherCacheStaleTTL)
+		},
+		WeatherCacheRefreshAhead: func() time.Duration {
+			return viper.GetDuration(weatherCacheRefresh)
+		},
+		WeatherRetryMaxAttempts: func() int {
+			return viper.GetInt(weatherRetryAttempts)
+		},
+		WeatherRetryBaseBackoff: func() time.Duration {
+			return viper.GetDuration(weatherRetryBase)
+		},
+		WeatherRetryMaxBackoff: func() time.Duration {
+			return viper.GetDuration(weatherRetryMax)
+		},
+		WeatherBreakerFailureThreshold: func() int {
+			return viper.GetInt(weatherBreakerFailures)
+		},
+		WeatherBreakerCoolDown: func() time.Duration {
+			return viper.GetDuration(weatherBreakerCoolDown)
+		},
 	}
 }
 
 func LoadEnv() {
-	//viber 
+	//viber
 	viper.AutomaticEnv()
 	viper.SetDefault(serverPort, "8080")
 	viper.SetDefault(enableProfiling, false)
+	viper.SetDefault(cityRepository, "memory")
+	viper.SetDefault(cityDatabasePath, "woc.db")
+	viper.SetDefault(adminToken, "")
+	viper.SetDefault(geocodingEnabled, false)
+	viper.SetDefault(geocodingBaseURL, "https://geocoding-api.open-meteo.com")
 	viper.SetDefault(weatherBaseURL, "https://api.open-meteo.com")
+	viper.SetDefault(weatherProviders, "open-meteo")
+	viper.SetDefault(weatherMetNorwayURL, "https://api.met.no")
+	viper.SetDefault(weatherCacheTTL, 5*time.Minute)
+	viper.SetDefault(weatherCacheMaxEntries, 1000)
+	viper.SetDefault(weatherCacheStaleTTL, time.Hour)
+	viper.SetDefault(weatherCacheRefresh, 30*time.Second)
+	viper.SetDefault(weatherRetryAttempts, 3)
+	viper.SetDefault(weatherRetryBase, 200*time.Millisecond)
+	viper.SetDefault(weatherRetryMax, 2*time.Second)
+	viper.SetDefault(weatherBreakerFailures, 5)
+	viper.SetDefault(weatherBreakerCoolDown, 30*time.Second)
 }
@@ -1,14 +1,332 @@
 package domain
 
-// City represents city data with coordinates.
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// MaxCityNameLength is the longest city name accepted, and the longest administrative area.
+	MaxCityNameLength = 100
+	// MaxCityIDLength is the longest city ID accepted.
+	MaxCityIDLength = 256
+	// CoordinatePrecision is the number of decimal places coordinates are rounded to, about 11 metres.
+	CoordinatePrecision = 4
+)
+
+// City represents city data with coordinates in decimal degrees. Cities are identified by their
+// ID, several cities may share a name, such as Paris, France and Paris, Texas.
 type City struct {
-    Name      string
-    Latitude  string
-    Longitude string
+	// ID identifies the city and does not change when it is renamed, see NewCityID.
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// CountryCode is the ISO 3166-1 alpha-2 code of the country, e.g. "FR".
+	CountryCode string `json:"countryCode,omitempty"`
+	// Admin1 is the first-level administrative area, such as a state or region, by name or code.
+	Admin1    string  `json:"admin1,omitempty"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// Elevation is the height above sea level in metres.
+	Elevation *float64 `json:"elevation,omitempty"`
+	// Timezone is the IANA time zone of the city, e.g. "Europe/Paris".
+	Timezone   string `json:"timezone,omitempty"`
+	Population int    `json:"population,omitempty"`
+	// Aliases are alternate names the city can be found by, e.g. "NYC" or "東京".
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// NewCity returns a validated city with its coordinates rounded to CoordinatePrecision decimal places,
+// see City.Normalized.
+func NewCity(name string, latitude, longitude float64, aliases ...string) (City, error) {
+	return City{Name: name, Latitude: latitude, Longitude: longitude, Aliases: aliases}.Normalized()
+}
+
+// Normalized returns the city validated, with its coordinates rounded to CoordinatePrecision
+// decimal places and its country code upper-cased. Aliases are trimmed and those matching the
+// name or an earlier alias are dropped. A city without an ID gets the one NewCityID derives.
+func (c City) Normalized() (City, error) {
+	c.Latitude = roundCoordinate(c.Latitude)
+	c.Longitude = roundCoordinate(c.Longitude)
+	c.CountryCode = strings.ToUpper(strings.TrimSpace(c.CountryCode))
+	c.Admin1 = strings.TrimSpace(c.Admin1)
+	c.Timezone = strings.TrimSpace(c.Timezone)
+	aliases := c.Aliases
+	c.Aliases = nil
+	seen := map[string]bool{NormalizeCityName(c.Name): true}
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if key := NormalizeCityName(alias); key == "" || !seen[key] {
+			seen[key] = true
+			c.Aliases = append(c.Aliases, alias)
+		}
+	}
+	if c.ID == "" {
+		c.ID = NewCityID(c.Name, c.Admin1, c.CountryCode)
+	}
+	if err := c.Validate(); err != nil {
+		return City{}, err
+	}
+	return c, nil
+}
+
+// NewCityID returns the ID of a city, the slug of its name, administrative area and country code
+// such as "paris-ile-de-france-fr" or "tokyo-jp". The area is left out when it repeats the name.
+func NewCityID(name, admin1, countryCode string) string {
+	parts := []string{name}
+	if NormalizeCityName(admin1) != NormalizeCityName(name) {
+		parts = append(parts, admin1)
+	}
+	return slug(strings.Join(append(parts, countryCode), " "))
+}
+
+// slug returns s normalized like a city name, with every run of characters other than letters
+// and digits replaced by a hyphen.
+func slug(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range NormalizeCityName(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+		} else {
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// QualifiedName returns the name of the city followed by its administrative area and country code,
+// as in "Paris, Texas, US", which tells it apart from other cities of the same name.
+func (c City) QualifiedName() string {
+	parts := []string{c.Name}
+	for _, part := range []string{c.Admin1, c.CountryCode} {
+		if part != "" && NormalizeCityName(part) != NormalizeCityName(c.Name) {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Matches reports whether every qualifier, such as "US" or "Texas", is the city's country code or
+// administrative area.
+func (c City) Matches(qualifiers []string) bool {
+	for _, qualifier := range qualifiers {
+		q := NormalizeCityName(qualifier)
+		if q != NormalizeCityName(c.CountryCode) && q != NormalizeCityName(c.Admin1) {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectCity returns the first city matching every qualifier, see City.Matches.
+func SelectCity(cities []City, qualifiers []string) (City, bool) {
+	for _, city := range cities {
+		if city.Matches(qualifiers) {
+			return city, true
+		}
+	}
+	return City{}, false
+}
+
+// SortByPopulation sorts cities most populous first, and cities of the same population by ID.
+// A name shared by several cities finds the first of them.
+func SortByPopulation(cities []City) {
+	slices.SortFunc(cities, func(a, b City) int {
+		if c := cmp.Compare(b.Population, a.Population); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+}
+
+// Location returns the time zone of the city, false if it has none or it is unknown.
+func (c City) Location() (*time.Location, bool) {
+	if c.Timezone == "" {
+		return nil, false
+	}
+	location, err := time.LoadLocation(c.Timezone)
+	return location, err == nil
+}
+
+// CountryFlag returns the flag emoji of an ISO 3166-1 alpha-2 country code, such as 🇫🇷 for "FR",
+// or an empty string for anything else.
+func CountryFlag(countryCode string) string {
+	if !isCountryCode(countryCode) {
+		return ""
+	}
+	var flag strings.Builder
+	for _, r := range strings.ToUpper(countryCode) {
+		// flags are spelled with the regional indicator symbols 🇦 to 🇿
+		flag.WriteRune(r - 'A' + '\U0001F1E6')
+	}
+	return flag.String()
+}
+
+// isCountryCode reports whether s consists of two ASCII letters.
+func isCountryCode(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks that the city has a name, an ID and coordinates within the valid ranges, and
+// that the optional fields are well-formed.
+func (c City) Validate() error {
+	name := strings.TrimSpace(c.Name)
+	if name == "" {
+		return fmt.Errorf("%w: city name is required", ErrInvalidInput)
+	}
+	if name != c.Name {
+		return fmt.Errorf("%w: city name must not start or end with spaces", ErrInvalidInput)
+	}
+	if len(name) > MaxCityNameLength {
+		return fmt.Errorf("%w: city name must be at most %d characters", ErrInvalidInput, MaxCityNameLength)
+	}
+	if err := validateCoordinate("latitude", c.Latitude, 90); err != nil {
+		return err
+	}
+	if err := validateCoordinate("longitude", c.Longitude, 180); err != nil {
+		return err
+	}
+	if c.CountryCode != "" && !isCountryCode(c.CountryCode) {
+		return fmt.Errorf("%w: country code must be two letters, got %q", ErrInvalidInput, c.CountryCode)
+	}
+	if len(c.Admin1) > MaxCityNameLength {
+		return fmt.Errorf("%w: administrative area must be at most %d characters", ErrInvalidInput, MaxCityNameLength)
+	}
+	if c.Elevation != nil && (math.IsNaN(*c.Elevation) || math.IsInf(*c.Elevation, 0)) {
+		return fmt.Errorf("%w: elevation must be a number of metres", ErrInvalidInput)
+	}
+	if _, ok := c.Location(); c.Timezone != "" && !ok {
+		return fmt.Errorf("%w: unknown time zone %q", ErrInvalidInput, c.Timezone)
+	}
+	if c.Population < 0 {
+		return fmt.Errorf("%w: population must not be negative, got %d", ErrInvalidInput, c.Population)
+	}
+	for _, alias := range c.Aliases {
+		if NormalizeCityName(alias) == "" {
+			return fmt.Errorf("%w: city aliases must not be empty", ErrInvalidInput)
+		}
+		if len(alias) > MaxCityNameLength {
+			return fmt.Errorf("%w: city aliases must be at most %d characters", ErrInvalidInput, MaxCityNameLength)
+		}
+	}
+	if c.ID == "" {
+		return fmt.Errorf("%w: city ID is required", ErrInvalidInput)
+	}
+	if len(c.ID) > MaxCityIDLength || slug(c.ID) != c.ID {
+		return fmt.Errorf("%w: city ID must be at most %d lowercase letters, digits and single hyphens, got %q", ErrInvalidInput, MaxCityIDLength, c.ID)
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a city, accepting coordinates given as numbers or, as earlier versions of
+// the API returned them, as numeric strings. Both coordinates are required and unknown fields are rejected.
+func (c *City) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ID          string       `json:"id"`
+		Name        string       `json:"name"`
+		CountryCode string       `json:"countryCode"`
+		Admin1      string       `json:"admin1"`
+		Latitude    *json.Number `json:"latitude"`
+		Longitude   *json.Number `json:"longitude"`
+		Elevation   *float64     `json:"elevation"`
+		Timezone    string       `json:"timezone"`
+		Population  int          `json:"population"`
+		Aliases     []string     `json:"aliases"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+	latitude, err := parseCoordinate("latitude", raw.Latitude)
+	if err != nil {
+		return err
+	}
+	longitude, err := parseCoordinate("longitude", raw.Longitude)
+	if err != nil {
+		return err
+	}
+	*c = City{
+		ID:          raw.ID,
+		Name:        raw.Name,
+		CountryCode: raw.CountryCode,
+		Admin1:      raw.Admin1,
+		Latitude:    latitude,
+		Longitude:   longitude,
+		Elevation:   raw.Elevation,
+		Timezone:    raw.Timezone,
+		Population:  raw.Population,
+		Aliases:     raw.Aliases,
+	}
+	return nil
+}
+
+// parseCoordinate returns the value of a coordinate decoded from JSON.
+func parseCoordinate(field string, value *json.Number) (float64, error) {
+	if value == nil {
+		return 0, fmt.Errorf("%w: %s is required", ErrInvalidInput, field)
+	}
+	degrees, err := value.Float64()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be a decimal number, got %q", ErrInvalidInput, field, value.String())
+	}
+	return degrees, nil
+}
+
+// validateCoordinate checks that degrees is a number between -limit and limit.
+func validateCoordinate(field string, degrees, limit float64) error {
+	if math.IsNaN(degrees) || degrees < -limit || degrees > limit {
+		return fmt.Errorf("%w: %s must be between %g and %g, got %g", ErrInvalidInput, field, -limit, limit, degrees)
+	}
+	return nil
+}
+
+// roundCoordinate rounds degrees to CoordinatePrecision decimal places.
+func roundCoordinate(degrees float64) float64 {
+	scale := math.Pow10(CoordinatePrecision)
+	return math.Round(degrees*scale) / scale
 }
 
 // CityRepository defines the interface for accessing city data.
+// Cities are keyed by ID and looked up by their ID, name or any alias, names compared in the form
+// NormalizeCityName returns. A name shared by several cities finds the most populous, see SortByPopulation.
 type CityRepository interface {
-    GetCity(name string) (*City, error)
-    GetAllCities() ([]City, error)
-}
\ No newline at end of file
+	// GetCity returns the city with the ID, or else the one found by name or alias.
+	GetCity(name string) (*City, error)
+	// FindCities returns every city with the name or alias, most populous first.
+	FindCities(name string) ([]City, error)
+	GetAllCities() ([]City, error)
+	// CreateCity adds a city, failing with ErrCityExists if its ID is taken.
+	CreateCity(city City) error
+	// CreateCities adds the cities whose ID is not taken in a single transaction, so either all of
+	// them or none are added, and returns how many were added.
+	CreateCities(cities []City) (int, error)
+	// UpdateCity replaces the city found like GetCity does, which may rename it or change its ID.
+	UpdateCity(name string, city City) error
+	DeleteCity(name string) error
+	// SearchCities returns up to limit cities whose name or an alias matches query, best match first.
+	SearchCities(query string, limit int) ([]City, error)
+	// Nearest returns the k cities closest to the point by great-circle distance, nearest first.
+	Nearest(latitude, longitude float64, k int) ([]NearbyCity, error)
+	// WithinRadius returns the cities at most km kilometres from the point, nearest first.
+	WithinRadius(latitude, longitude, km float64) ([]NearbyCity, error)
+}
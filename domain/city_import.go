@@ -0,0 +1,38 @@
+package domain
+
+// MaxImportErrors is the number of invalid records an ImportReport describes, the rest are only counted.
+const MaxImportErrors = 20
+
+// CityRecord is a city read from an import file.
+type CityRecord struct {
+	// Position locates the record in its file for error messages, e.g. "line 12".
+	Position string
+	City     City
+	// Err is set when the record could not be read, such as a malformed coordinate.
+	Err error
+}
+
+// ImportOptions control which records of an import are stored.
+type ImportOptions struct {
+	// MinPopulation skips cities with a smaller population.
+	MinPopulation int
+	// DryRun reports what would be imported without storing anything.
+	DryRun bool
+}
+
+// ImportReport summarises a city import. Every record read is counted in exactly one of the
+// other counters.
+type ImportReport struct {
+	Read     int  `json:"read"`
+	DryRun   bool `json:"dryRun"`
+	Imported int  `json:"imported"`
+	// Existing counts cities already in the repository, under the same ID or as a nearby city of the
+	// same name and country.
+	Existing int `json:"existing"`
+	// Duplicates counts cities sharing an ID with a more populous city of the same import.
+	Duplicates         int `json:"duplicates"`
+	BelowMinPopulation int `json:"belowMinPopulation"`
+	Invalid            int `json:"invalid"`
+	// Errors describes the first MaxImportErrors invalid records.
+	Errors []string `json:"errors,omitempty"`
+}
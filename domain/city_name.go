@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeCityName returns the form city names and aliases are matched in: compatibility
+// characters such as full-width letters are replaced, accents are stripped, case is folded and
+// whitespace is trimmed and collapsed, so "  ZÜRICH " and "zurich" match.
+func NormalizeCityName(name string) string {
+	// the transformers keep state, so a chain cannot be shared between goroutines
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.Predicate(isDiacritic)), norm.NFC, cases.Fold())
+	normalized, _, err := transform.String(t, name)
+	if err != nil {
+		normalized = strings.ToLower(name)
+	}
+	return strings.Join(strings.Fields(normalized), " ")
+}
+
+// isDiacritic reports whether r is one of the combining accents that decomposing Latin, Greek
+// and Cyrillic letters produces. Other combining marks, such as the kana voicing marks, change
+// the letter rather than accent it and are kept.
+func isDiacritic(r rune) bool {
+	return r >= '\u0300' && r <= '\u036f' && unicode.Is(unicode.Mn, r)
+}
+
+// LookupKeys returns the normalized name and aliases the city can be found by, without duplicates.
+func (c City) LookupKeys() []string {
+	keys := make([]string, 0, 1+len(c.Aliases))
+	seen := make(map[string]bool, 1+len(c.Aliases))
+	for _, name := range append([]string{c.Name}, c.Aliases...) {
+		key := NormalizeCityName(name)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
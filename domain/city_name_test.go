@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeCityName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "Tokyo", expected: "tokyo"},
+		{name: "  new   York ", expected: "new york"},
+		{name: "Zürich", expected: "zurich"},
+		{name: "Zu\u0308rich", expected: "zurich"},
+		{name: "SÃO PAULO", expected: "sao paulo"},
+		{name: "Straße", expected: "strasse"},
+		{name: "ＮＹＣ", expected: "nyc"},
+		{name: "Αθήνα", expected: "αθηνα"},
+		{name: "東京", expected: "東京"},
+		{name: "ドバイ", expected: "ドバイ"},
+		{name: " ", expected: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := NormalizeCityName(tc.name); got != tc.expected {
+				t.Errorf("Expected %q, but got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCity_LookupKeys(t *testing.T) {
+	city := City{Name: "New York", Aliases: []string{"NYC", "new york", "New York City", "nyc "}}
+	expected := []string{"new york", "nyc", "new york city"}
+	if keys := city.LookupKeys(); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected keys %q, but got %q", expected, keys)
+	}
+}
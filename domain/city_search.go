@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+const (
+	// DefaultCitySearchLimit is the number of search results returned when no limit is given.
+	DefaultCitySearchLimit = 10
+	// MaxCitySearchLimit is the largest number of search results returned.
+	MaxCitySearchLimit = 50
+)
+
+// Ranks of a lookup key matching a search query, best first. Fuzzy matches rank below
+// fuzzyMatch by their number of typos.
+const (
+	exactMatch = iota
+	prefixMatch
+	wordPrefixMatch
+	fuzzyMatch
+)
+
+// CityMatches ranks the cities identified by ID by how well their lookup keys match a search
+// query. Add every lookup key, then take the best cities with Top.
+type CityMatches[ID comparable] struct {
+	query []rune
+	best  map[ID]keyMatch
+}
+
+// keyMatch is the best match of a city's lookup keys.
+type keyMatch struct {
+	rank int
+	key  string
+}
+
+// NewCityMatches ranks cities against query, which is normalized like city names.
+func NewCityMatches[ID comparable](query string) *CityMatches[ID] {
+	return &CityMatches[ID]{
+		query: []rune(NormalizeCityName(query)),
+		best:  map[ID]keyMatch{},
+	}
+}
+
+// Add matches one lookup key of the city identified by id against the query.
+func (m *CityMatches[ID]) Add(id ID, key string) {
+	rank, ok := matchKey(m.query, key)
+	if !ok {
+		return
+	}
+	match := keyMatch{rank: rank, key: key}
+	if best, ok := m.best[id]; !ok || compareKeyMatches(match, best) < 0 {
+		m.best[id] = match
+	}
+}
+
+// Top returns the ids of the best matching cities, best first. Ties go to the shorter key,
+// which the query is closer to, then alphabetically.
+func (m *CityMatches[ID]) Top(limit int) []ID {
+	ids := make([]ID, 0, len(m.best))
+	for id := range m.best {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b ID) int {
+		return compareKeyMatches(m.best[a], m.best[b])
+	})
+	return ids[:min(limit, len(ids))]
+}
+
+func compareKeyMatches(a, b keyMatch) int {
+	if c := cmp.Compare(a.rank, b.rank); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(len(a.key), len(b.key)); c != 0 {
+		return c
+	}
+	return strings.Compare(a.key, b.key)
+}
+
+// matchKey ranks how well a lookup key matches the normalized query. Besides exact and prefix
+// matches of the key or one of its words it tolerates typos: one in queries of four to seven
+// letters, two in longer ones.
+func matchKey(query []rune, key string) (int, bool) {
+	q := string(query)
+	switch {
+	case len(query) == 0:
+		return 0, false
+	case key == q:
+		return exactMatch, true
+	case strings.HasPrefix(key, q):
+		return prefixMatch, true
+	case strings.Contains(key, " "+q) || strings.Contains(key, "-"+q):
+		return wordPrefixMatch, true
+	}
+	maxTypos := 0
+	switch {
+	case len(query) >= 8:
+		maxTypos = 2
+	case len(query) >= 4:
+		maxTypos = 1
+	}
+	if maxTypos == 0 {
+		return 0, false
+	}
+	// the query may be a misspelled prefix of the key or of one of its words, compare it with the
+	// prefix of the same length and with one letter more or less for insertions and deletions
+	runes := []rune(key)
+	typos := maxTypos + 1
+	for start := range runes {
+		if start > 0 && runes[start-1] != ' ' && runes[start-1] != '-' {
+			continue
+		}
+		word := runes[start:]
+		for n := len(query) - 1; n <= len(query)+1 && n <= len(word); n++ {
+			typos = min(typos, editDistance(query, word[:n]))
+		}
+	}
+	if typos > maxTypos {
+		return 0, false
+	}
+	return fuzzyMatch + typos, true
+}
+
+// editDistance is the optimal string alignment distance of a and b: the number of inserted,
+// deleted, substituted or swapped adjacent letters that turns one into the other.
+func editDistance(a, b []rune) int {
+	// prev2, prev and curr are the last three rows of the distance matrix
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
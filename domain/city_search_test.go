@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCityMatches(t *testing.T) {
+	keys := map[string][]string{
+		"London":         {"london"},
+		"Londonderry":    {"londonderry"},
+		"New London":     {"new london"},
+		"New York":       {"new york", "nyc", "new york city"},
+		"Newcastle":      {"newcastle"},
+		"Paris":          {"paris"},
+		"Lyon":           {"lyon"},
+		"Tokyo":          {"tokyo", "東京"},
+		"Rio de Janeiro": {"rio de janeiro", "rio"},
+	}
+	tests := []struct {
+		query    string
+		limit    int
+		expected []string
+	}{
+		{query: "London", limit: 10, expected: []string{"London", "Londonderry", "New London"}},
+		{query: "lon", limit: 10, expected: []string{"London", "Londonderry", "New London"}},
+		{query: "lon", limit: 2, expected: []string{"London", "Londonderry"}},
+		{query: "new", limit: 10, expected: []string{"New York", "Newcastle", "New London"}},
+		{query: "nyc", limit: 10, expected: []string{"New York"}},
+		{query: "Lodnon", limit: 10, expected: []string{"London", "New London", "Londonderry"}},
+		{query: "Pariss", limit: 10, expected: []string{"Paris"}},
+		{query: "tokio", limit: 10, expected: []string{"Tokyo"}},
+		{query: "東", limit: 10, expected: []string{"Tokyo"}},
+		{query: "janiero", limit: 10, expected: []string{"Rio de Janeiro"}},
+		{query: "lyn", limit: 10, expected: nil},
+		{query: "  ", limit: 10, expected: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			matches := NewCityMatches[string](tc.query)
+			for name, cityKeys := range keys {
+				for _, key := range cityKeys {
+					matches.Add(name, key)
+				}
+			}
+			got := matches.Top(tc.limit)
+			if len(got) == 0 {
+				got = nil
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected %q, but got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "london", b: "london", expected: 0},
+		{a: "lodnon", b: "london", expected: 1},
+		{a: "londn", b: "london", expected: 1},
+		{a: "lonxdon", b: "london", expected: 1},
+		{a: "paris", b: "parks", expected: 1},
+		{a: "", b: "rome", expected: 4},
+		{a: "zürich", b: "zurich", expected: 1},
+	}
+	for _, tc := range tests {
+		if got := editDistance([]rune(tc.a), []rune(tc.b)); got != tc.expected {
+			t.Errorf("Expected distance %d between %q and %q, but got %d", tc.expected, tc.a, tc.b, got)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCity_Validate(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name        string
+		city        City
+		expectedErr string
+	}{
+		{name: "Valid", city: City{ID: "berlin", Name: "Berlin", Latitude: 52.52, Longitude: 13.405}},
+		{name: "Poles and antimeridian", city: City{ID: "edge", Name: "Edge", Latitude: -90, Longitude: 180}},
+		{name: "All fields", city: City{ID: "berlin-de", Name: "Berlin", CountryCode: "DE", Admin1: "Land Berlin", Latitude: 52.52, Longitude: 13.405,
+			Elevation: new(float64), Timezone: "Europe/Berlin", Population: 3644826, Aliases: []string{"BER"}}},
+		{name: "Missing name", city: City{Name: " ", Latitude: 52.52, Longitude: 13.405}, expectedErr: "invalid input: city name is required"},
+		{name: "Padded name", city: City{Name: " Berlin", Latitude: 52.52, Longitude: 13.405}, expectedErr: "invalid input: city name must not start or end with spaces"},
+		{name: "Long name", city: City{Name: strings.Repeat("a", MaxCityNameLength+1)}, expectedErr: "invalid input: city name must be at most 100 characters"},
+		{name: "Latitude NaN", city: City{Name: "Berlin", Latitude: math.NaN(), Longitude: 13.405}, expectedErr: "invalid input: latitude must be between -90 and 90, got NaN"},
+		{name: "Latitude out of range", city: City{Name: "Berlin", Latitude: 90.5, Longitude: 13.405}, expectedErr: "invalid input: latitude must be between -90 and 90, got 90.5"},
+		{name: "Longitude out of range", city: City{Name: "Berlin", Latitude: 52.52, Longitude: -180.1}, expectedErr: "invalid input: longitude must be between -180 and 180, got -180.1"},
+		{name: "Long alias", city: City{Name: "Berlin", Aliases: []string{strings.Repeat("a", MaxCityNameLength+1)}}, expectedErr: "invalid input: city aliases must be at most 100 characters"},
+		{name: "Longitude infinite", city: City{Name: "Berlin", Latitude: 52.52, Longitude: math.Inf(1)}, expectedErr: "invalid input: longitude must be between -180 and 180, got +Inf"},
+		{name: "Missing ID", city: City{Name: "Berlin"}, expectedErr: "invalid input: city ID is required"},
+		{name: "Malformed ID", city: City{ID: "Berlin DE", Name: "Berlin"}, expectedErr: `invalid input: city ID must be at most 256 lowercase letters, digits and single hyphens, got "Berlin DE"`},
+		{name: "Country name", city: City{ID: "berlin", Name: "Berlin", CountryCode: "Germany"}, expectedErr: `invalid input: country code must be two letters, got "Germany"`},
+		{name: "Unknown time zone", city: City{ID: "berlin", Name: "Berlin", Timezone: "Europe/Atlantis"}, expectedErr: `invalid input: unknown time zone "Europe/Atlantis"`},
+		{name: "Negative population", city: City{ID: "berlin", Name: "Berlin", Population: -1}, expectedErr: "invalid input: population must not be negative, got -1"},
+		{name: "Elevation NaN", city: City{ID: "berlin", Name: "Berlin", Elevation: &nan}, expectedErr: "invalid input: elevation must be a number of metres"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.city.Validate()
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expectedErr {
+				t.Errorf("Expected error %q, but got %v", tc.expectedErr, err)
+			}
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("Expected error to be ErrInvalidInput, but got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewCity(t *testing.T) {
+	city, err := NewCity("Berlin", 52.520008, 13.404954)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := (City{ID: "berlin", Name: "Berlin", Latitude: 52.52, Longitude: 13.405}); !reflect.DeepEqual(city, expected) {
+		t.Errorf("Expected city %v, but got %v", expected, city)
+	}
+
+	city, err = NewCity("New York", 40.7128, -74.006, " NYC ", "new york", "nyc", "New York City")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"NYC", "New York City"}; !reflect.DeepEqual(city.Aliases, expected) {
+		t.Errorf("Expected aliases %q, but got %q", expected, city.Aliases)
+	}
+
+	if _, err := NewCity("Berlin", 152.52, 13.405); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected error to be ErrInvalidInput, but got %v", err)
+	}
+	if _, err := NewCity("Berlin", 52.52, 13.405, " "); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected empty alias to be rejected, but got %v", err)
+	}
+}
+
+func TestCity_Normalized(t *testing.T) {
+	city, err := City{Name: "Zürich", CountryCode: " ch", Admin1: "Zurich ", Latitude: 47.366667, Longitude: 8.55, Timezone: "Europe/Zurich"}.Normalized()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := City{ID: "zurich-ch", Name: "Zürich", CountryCode: "CH", Admin1: "Zurich", Latitude: 47.3667, Longitude: 8.55, Timezone: "Europe/Zurich"}
+	if !reflect.DeepEqual(city, expected) {
+		t.Errorf("Expected city %v, but got %v", expected, city)
+	}
+
+	city, err = City{ID: "zrh", Name: "Zürich"}.Normalized()
+	if err != nil || city.ID != "zrh" {
+		t.Errorf("Expected the given ID to be kept, but got %q, %v", city.ID, err)
+	}
+}
+
+func TestNewCityID(t *testing.T) {
+	tests := []struct {
+		name, admin1, countryCode string
+		expected                  string
+	}{
+		{name: "Paris", admin1: "Île-de-France", countryCode: "FR", expected: "paris-ile-de-france-fr"},
+		{name: "Paris", admin1: "Texas", countryCode: "US", expected: "paris-texas-us"},
+		{name: "Tokyo", admin1: "Tokyo", countryCode: "JP", expected: "tokyo-jp"},
+		{name: "St. John's", admin1: "Newfoundland and Labrador", countryCode: "CA", expected: "st-john-s-newfoundland-and-labrador-ca"},
+		{name: "東京", expected: "東京"},
+		{name: "Berlin", expected: "berlin"},
+	}
+	for _, tc := range tests {
+		if id := NewCityID(tc.name, tc.admin1, tc.countryCode); id != tc.expected {
+			t.Errorf("Expected ID %q for %s, %s, %s, but got %q", tc.expected, tc.name, tc.admin1, tc.countryCode, id)
+		}
+	}
+}
+
+func TestCity_QualifiedName(t *testing.T) {
+	tests := []struct {
+		city     City
+		expected string
+	}{
+		{city: City{Name: "Paris", CountryCode: "US", Admin1: "Texas"}, expected: "Paris, Texas, US"},
+		{city: City{Name: "Tokyo", CountryCode: "JP", Admin1: "Tokyo"}, expected: "Tokyo, JP"},
+		{city: City{Name: "Atlantis"}, expected: "Atlantis"},
+	}
+	for _, tc := range tests {
+		if name := tc.city.QualifiedName(); name != tc.expected {
+			t.Errorf("Expected %q, but got %q", tc.expected, name)
+		}
+	}
+}
+
+func TestSelectCity(t *testing.T) {
+	cities := []City{
+		{ID: "paris-ile-de-france-fr", Name: "Paris", CountryCode: "FR", Admin1: "Île-de-France"},
+		{ID: "paris-texas-us", Name: "Paris", CountryCode: "US", Admin1: "Texas"},
+		{ID: "paris-tennessee-us", Name: "Paris", CountryCode: "US", Admin1: "Tennessee"},
+	}
+	tests := []struct {
+		qualifiers []string
+		expectedID string
+	}{
+		{expectedID: "paris-ile-de-france-fr"},
+		{qualifiers: []string{"us"}, expectedID: "paris-texas-us"},
+		{qualifiers: []string{"Tennessee", "US"}, expectedID: "paris-tennessee-us"},
+		{qualifiers: []string{"ile-de-france"}, expectedID: "paris-ile-de-france-fr"},
+		{qualifiers: []string{"France"}},
+	}
+	for _, tc := range tests {
+		city, ok := SelectCity(cities, tc.qualifiers)
+		if ok != (tc.expectedID != "") || city.ID != tc.expectedID {
+			t.Errorf("%q: expected %q, but got %q, %v", tc.qualifiers, tc.expectedID, city.ID, ok)
+		}
+	}
+}
+
+func TestSortByPopulation(t *testing.T) {
+	cities := []City{{ID: "paris-texas-us", Population: 24782}, {ID: "paris-tennessee-us", Population: 10156}, {ID: "paris-fr", Population: 2138551}, {ID: "paris-ky-us", Population: 10156}}
+	SortByPopulation(cities)
+	var ids []string
+	for _, city := range cities {
+		ids = append(ids, city.ID)
+	}
+	if expected := []string{"paris-fr", "paris-texas-us", "paris-ky-us", "paris-tennessee-us"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected %q, but got %q", expected, ids)
+	}
+}
+
+func TestCountryFlag(t *testing.T) {
+	for code, expected := range map[string]string{"FR": "🇫🇷", "us": "🇺🇸", "": "", "USA": "", "1A": ""} {
+		if flag := CountryFlag(code); flag != expected {
+			t.Errorf("Expected flag %q for %q, but got %q", expected, code, flag)
+		}
+	}
+}
+
+func TestCity_UnmarshalJSON(t *testing.T) {
+	elevation := 182.0
+	tests := []struct {
+		name         string
+		body         string
+		expectedCity City
+		expectedErr  string
+	}{
+		{name: "Numbers", body: `{"name": "Berlin", "latitude": 52.52, "longitude": 13.405}`, expectedCity: City{Name: "Berlin", Latitude: 52.52, Longitude: 13.405}},
+		{name: "Strings", body: `{"name": "Berlin", "latitude": "52.5200", "longitude": "13.4050"}`, expectedCity: City{Name: "Berlin", Latitude: 52.52, Longitude: 13.405}},
+		{name: "Aliases", body: `{"name": "Tokyo", "latitude": 35.6895, "longitude": 139.6917, "aliases": ["東京"]}`, expectedCity: City{Name: "Tokyo", Latitude: 35.6895, Longitude: 139.6917, Aliases: []string{"東京"}}},
+		{
+			name: "Details",
+			body: `{"id": "paris-texas-us", "name": "Paris", "countryCode": "US", "admin1": "Texas", "latitude": 33.6609, "longitude": -95.5555,
+				"elevation": 182, "timezone": "America/Chicago", "population": 24782}`,
+			expectedCity: City{ID: "paris-texas-us", Name: "Paris", CountryCode: "US", Admin1: "Texas", Latitude: 33.6609, Longitude: -95.5555,
+				Elevation: &elevation, Timezone: "America/Chicago", Population: 24782},
+		},
+		{name: "Equator", body: `{"name": "Null Island", "latitude": 0, "longitude": "0"}`, expectedCity: City{Name: "Null Island"}},
+		{name: "Missing latitude", body: `{"name": "Berlin", "longitude": 13.405}`, expectedErr: "invalid input: latitude is required"},
+		{name: "Non-numeric string", body: `{"name": "Berlin", "latitude": "52.52&x=1", "longitude": 13.405}`, expectedErr: `cannot unmarshal string "52.52&x=1"`},
+		{name: "Unknown field", body: `{"name": "Berlin", "lat": 52.52, "latitude": 52.52, "longitude": 13.405}`, expectedErr: `unknown field "lat"`},
+		{name: "Out of float range", body: `{"name": "Berlin", "latitude": 52.52, "longitude": "1e999"}`, expectedErr: `invalid input: longitude must be a decimal number, got "1e999"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var city City
+			err := json.Unmarshal([]byte(tc.body), &city)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(city, tc.expectedCity) {
+					t.Errorf("Expected city %v, but got %v", tc.expectedCity, city)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("Expected error containing %q, but got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Aggregation selects how provider readings are combined into a consensus.
+type Aggregation string
+
+const (
+	AggregateMedian Aggregation = "median"
+	AggregateMean   Aggregation = "mean"
+)
+
+// ParseAggregation returns the aggregation with the given name, empty means median.
+func ParseAggregation(name string) (Aggregation, error) {
+	switch Aggregation(name) {
+	case "", AggregateMedian:
+		return AggregateMedian, nil
+	case AggregateMean:
+		return AggregateMean, nil
+	default:
+		return "", fmt.Errorf("%w: unknown aggregation %q", ErrInvalidInput, name)
+	}
+}
+
+// FieldSpread describes how far the providers disagree on a value.
+type FieldSpread struct {
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Range  float64 `json:"range"`
+	StdDev float64 `json:"stdDev"`
+}
+
+// ProviderWeather is the reading of a single provider, or the reason it has none.
+type ProviderWeather struct {
+	Provider string   `json:"provider"`
+	Weather  *Weather `json:"weather,omitempty"`
+	// Error is the message reported for Err, which can carry provider URLs and is left out of the JSON.
+	Error string `json:"error,omitempty"`
+	Err   error  `json:"-"`
+}
+
+// ConsensusWeather is current weather merged from several providers.
+type ConsensusWeather struct {
+	Aggregation Aggregation `json:"aggregation"`
+	// Weather holds the aggregated values.
+	Weather Weather `json:"weather"`
+	// Spread maps each numeric field, by its JSON name, to the disagreement between providers.
+	Spread    map[string]FieldSpread `json:"spread"`
+	Providers []ProviderWeather      `json:"providers"`
+}
+
+// ConsensusClient fetches current weather from several providers at once.
+type ConsensusClient interface {
+	FetchConsensusWeatherByCity(ctx context.Context, city City, units Units, aggregation Aggregation) (*ConsensusWeather, error)
+}
+
+// consensusFields are the numeric Weather fields that are aggregated, by JSON name.
+var consensusFields = []struct {
+	name  string
+	field func(*Weather) *float64
+	// angle marks compass directions, which are aggregated around the circle
+	angle bool
+}{
+	{"temperature", func(w *Weather) *float64 { return &w.Temperature }, false},
+	{"windSpeed", func(w *Weather) *float64 { return &w.WindSpeed }, false},
+	{"apparentTemperature", func(w *Weather) *float64 { return &w.ApparentTemperature }, false},
+	{"humidity", func(w *Weather) *float64 { return &w.Humidity }, false},
+	{"precipitation", func(w *Weather) *float64 { return &w.Precipitation }, false},
+	// surface pressure is left out, it follows the elevation each provider models
+	{"seaLevelPressure", func(w *Weather) *float64 { return &w.SeaLevelPressure }, false},
+	{"cloudCover", func(w *Weather) *float64 { return &w.CloudCover }, false},
+	{"windDirection", func(w *Weather) *float64 { return &w.WindDirection }, true},
+	{"windGusts", func(w *Weather) *float64 { return &w.WindGusts }, false},
+}
+
+// MergeWeather aggregates the successful provider readings. Numeric fields are combined with the
+// aggregation, the weather code is the most common one (the most severe on a tie) and the time is
+// that of the most recent reading. It returns nil if no provider has a reading.
+func MergeWeather(readings []ProviderWeather, aggregation Aggregation) *ConsensusWeather {
+	var weathers []*Weather
+	for _, r := range readings {
+		if r.Weather != nil {
+			weathers = append(weathers, r.Weather)
+		}
+	}
+	if len(weathers) == 0 {
+		return nil
+	}
+	consensus := &ConsensusWeather{
+		Aggregation: aggregation,
+		Weather: Weather{
+			City:        weathers[0].City,
+			CountryCode: weathers[0].CountryCode,
+			Units:       weathers[0].Units,
+		},
+		Spread:    make(map[string]FieldSpread, len(consensusFields)),
+		Providers: readings,
+	}
+	for _, f := range consensusFields {
+		values := make([]float64, len(weathers))
+		for i, w := range weathers {
+			values[i] = *f.field(w)
+		}
+		if !f.angle {
+			*f.field(&consensus.Weather) = aggregate(values, aggregation)
+			consensus.Spread[f.name] = spread(values)
+			continue
+		}
+		// unwrap the directions around their circular mean so 350° and 10° average to 0°
+		center := circularMean(values)
+		for i, v := range values {
+			values[i] = center + math.Remainder(v-center, 360)
+		}
+		s := spread(values)
+		s.Min, s.Max = normalizeDegrees(s.Min), normalizeDegrees(s.Max)
+		*f.field(&consensus.Weather) = normalizeDegrees(aggregate(values, aggregation))
+		consensus.Spread[f.name] = s
+	}
+	votes := make(map[int]int)
+	for _, w := range weathers {
+		if w.Time.After(consensus.Weather.Time) {
+			consensus.Weather.Time = w.Time
+		}
+		votes[w.WeatherCode]++
+	}
+	code, best := -1, 0
+	for c, n := range votes {
+		// codes without a known meaning only count when nothing else is known
+		if _, ok := weatherConditions[c]; !ok {
+			n = 0
+		}
+		if n > best || n == best && c > code {
+			code, best = c, n
+		}
+	}
+	consensus.Weather.WeatherCode = code
+	consensus.Weather.Description, consensus.Weather.Icon = DescribeWeatherCode(code)
+	return consensus
+}
+
+func aggregate(values []float64, aggregation Aggregation) float64 {
+	if aggregation == AggregateMean {
+		return mean(values)
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func spread(values []float64) FieldSpread {
+	s := FieldSpread{Min: slices.Min(values), Max: slices.Max(values)}
+	s.Range = s.Max - s.Min
+	m := mean(values)
+	var variance float64
+	for _, v := range values {
+		variance += (v - m) * (v - m)
+	}
+	s.StdDev = math.Sqrt(variance / float64(len(values)))
+	return s
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// circularMean returns the mean of compass directions in degrees.
+func circularMean(degrees []float64) float64 {
+	var x, y float64
+	for _, d := range degrees {
+		x += math.Cos(d * math.Pi / 180)
+		y += math.Sin(d * math.Pi / 180)
+	}
+	return normalizeDegrees(math.Atan2(y, x) * 180 / math.Pi)
+}
+
+// normalizeDegrees maps a direction in degrees onto [0, 360).
+func normalizeDegrees(d float64) float64 {
+	d = math.Mod(d, 360)
+	if d < 0 {
+		d += 360
+	}
+	return d
+}
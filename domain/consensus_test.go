@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMergeWeather(t *testing.T) {
+	noon := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	readings := []ProviderWeather{
+		{Provider: "a", Weather: &Weather{City: "London", Time: noon, Temperature: 10, WindDirection: 350, Humidity: 60, WeatherCode: 3, Units: Metric.Labels()}},
+		{Provider: "b", Weather: &Weather{City: "London", Time: noon.Add(time.Hour), Temperature: 12, WindDirection: 10, Humidity: 70, WeatherCode: 61, Units: Metric.Labels()}},
+		{Provider: "c", Weather: &Weather{City: "London", Time: noon, Temperature: 17, WindDirection: 20, Humidity: 80, WeatherCode: 3, Units: Metric.Labels()}},
+		{Provider: "d", Error: "upstream unavailable"},
+	}
+
+	tests := []struct {
+		name                  string
+		aggregation           Aggregation
+		expectedTemperature   float64
+		expectedWindDirection float64
+	}{
+		{name: "median", aggregation: AggregateMedian, expectedTemperature: 12, expectedWindDirection: 10},
+		{name: "mean", aggregation: AggregateMean, expectedTemperature: 13, expectedWindDirection: 6.67},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			consensus := MergeWeather(readings, tc.aggregation)
+			if consensus == nil {
+				t.Fatal("Expected a consensus, got nil")
+			}
+			w := consensus.Weather
+			if w.Temperature != tc.expectedTemperature {
+				t.Errorf("Expected temperature %v, got %v", tc.expectedTemperature, w.Temperature)
+			}
+			if math.Abs(w.WindDirection-tc.expectedWindDirection) > 0.01 {
+				t.Errorf("Expected wind direction %v, got %v", tc.expectedWindDirection, w.WindDirection)
+			}
+			if w.WeatherCode != 3 || w.Description != "Overcast" {
+				t.Errorf("Expected the most common weather code 3, got %d (%s)", w.WeatherCode, w.Description)
+			}
+			if !w.Time.Equal(noon.Add(time.Hour)) {
+				t.Errorf("Expected the most recent time, got %v", w.Time)
+			}
+			if w.City != "London" || w.Units != Metric.Labels() {
+				t.Errorf("Expected city and units of the readings, got %q and %v", w.City, w.Units)
+			}
+			if s := consensus.Spread["temperature"]; s.Min != 10 || s.Max != 17 || s.Range != 7 || math.Abs(s.StdDev-2.94) > 0.01 {
+				t.Errorf("Unexpected temperature spread %+v", s)
+			}
+			if s := consensus.Spread["windDirection"]; math.Abs(s.Min-350) > 0.01 || math.Abs(s.Max-20) > 0.01 || math.Abs(s.Range-30) > 0.01 {
+				t.Errorf("Unexpected wind direction spread %+v", s)
+			}
+			if len(consensus.Providers) != len(readings) {
+				t.Errorf("Expected all %d provider readings, got %d", len(readings), len(consensus.Providers))
+			}
+		})
+	}
+}
+
+func TestMergeWeather_NoReadings(t *testing.T) {
+	if consensus := MergeWeather([]ProviderWeather{{Provider: "a", Error: "down"}}, AggregateMedian); consensus != nil {
+		t.Errorf("Expected nil without readings, got %+v", consensus)
+	}
+}
+
+func TestMergeWeather_WeatherCodeTie(t *testing.T) {
+	readings := []ProviderWeather{
+		{Provider: "a", Weather: &Weather{WeatherCode: 1}},
+		{Provider: "b", Weather: &Weather{WeatherCode: 95}},
+		{Provider: "c", Weather: &Weather{WeatherCode: -1}},
+	}
+	if code := MergeWeather(readings, AggregateMedian).Weather.WeatherCode; code != 95 {
+		t.Errorf("Expected the most severe code on a tie, got %d", code)
+	}
+}
+
+func TestParseAggregation(t *testing.T) {
+	tests := []struct {
+		name      string
+		expected  Aggregation
+		expectErr bool
+	}{
+		{name: "", expected: AggregateMedian},
+		{name: "median", expected: AggregateMedian},
+		{name: "mean", expected: AggregateMean},
+		{name: "mode", expectErr: true},
+	}
+	for _, tc := range tests {
+		aggregation, err := ParseAggregation(tc.name)
+		if tc.expectErr != (err != nil) || aggregation != tc.expected {
+			t.Errorf("ParseAggregation(%q): expected %q (error %v), got %q, %v", tc.name, tc.expected, tc.expectErr, aggregation, err)
+		}
+	}
+}
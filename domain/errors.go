@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds shared across layers. Implementations wrap them with %w so callers
+// can classify failures with errors.Is.
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrInvalidInput        = errors.New("invalid input")
+	ErrAlreadyExists       = errors.New("already exists")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrUpstreamTimeout     = errors.New("upstream timeout")
+	ErrRateLimited         = errors.New("rate limited")
+	// ErrProviderUnavailable is returned without contacting the provider, e.g. while a circuit breaker is open.
+	ErrProviderUnavailable = errors.New("provider unavailable")
+)
+
+// ErrCityNotFound is returned by a CityRepository when no city matches the lookup.
+var ErrCityNotFound = fmt.Errorf("city %w", ErrNotFound)
+
+// ErrCityExists is returned by a CityRepository when a city with the same ID is already stored.
+var ErrCityExists = fmt.Errorf("city %w", ErrAlreadyExists)
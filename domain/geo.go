@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+const (
+	// EarthRadiusKm is the mean radius of the Earth used for great-circle distances.
+	EarthRadiusKm = 6371.0088
+	// MaxDistanceKm is half the circumference of the Earth, no two points are farther apart.
+	MaxDistanceKm = math.Pi * EarthRadiusKm
+	// DefaultNearestCities is the number of nearest cities returned when no limit is given.
+	DefaultNearestCities = 10
+	// MaxNearestCities is the largest number of nearest cities that can be requested.
+	MaxNearestCities = 50
+)
+
+// DistanceKm returns the great-circle distance in kilometres between two points given in decimal degrees.
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	φ1, φ2 := lat1*math.Pi/180, lat2*math.Pi/180
+	Δφ, Δλ := φ2-φ1, (lon2-lon1)*math.Pi/180
+	a := math.Sin(Δφ/2)*math.Sin(Δφ/2) + math.Cos(φ1)*math.Cos(φ2)*math.Sin(Δλ/2)*math.Sin(Δλ/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Sqrt(min(a, 1)))
+}
+
+// BoundingBox is a latitude and longitude range. MinLongitude is greater than MaxLongitude when
+// the box crosses the antimeridian.
+type BoundingBox struct {
+	MinLatitude, MaxLatitude   float64
+	MinLongitude, MaxLongitude float64
+}
+
+// NewBoundingBox returns the smallest box containing every point within km kilometres of the
+// given point. Around a pole it spans all longitudes.
+func NewBoundingBox(latitude, longitude, km float64) BoundingBox {
+	angle := km / EarthRadiusKm
+	degrees := angle * 180 / math.Pi
+	box := BoundingBox{
+		MinLatitude:  latitude - degrees,
+		MaxLatitude:  latitude + degrees,
+		MinLongitude: -180,
+		MaxLongitude: 180,
+	}
+	if box.MinLatitude <= -90 || box.MaxLatitude >= 90 {
+		box.MinLatitude, box.MaxLatitude = max(box.MinLatitude, -90), min(box.MaxLatitude, 90)
+		return box
+	}
+	// the circle is widest east to west north or south of its centre, where its meridians touch it
+	Δλ := math.Asin(math.Sin(angle)/math.Cos(latitude*math.Pi/180)) * 180 / math.Pi
+	box.MinLongitude, box.MaxLongitude = longitude-Δλ, longitude+Δλ
+	if box.MinLongitude < -180 {
+		box.MinLongitude += 360
+	}
+	if box.MaxLongitude > 180 {
+		box.MaxLongitude -= 360
+	}
+	return box
+}
+
+// LongitudeRanges returns the longitude range of the box, split in two at the antimeridian if it crosses it.
+func (b BoundingBox) LongitudeRanges() [][2]float64 {
+	if b.MinLongitude <= b.MaxLongitude {
+		return [][2]float64{{b.MinLongitude, b.MaxLongitude}}
+	}
+	return [][2]float64{{b.MinLongitude, 180}, {-180, b.MaxLongitude}}
+}
+
+// NewLocation returns an ad-hoc city at the given coordinates, such as a device's GPS position,
+// validated and rounded like NewCity. It is named after its rounded coordinates.
+func NewLocation(latitude, longitude float64) (City, error) {
+	name := fmt.Sprintf("%g,%g", roundCoordinate(latitude), roundCoordinate(longitude))
+	return NewCity(name, latitude, longitude)
+}
+
+// NearbyCity is a known city together with its distance from a location.
+type NearbyCity struct {
+	City       City    `json:"city"`
+	DistanceKm float64 `json:"distanceKm"`
+}
+
+// LocationWeather is the current weather at an ad-hoc location.
+type LocationWeather struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// NearestCity is the known city closest to the location, nil if no cities are known.
+	NearestCity *NearbyCity `json:"nearestCity,omitempty"`
+	Weather     *Weather    `json:"weather"`
+}
+
+// SortByDistance sorts cities nearest first, and cities at the same distance by name.
+func SortByDistance(cities []NearbyCity) {
+	slices.SortFunc(cities, func(a, b NearbyCity) int {
+		return cmp.Or(cmp.Compare(a.DistanceKm, b.DistanceKm), strings.Compare(a.City.Name, b.City.Name))
+	})
+}
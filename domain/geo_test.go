@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestDistanceKm(t *testing.T) {
+	testCases := []struct {
+		name       string
+		lat1, lon1 float64
+		lat2, lon2 float64
+		expected   float64
+	}{
+		{name: "same point", lat1: 51.5074, lon1: -0.1278, lat2: 51.5074, lon2: -0.1278, expected: 0},
+		{name: "London to Paris", lat1: 51.5074, lon1: -0.1278, lat2: 48.8566, lon2: 2.3522, expected: 343.5},
+		{name: "across the antimeridian", lat1: 0, lon1: 179.5, lat2: 0, lon2: -179.5, expected: 111.2},
+		{name: "antipodes", lat1: 0, lon1: 0, lat2: 0, lon2: 180, expected: math.Pi * EarthRadiusKm},
+	}
+	for _, tc := range testCases {
+		if got := DistanceKm(tc.lat1, tc.lon1, tc.lat2, tc.lon2); math.Abs(got-tc.expected) > 0.1 {
+			t.Errorf("%s: expected %.1f km, but got %.1f km", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestNewLocation(t *testing.T) {
+	location, err := NewLocation(48.856614, 2.3522219)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := City{Name: "48.8566,2.3522", Latitude: 48.8566, Longitude: 2.3522}
+	if location.Name != expected.Name || location.Latitude != expected.Latitude || location.Longitude != expected.Longitude {
+		t.Errorf("Expected location %v, but got %v", expected, location)
+	}
+
+	for _, coordinates := range [][2]float64{{91, 0}, {0, -181}, {math.NaN(), 0}} {
+		if _, err := NewLocation(coordinates[0], coordinates[1]); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput for %v, but got %v", coordinates, err)
+		}
+	}
+}
+
+func TestNewBoundingBox(t *testing.T) {
+	degree := EarthRadiusKm * math.Pi / 180
+	testCases := []struct {
+		name                    string
+		latitude, longitude, km float64
+		expected                BoundingBox
+		ranges                  int
+	}{
+		{name: "equator", latitude: 0, longitude: 0, km: degree, expected: BoundingBox{-1, 1, -1, 1}, ranges: 1},
+		{name: "across the antimeridian", latitude: 0, longitude: 179.5, km: degree, expected: BoundingBox{-1, 1, 178.5, -179.5}, ranges: 2},
+		{name: "around the north pole", latitude: 89.5, longitude: 10, km: degree, expected: BoundingBox{88.5, 90, -180, 180}, ranges: 1},
+		{name: "whole earth", latitude: 10, longitude: 10, km: MaxDistanceKm, expected: BoundingBox{-90, 90, -180, 180}, ranges: 1},
+	}
+	for _, tc := range testCases {
+		box := NewBoundingBox(tc.latitude, tc.longitude, tc.km)
+		for _, v := range []struct{ got, want float64 }{
+			{box.MinLatitude, tc.expected.MinLatitude}, {box.MaxLatitude, tc.expected.MaxLatitude},
+			{box.MinLongitude, tc.expected.MinLongitude}, {box.MaxLongitude, tc.expected.MaxLongitude},
+		} {
+			if math.Abs(v.got-v.want) > 1e-9 {
+				t.Errorf("%s: expected box %v, but got %v", tc.name, tc.expected, box)
+				break
+			}
+		}
+		if n := len(box.LongitudeRanges()); n != tc.ranges {
+			t.Errorf("%s: expected %d longitude ranges, but got %d", tc.name, tc.ranges, n)
+		}
+	}
+}
+
+func TestNewBoundingBox_ContainsCircle(t *testing.T) {
+	for _, centre := range [][2]float64{{0, 0}, {51.5, -0.13}, {-33.9, 151.2}, {64.1, -21.9}, {-45, 179.9}} {
+		for _, km := range []float64{1, 50, 500, 2000} {
+			box := NewBoundingBox(centre[0], centre[1], km)
+			for bearing := 0.0; bearing < 360; bearing += 5 {
+				lat, lon := destination(centre[0], centre[1], bearing, km*0.999)
+				if !box.contains(lat, lon) {
+					t.Errorf("Expected box %v around %v to contain %v,%v at %g km, bearing %g", box, centre, lat, lon, km, bearing)
+				}
+			}
+		}
+	}
+}
+
+// destination returns the point km kilometres from the start in the direction of bearing, in degrees from north.
+func destination(latitude, longitude, bearing, km float64) (float64, float64) {
+	φ1, λ1, θ, δ := latitude*math.Pi/180, longitude*math.Pi/180, bearing*math.Pi/180, km/EarthRadiusKm
+	φ2 := math.Asin(math.Sin(φ1)*math.Cos(δ) + math.Cos(φ1)*math.Sin(δ)*math.Cos(θ))
+	λ2 := λ1 + math.Atan2(math.Sin(θ)*math.Sin(δ)*math.Cos(φ1), math.Cos(δ)-math.Sin(φ1)*math.Sin(φ2))
+	lon := math.Mod(λ2*180/math.Pi+540, 360) - 180
+	return φ2 * 180 / math.Pi, lon
+}
+
+func (b BoundingBox) contains(latitude, longitude float64) bool {
+	if latitude < b.MinLatitude || latitude > b.MaxLatitude {
+		return false
+	}
+	for _, r := range b.LongitudeRanges() {
+		if longitude >= r[0] && longitude <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSortByDistance(t *testing.T) {
+	cities := []NearbyCity{
+		{City: City{Name: "Paris"}, DistanceKm: 343.5},
+		{City: City{Name: "Reading"}, DistanceKm: 58},
+		{City: City{Name: "Oxford"}, DistanceKm: 80.2},
+		{City: City{Name: "Luton"}, DistanceKm: 58},
+	}
+	SortByDistance(cities)
+	var names []string
+	for _, city := range cities {
+		names = append(names, city.City.Name)
+	}
+	if expected := []string{"Luton", "Reading", "Oxford", "Paris"}; !slices.Equal(names, expected) {
+		t.Errorf("Expected %v, but got %v", expected, names)
+	}
+}
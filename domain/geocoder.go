@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"context"
+	"strings"
+)
+
+// Place is a location found by a Geocoder.
+type Place struct {
+	Name        string
+	Latitude    float64
+	Longitude   float64
+	Country     string
+	CountryCode string
+	// Admin1 is the first-level administrative area, such as a state or region.
+	Admin1     string
+	Population int
+	// Elevation is the height above sea level in metres, nil if unknown.
+	Elevation *float64
+	// Timezone is the IANA time zone of the place.
+	Timezone string
+}
+
+// Geocoder resolves place names to coordinates.
+type Geocoder interface {
+	// Geocode returns the places matching name, most relevant first, or none if there is no match.
+	Geocode(ctx context.Context, name string) ([]Place, error)
+}
+
+// ParsePlaceQuery splits a query like "Springfield, Illinois, US" into the place name and the
+// qualifiers that disambiguate it, the country or administrative area.
+func ParsePlaceQuery(query string) (name string, qualifiers []string) {
+	parts := strings.Split(query, ",")
+	for _, part := range parts[1:] {
+		if qualifier := strings.TrimSpace(part); qualifier != "" {
+			qualifiers = append(qualifiers, qualifier)
+		}
+	}
+	return strings.TrimSpace(parts[0]), qualifiers
+}
+
+// SelectPlace returns the first place matching every qualifier by its country, country code or
+// administrative area.
+func SelectPlace(places []Place, qualifiers []string) (Place, bool) {
+	for _, place := range places {
+		if place.matches(qualifiers) {
+			return place, true
+		}
+	}
+	return Place{}, false
+}
+
+func (p Place) matches(qualifiers []string) bool {
+	for _, qualifier := range qualifiers {
+		q := NormalizeCityName(qualifier)
+		if q != NormalizeCityName(p.Country) && q != NormalizeCityName(p.CountryCode) && q != NormalizeCityName(p.Admin1) {
+			return false
+		}
+	}
+	return true
+}
+
+// City returns the place as a city with the given aliases.
+func (p Place) City(aliases ...string) (City, error) {
+	return City{
+		Name:        p.Name,
+		CountryCode: p.CountryCode,
+		Admin1:      p.Admin1,
+		Latitude:    p.Latitude,
+		Longitude:   p.Longitude,
+		Elevation:   p.Elevation,
+		Timezone:    p.Timezone,
+		Population:  p.Population,
+		Aliases:     aliases,
+	}.Normalized()
+}
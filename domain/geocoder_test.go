@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePlaceQuery(t *testing.T) {
+	tests := []struct {
+		query              string
+		expectedName       string
+		expectedQualifiers []string
+	}{
+		{query: "Zurich", expectedName: "Zurich"},
+		{query: " Paris , US ", expectedName: "Paris", expectedQualifiers: []string{"US"}},
+		{query: "Springfield, Illinois,, United States", expectedName: "Springfield", expectedQualifiers: []string{"Illinois", "United States"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			name, qualifiers := ParsePlaceQuery(tc.query)
+			if name != tc.expectedName {
+				t.Errorf("Expected name %q, but got %q", tc.expectedName, name)
+			}
+			if !reflect.DeepEqual(qualifiers, tc.expectedQualifiers) {
+				t.Errorf("Expected qualifiers %q, but got %q", tc.expectedQualifiers, qualifiers)
+			}
+		})
+	}
+}
+
+func TestSelectPlace(t *testing.T) {
+	places := []Place{
+		{Name: "Paris", Country: "France", CountryCode: "FR", Admin1: "Île-de-France"},
+		{Name: "Paris", Country: "United States", CountryCode: "US", Admin1: "Texas"},
+		{Name: "Paris", Country: "United States", CountryCode: "US", Admin1: "Tennessee"},
+	}
+	tests := []struct {
+		name          string
+		qualifiers    []string
+		expectedIndex int
+	}{
+		{name: "No qualifiers", qualifiers: nil, expectedIndex: 0},
+		{name: "Country code", qualifiers: []string{"us"}, expectedIndex: 1},
+		{name: "Admin area", qualifiers: []string{"Tennessee"}, expectedIndex: 2},
+		{name: "Admin area and country", qualifiers: []string{"ILE-DE-FRANCE", "France"}, expectedIndex: 0},
+		{name: "No match", qualifiers: []string{"Canada"}, expectedIndex: -1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			place, ok := SelectPlace(places, tc.qualifiers)
+			if tc.expectedIndex < 0 {
+				if ok {
+					t.Errorf("Expected no place, but got %v", place)
+				}
+				return
+			}
+			if !ok || place != places[tc.expectedIndex] {
+				t.Errorf("Expected %v, but got %v", places[tc.expectedIndex], place)
+			}
+		})
+	}
+}
+
+func TestPlace_City(t *testing.T) {
+	elevation := 182.0
+	place := Place{
+		Name: "Paris", Latitude: 33.66094, Longitude: -95.55551, Country: "United States", CountryCode: "US",
+		Admin1: "Texas", Population: 24782, Elevation: &elevation, Timezone: "America/Chicago",
+	}
+
+	city, err := place.City("paris", "Paris, Lamar County")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := City{
+		ID: "paris-texas-us", Name: "Paris", CountryCode: "US", Admin1: "Texas", Latitude: 33.6609, Longitude: -95.5555,
+		Elevation: &elevation, Timezone: "America/Chicago", Population: 24782, Aliases: []string{"Paris, Lamar County"},
+	}
+	if !reflect.DeepEqual(city, expected) {
+		t.Errorf("Expected %v, but got %v", expected, city)
+	}
+}
@@ -0,0 +1,13 @@
+package domain
+
+// Health is the reported health of a component the application depends on.
+type Health struct {
+	Healthy bool   `json:"healthy"`
+	State   string `json:"state,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// HealthReporter is implemented by components that can report their health.
+type HealthReporter interface {
+	Health() Health
+}
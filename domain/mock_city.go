@@ -38,6 +38,64 @@ func (m *MockCityRepository) EXPECT() *MockCityRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CreateCities mocks base method.
+func (m *MockCityRepository) CreateCities(cities []City) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCities", cities)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCities indicates an expected call of CreateCities.
+func (mr *MockCityRepositoryMockRecorder) CreateCities(cities any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCities", reflect.TypeOf((*MockCityRepository)(nil).CreateCities), cities)
+}
+
+// CreateCity mocks base method.
+func (m *MockCityRepository) CreateCity(city City) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCity", city)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCity indicates an expected call of CreateCity.
+func (mr *MockCityRepositoryMockRecorder) CreateCity(city any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCity", reflect.TypeOf((*MockCityRepository)(nil).CreateCity), city)
+}
+
+// DeleteCity mocks base method.
+func (m *MockCityRepository) DeleteCity(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCity", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCity indicates an expected call of DeleteCity.
+func (mr *MockCityRepositoryMockRecorder) DeleteCity(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCity", reflect.TypeOf((*MockCityRepository)(nil).DeleteCity), name)
+}
+
+// FindCities mocks base method.
+func (m *MockCityRepository) FindCities(name string) ([]City, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCities", name)
+	ret0, _ := ret[0].([]City)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCities indicates an expected call of FindCities.
+func (mr *MockCityRepositoryMockRecorder) FindCities(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCities", reflect.TypeOf((*MockCityRepository)(nil).FindCities), name)
+}
+
 // GetAllCities mocks base method.
 func (m *MockCityRepository) GetAllCities() ([]City, error) {
 	m.ctrl.T.Helper()
@@ -67,3 +125,62 @@ func (mr *MockCityRepositoryMockRecorder) GetCity(name any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCity", reflect.TypeOf((*MockCityRepository)(nil).GetCity), name)
 }
+
+// Nearest mocks base method.
+func (m *MockCityRepository) Nearest(latitude, longitude float64, k int) ([]NearbyCity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Nearest", latitude, longitude, k)
+	ret0, _ := ret[0].([]NearbyCity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Nearest indicates an expected call of Nearest.
+func (mr *MockCityRepositoryMockRecorder) Nearest(latitude, longitude, k any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Nearest", reflect.TypeOf((*MockCityRepository)(nil).Nearest), latitude, longitude, k)
+}
+
+// SearchCities mocks base method.
+func (m *MockCityRepository) SearchCities(query string, limit int) ([]City, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchCities", query, limit)
+	ret0, _ := ret[0].([]City)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchCities indicates an expected call of SearchCities.
+func (mr *MockCityRepositoryMockRecorder) SearchCities(query, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchCities", reflect.TypeOf((*MockCityRepository)(nil).SearchCities), query, limit)
+}
+
+// UpdateCity mocks base method.
+func (m *MockCityRepository) UpdateCity(name string, city City) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCity", name, city)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCity indicates an expected call of UpdateCity.
+func (mr *MockCityRepositoryMockRecorder) UpdateCity(name, city any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCity", reflect.TypeOf((*MockCityRepository)(nil).UpdateCity), name, city)
+}
+
+// WithinRadius mocks base method.
+func (m *MockCityRepository) WithinRadius(latitude, longitude, km float64) ([]NearbyCity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinRadius", latitude, longitude, km)
+	ret0, _ := ret[0].([]NearbyCity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WithinRadius indicates an expected call of WithinRadius.
+func (mr *MockCityRepositoryMockRecorder) WithinRadius(latitude, longitude, km any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinRadius", reflect.TypeOf((*MockCityRepository)(nil).WithinRadius), latitude, longitude, km)
+}
@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: consensus.go
+//
+// Generated by this command:
+//
+//	mockgen -source consensus.go -destination mock_consensus.go -package domain
+//
+
+// Package domain is a generated GoMock package.
+package domain
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockConsensusClient is a mock of ConsensusClient interface.
+type MockConsensusClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockConsensusClientMockRecorder
+}
+
+// MockConsensusClientMockRecorder is the mock recorder for MockConsensusClient.
+type MockConsensusClientMockRecorder struct {
+	mock *MockConsensusClient
+}
+
+// NewMockConsensusClient creates a new mock instance.
+func NewMockConsensusClient(ctrl *gomock.Controller) *MockConsensusClient {
+	mock := &MockConsensusClient{ctrl: ctrl}
+	mock.recorder = &MockConsensusClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockConsensusClient) EXPECT() *MockConsensusClientMockRecorder {
+	return m.recorder
+}
+
+// FetchConsensusWeatherByCity mocks base method.
+func (m *MockConsensusClient) FetchConsensusWeatherByCity(ctx context.Context, city City, units Units, aggregation Aggregation) (*ConsensusWeather, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchConsensusWeatherByCity", ctx, city, units, aggregation)
+	ret0, _ := ret[0].(*ConsensusWeather)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchConsensusWeatherByCity indicates an expected call of FetchConsensusWeatherByCity.
+func (mr *MockConsensusClientMockRecorder) FetchConsensusWeatherByCity(ctx, city, units, aggregation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchConsensusWeatherByCity", reflect.TypeOf((*MockConsensusClient)(nil).FetchConsensusWeatherByCity), ctx, city, units, aggregation)
+}
@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: geocoder.go
+//
+// Generated by this command:
+//
+//	mockgen -source geocoder.go -destination mock_geocoder.go -package domain
+//
+
+// Package domain is a generated GoMock package.
+package domain
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockGeocoder is a mock of Geocoder interface.
+type MockGeocoder struct {
+	ctrl     *gomock.Controller
+	recorder *MockGeocoderMockRecorder
+}
+
+// MockGeocoderMockRecorder is the mock recorder for MockGeocoder.
+type MockGeocoderMockRecorder struct {
+	mock *MockGeocoder
+}
+
+// NewMockGeocoder creates a new mock instance.
+func NewMockGeocoder(ctrl *gomock.Controller) *MockGeocoder {
+	mock := &MockGeocoder{ctrl: ctrl}
+	mock.recorder = &MockGeocoderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGeocoder) EXPECT() *MockGeocoderMockRecorder {
+	return m.recorder
+}
+
+// Geocode mocks base method.
+func (m *MockGeocoder) Geocode(ctx context.Context, name string) ([]Place, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Geocode", ctx, name)
+	ret0, _ := ret[0].([]Place)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Geocode indicates an expected call of Geocode.
+func (mr *MockGeocoderMockRecorder) Geocode(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Geocode", reflect.TypeOf((*MockGeocoder)(nil).Geocode), ctx, name)
+}
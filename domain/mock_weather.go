@@ -39,17 +39,130 @@ func (m *MockWeatherClient) EXPECT() *MockWeatherClientMockRecorder {
 	return m.recorder
 }
 
+// FetchDailyForecastByCity mocks base method.
+func (m *MockWeatherClient) FetchDailyForecastByCity(ctx context.Context, city City, days int, units Units) (*DailyForecast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchDailyForecastByCity", ctx, city, days, units)
+	ret0, _ := ret[0].(*DailyForecast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchDailyForecastByCity indicates an expected call of FetchDailyForecastByCity.
+func (mr *MockWeatherClientMockRecorder) FetchDailyForecastByCity(ctx, city, days, units any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchDailyForecastByCity", reflect.TypeOf((*MockWeatherClient)(nil).FetchDailyForecastByCity), ctx, city, days, units)
+}
+
+// FetchForecastByCity mocks base method.
+func (m *MockWeatherClient) FetchForecastByCity(ctx context.Context, city City, hours int, units Units) (*Forecast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchForecastByCity", ctx, city, hours, units)
+	ret0, _ := ret[0].(*Forecast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchForecastByCity indicates an expected call of FetchForecastByCity.
+func (mr *MockWeatherClientMockRecorder) FetchForecastByCity(ctx, city, hours, units any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchForecastByCity", reflect.TypeOf((*MockWeatherClient)(nil).FetchForecastByCity), ctx, city, hours, units)
+}
+
+// FetchWeatherByCity mocks base method.
+func (m *MockWeatherClient) FetchWeatherByCity(ctx context.Context, city City, units Units) (*Weather, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchWeatherByCity", ctx, city, units)
+	ret0, _ := ret[0].(*Weather)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchWeatherByCity indicates an expected call of FetchWeatherByCity.
+func (mr *MockWeatherClientMockRecorder) FetchWeatherByCity(ctx, city, units any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchWeatherByCity", reflect.TypeOf((*MockWeatherClient)(nil).FetchWeatherByCity), ctx, city, units)
+}
+
+// MockBatchWeatherClient is a mock of BatchWeatherClient interface.
+type MockBatchWeatherClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockBatchWeatherClientMockRecorder
+}
+
+// MockBatchWeatherClientMockRecorder is the mock recorder for MockBatchWeatherClient.
+type MockBatchWeatherClientMockRecorder struct {
+	mock *MockBatchWeatherClient
+}
+
+// NewMockBatchWeatherClient creates a new mock instance.
+func NewMockBatchWeatherClient(ctrl *gomock.Controller) *MockBatchWeatherClient {
+	mock := &MockBatchWeatherClient{ctrl: ctrl}
+	mock.recorder = &MockBatchWeatherClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBatchWeatherClient) EXPECT() *MockBatchWeatherClientMockRecorder {
+	return m.recorder
+}
+
+// FetchDailyForecastByCity mocks base method.
+func (m *MockBatchWeatherClient) FetchDailyForecastByCity(ctx context.Context, city City, days int, units Units) (*DailyForecast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchDailyForecastByCity", ctx, city, days, units)
+	ret0, _ := ret[0].(*DailyForecast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchDailyForecastByCity indicates an expected call of FetchDailyForecastByCity.
+func (mr *MockBatchWeatherClientMockRecorder) FetchDailyForecastByCity(ctx, city, days, units any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchDailyForecastByCity", reflect.TypeOf((*MockBatchWeatherClient)(nil).FetchDailyForecastByCity), ctx, city, days, units)
+}
+
+// FetchForecastByCity mocks base method.
+func (m *MockBatchWeatherClient) FetchForecastByCity(ctx context.Context, city City, hours int, units Units) (*Forecast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchForecastByCity", ctx, city, hours, units)
+	ret0, _ := ret[0].(*Forecast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchForecastByCity indicates an expected call of FetchForecastByCity.
+func (mr *MockBatchWeatherClientMockRecorder) FetchForecastByCity(ctx, city, hours, units any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchForecastByCity", reflect.TypeOf((*MockBatchWeatherClient)(nil).FetchForecastByCity), ctx, city, hours, units)
+}
+
+// FetchWeatherByCities mocks base method.
+func (m *MockBatchWeatherClient) FetchWeatherByCities(ctx context.Context, cities []City, units Units) ([]*Weather, []error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchWeatherByCities", ctx, cities, units)
+	ret0, _ := ret[0].([]*Weather)
+	ret1, _ := ret[1].([]error)
+	return ret0, ret1
+}
+
+// FetchWeatherByCities indicates an expected call of FetchWeatherByCities.
+func (mr *MockBatchWeatherClientMockRecorder) FetchWeatherByCities(ctx, cities, units any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchWeatherByCities", reflect.TypeOf((*MockBatchWeatherClient)(nil).FetchWeatherByCities), ctx, cities, units)
+}
+
 // FetchWeatherByCity mocks base method.
-func (m *MockWeatherClient) FetchWeatherByCity(ctx context.Context, city City) (*Weather, error) {
+func (m *MockBatchWeatherClient) FetchWeatherByCity(ctx context.Context, city City, units Units) (*Weather, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FetchWeatherByCity", ctx, city)
+	ret := m.ctrl.Call(m, "FetchWeatherByCity", ctx, city, units)
 	ret0, _ := ret[0].(*Weather)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FetchWeatherByCity indicates an expected call of FetchWeatherByCity.
-func (mr *MockWeatherClientMockRecorder) FetchWeatherByCity(ctx, city any) *gomock.Call {
+func (mr *MockBatchWeatherClientMockRecorder) FetchWeatherByCity(ctx, city, units any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchWeatherByCity", reflect.TypeOf((*MockWeatherClient)(nil).FetchWeatherByCity), ctx, city)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchWeatherByCity", reflect.TypeOf((*MockBatchWeatherClient)(nil).FetchWeatherByCity), ctx, city, units)
 }
@@ -0,0 +1,142 @@
+package domain
+
+import "fmt"
+
+type (
+	TemperatureUnit   string
+	WindSpeedUnit     string
+	PrecipitationUnit string
+)
+
+// Supported units. The values match the unit names understood by Open-Meteo.
+const (
+	Celsius    TemperatureUnit = "celsius"
+	Fahrenheit TemperatureUnit = "fahrenheit"
+
+	KilometresPerHour WindSpeedUnit = "kmh"
+	MetresPerSecond   WindSpeedUnit = "ms"
+	MilesPerHour      WindSpeedUnit = "mph"
+	Knots             WindSpeedUnit = "kn"
+
+	Millimetres PrecipitationUnit = "mm"
+	Inches      PrecipitationUnit = "inch"
+)
+
+var (
+	temperatureLabels   = map[TemperatureUnit]string{Celsius: "°C", Fahrenheit: "°F"}
+	windSpeedLabels     = map[WindSpeedUnit]string{KilometresPerHour: "km/h", MetresPerSecond: "m/s", MilesPerHour: "mph", Knots: "kn"}
+	precipitationLabels = map[PrecipitationUnit]string{Millimetres: "mm", Inches: "in"}
+)
+
+// Units selects the units weather values are reported in. Empty fields fall back to metric.
+type Units struct {
+	Temperature   TemperatureUnit
+	WindSpeed     WindSpeedUnit
+	Precipitation PrecipitationUnit
+}
+
+// UnitLabels are the display labels of the units a response is expressed in.
+type UnitLabels struct {
+	Temperature   string `json:"temperature"`
+	WindSpeed     string `json:"windSpeed"`
+	Precipitation string `json:"precipitation"`
+}
+
+var (
+	// Metric is the default unit system.
+	Metric = Units{Temperature: Celsius, WindSpeed: KilometresPerHour, Precipitation: Millimetres}
+	// Imperial reports temperatures in Fahrenheit, wind speed in mph and precipitation in inches.
+	Imperial = Units{Temperature: Fahrenheit, WindSpeed: MilesPerHour, Precipitation: Inches}
+)
+
+// ParseUnits builds Units from a unit system name ("metric" or "imperial", empty means metric)
+// and optional per-variable overrides.
+func ParseUnits(system, temperature, windSpeed, precipitation string) (Units, error) {
+	var units Units
+	switch system {
+	case "", "metric":
+		units = Metric
+	case "imperial":
+		units = Imperial
+	default:
+		return Units{}, fmt.Errorf("%w: unknown unit system %q", ErrInvalidInput, system)
+	}
+	if temperature != "" {
+		units.Temperature = TemperatureUnit(temperature)
+	}
+	if windSpeed != "" {
+		units.WindSpeed = WindSpeedUnit(windSpeed)
+	}
+	if precipitation != "" {
+		units.Precipitation = PrecipitationUnit(precipitation)
+	}
+	return units, units.Validate()
+}
+
+// Validate reports an error if any of the units is not supported.
+func (u Units) Validate() error {
+	if _, ok := temperatureLabels[u.Temperature]; u.Temperature != "" && !ok {
+		return fmt.Errorf("%w: unknown temperature unit %q", ErrInvalidInput, u.Temperature)
+	}
+	if _, ok := windSpeedLabels[u.WindSpeed]; u.WindSpeed != "" && !ok {
+		return fmt.Errorf("%w: unknown wind speed unit %q", ErrInvalidInput, u.WindSpeed)
+	}
+	if _, ok := precipitationLabels[u.Precipitation]; u.Precipitation != "" && !ok {
+		return fmt.Errorf("%w: unknown precipitation unit %q", ErrInvalidInput, u.Precipitation)
+	}
+	return nil
+}
+
+// Labels returns the display labels for the units.
+func (u Units) Labels() UnitLabels {
+	u = u.withDefaults()
+	return UnitLabels{
+		Temperature:   temperatureLabels[u.Temperature],
+		WindSpeed:     windSpeedLabels[u.WindSpeed],
+		Precipitation: precipitationLabels[u.Precipitation],
+	}
+}
+
+// withDefaults fills empty fields with their metric unit.
+func (u Units) withDefaults() Units {
+	if u.Temperature == "" {
+		u.Temperature = Metric.Temperature
+	}
+	if u.WindSpeed == "" {
+		u.WindSpeed = Metric.WindSpeed
+	}
+	if u.Precipitation == "" {
+		u.Precipitation = Metric.Precipitation
+	}
+	return u
+}
+
+// FromCelsius converts a temperature in degrees Celsius to the unit. An empty unit means Celsius.
+func (u TemperatureUnit) FromCelsius(c float64) float64 {
+	if u == Fahrenheit {
+		return c*9/5 + 32
+	}
+	return c
+}
+
+// FromMetresPerSecond converts a wind speed in m/s to the unit. An empty unit means km/h.
+func (u WindSpeedUnit) FromMetresPerSecond(v float64) float64 {
+	switch u {
+	case MetresPerSecond:
+		return v
+	case MilesPerHour:
+		return v * 3600 / 1609.344
+	case Knots:
+		return v * 3600 / 1852
+	default:
+		return v * 3.6
+	}
+}
+
+// FromMillimetres converts a precipitation amount in mm to the unit. An empty unit means mm.
+func (u PrecipitationUnit) FromMillimetres(v float64) float64 {
+	if u == Inches {
+		return v / 25.4
+	}
+	return v
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		name          string
+		system        string
+		temperature   string
+		windSpeed     string
+		precipitation string
+		expected      Units
+		expectErr     bool
+	}{
+		{name: "default is metric", expected: Metric},
+		{name: "metric", system: "metric", expected: Metric},
+		{name: "imperial", system: "imperial", expected: Imperial},
+		{
+			name:      "imperial with wind speed override",
+			system:    "imperial",
+			windSpeed: "kn",
+			expected:  Units{Temperature: Fahrenheit, WindSpeed: Knots, Precipitation: Inches},
+		},
+		{
+			name:          "custom overrides on metric",
+			temperature:   "fahrenheit",
+			precipitation: "inch",
+			expected:      Units{Temperature: Fahrenheit, WindSpeed: KilometresPerHour, Precipitation: Inches},
+		},
+		{name: "unknown system", system: "nautical", expectErr: true},
+		{name: "unknown temperature unit", temperature: "kelvin", expectErr: true},
+		{name: "unknown wind speed unit", windSpeed: "beaufort", expectErr: true},
+		{name: "unknown precipitation unit", precipitation: "cm", expectErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			units, err := ParseUnits(tc.system, tc.temperature, tc.windSpeed, tc.precipitation)
+			if tc.expectErr {
+				if !errors.Is(err, ErrInvalidInput) {
+					t.Errorf("Expected invalid input error, but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if units != tc.expected {
+				t.Errorf("Expected units %v, got %v", tc.expected, units)
+			}
+		})
+	}
+}
+
+func TestUnits_Labels(t *testing.T) {
+	tests := []struct {
+		name     string
+		units    Units
+		expected UnitLabels
+	}{
+		{name: "metric", units: Metric, expected: UnitLabels{Temperature: "°C", WindSpeed: "km/h", Precipitation: "mm"}},
+		{name: "imperial", units: Imperial, expected: UnitLabels{Temperature: "°F", WindSpeed: "mph", Precipitation: "in"}},
+		{name: "zero value is metric", units: Units{}, expected: UnitLabels{Temperature: "°C", WindSpeed: "km/h", Precipitation: "mm"}},
+		{name: "custom", units: Units{WindSpeed: MetresPerSecond}, expected: UnitLabels{Temperature: "°C", WindSpeed: "m/s", Precipitation: "mm"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if labels := tc.units.Labels(); !reflect.DeepEqual(labels, tc.expected) {
+				t.Errorf("Expected labels %v, got %v", tc.expected, labels)
+			}
+		})
+	}
+}
+
+func TestUnits_Conversions(t *testing.T) {
+	tests := []struct {
+		name     string
+		convert  func(float64) float64
+		value    float64
+		expected float64
+	}{
+		{name: "celsius", convert: Celsius.FromCelsius, value: 20, expected: 20},
+		{name: "fahrenheit", convert: Fahrenheit.FromCelsius, value: 20, expected: 68},
+		{name: "default temperature", convert: TemperatureUnit("").FromCelsius, value: -5, expected: -5},
+		{name: "km/h", convert: KilometresPerHour.FromMetresPerSecond, value: 10, expected: 36},
+		{name: "m/s", convert: MetresPerSecond.FromMetresPerSecond, value: 10, expected: 10},
+		{name: "mph", convert: MilesPerHour.FromMetresPerSecond, value: 10, expected: 22.369},
+		{name: "knots", convert: Knots.FromMetresPerSecond, value: 10, expected: 19.438},
+		{name: "default wind speed", convert: WindSpeedUnit("").FromMetresPerSecond, value: 10, expected: 36},
+		{name: "millimetres", convert: Millimetres.FromMillimetres, value: 12.7, expected: 12.7},
+		{name: "inches", convert: Inches.FromMillimetres, value: 12.7, expected: 0.5},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.convert(tc.value); math.Abs(got-tc.expected) > 0.001 {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
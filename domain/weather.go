@@ -1,14 +1,127 @@
 // Business logic and data model weather
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// MaxForecastDays is the longest forecast horizon that can be requested.
+	MaxForecastDays = 16
+	// MaxForecastHours is the longest hourly forecast horizon that can be requested, a day short of
+	// MaxForecastDays since forecasts start at local midnight and the hours elapsed today are lost.
+	MaxForecastHours = (MaxForecastDays - 1) * 24
+	// MaxBatchCities is the most cities the weather can be requested for at once.
+	MaxBatchCities = 50
+)
 
 type Weather struct {
-	City        string  `json:"city"`
-	Temperature float64 `json:"temperature"`
-	WindSpeed   float64 `json:"windSpeed"`
+	City string `json:"city"`
+	// CountryCode is the ISO 3166-1 alpha-2 code of the city's country, empty if it is not known.
+	CountryCode string `json:"countryCode,omitempty"`
+	// Timezone is the IANA time zone of the location as the provider reports it, or else the city's own.
+	Timezone string `json:"timezone,omitempty"`
+	// Elevation is the elevation of the location in metres used by the provider, nil if it is not reported.
+	Elevation *float64 `json:"elevation,omitempty"`
+	// Time is the start of the hour the reading applies to, in the city's local time.
+	Time                time.Time  `json:"time"`
+	Temperature         float64    `json:"temperature"`
+	WindSpeed           float64    `json:"windSpeed"`
+	ApparentTemperature float64    `json:"apparentTemperature"`
+	Humidity            float64    `json:"humidity"`
+	Precipitation       float64    `json:"precipitation"`
+	SurfacePressure     float64    `json:"surfacePressure"`
+	SeaLevelPressure    float64    `json:"seaLevelPressure"` // unlike SurfacePressure, comparable across provider elevations
+	CloudCover          float64    `json:"cloudCover"`
+	WindDirection       float64    `json:"windDirection"`
+	WindGusts           float64    `json:"windGusts"`
+	WeatherCode         int        `json:"weatherCode"`
+	Description         string     `json:"description"`
+	Icon                string     `json:"icon"`
+	Units               UnitLabels `json:"units"`
+	// Stale is set when the provider failed and the last known reading was served instead.
+	Stale bool `json:"stale,omitempty"`
+	// AgeSeconds is how long ago a stale reading was fetched from the provider.
+	AgeSeconds int64 `json:"ageSeconds,omitempty"`
+}
+
+// HourlyWeather is a single point of an hourly forecast series.
+type HourlyWeather struct {
+	Time        time.Time `json:"time"`
+	Temperature float64   `json:"temperature"`
+	WindSpeed   float64   `json:"windSpeed"`
+}
+
+// Forecast is an hourly forecast timeline for a city, starting at the current hour.
+type Forecast struct {
+	City   string          `json:"city"`
+	Units  UnitLabels      `json:"units"`
+	Hourly []HourlyWeather `json:"hourly"`
+}
+
+// DailyWeather summarises a single forecast day in the city's local time.
+type DailyWeather struct {
+	Date                        time.Time `json:"date"`
+	WeatherCode                 int       `json:"weatherCode"`
+	Description                 string    `json:"description"`
+	Icon                        string    `json:"icon"`
+	TemperatureMin              float64   `json:"temperatureMin"`
+	TemperatureMax              float64   `json:"temperatureMax"`
+	PrecipitationSum            float64   `json:"precipitationSum"`
+	PrecipitationProbabilityMax float64   `json:"precipitationProbabilityMax"`
+	Sunrise                     time.Time `json:"sunrise"`
+	Sunset                      time.Time `json:"sunset"`
+}
+
+// DailyForecast is a day-by-day forecast for a city, starting today.
+type DailyForecast struct {
+	City  string         `json:"city"`
+	Units UnitLabels     `json:"units"`
+	Daily []DailyWeather `json:"daily"`
 }
 
 type WeatherClient interface {
-	FetchWeatherByCity(ctx context.Context, city City) (*Weather, error)
+	FetchWeatherByCity(ctx context.Context, city City, units Units) (*Weather, error)
+	FetchForecastByCity(ctx context.Context, city City, hours int, units Units) (*Forecast, error)
+	FetchDailyForecastByCity(ctx context.Context, city City, days int, units Units) (*DailyForecast, error)
+}
+
+// BatchWeatherClient is a WeatherClient that can also fetch the current weather of several cities
+// at once, with fewer upstream calls than fetching them one by one.
+type BatchWeatherClient interface {
+	WeatherClient
+	// FetchWeatherByCities returns the weather of each city or, at the same index, why it failed.
+	FetchWeatherByCities(ctx context.Context, cities []City, units Units) ([]*Weather, []error)
+}
+
+// CityWeather is the current weather of one city of a batch, or the reason it is missing.
+type CityWeather struct {
+	// City is the city as it was asked for.
+	City    string
+	Weather *Weather
+	Err     error
+}
+
+// FetchWeatherByCities fetches the current weather of the cities with a single batch call if the
+// client supports it, or else city by city with at most parallelism requests in flight.
+// The results and errors are in the order of cities.
+func FetchWeatherByCities(ctx context.Context, client WeatherClient, cities []City, units Units, parallelism int) ([]*Weather, []error) {
+	if batch, ok := client.(BatchWeatherClient); ok {
+		return batch.FetchWeatherByCities(ctx, cities, units)
+	}
+	weathers := make([]*Weather, len(cities))
+	errs := make([]error, len(cities))
+	var g errgroup.Group
+	g.SetLimit(max(parallelism, 1))
+	for i, city := range cities {
+		g.Go(func() error {
+			weathers[i], errs[i] = client.FetchWeatherByCity(ctx, city, units)
+			return nil
+		})
+	}
+	g.Wait()
+	return weathers, errs
 }
@@ -0,0 +1,50 @@
+package domain
+
+// weatherCondition is the human readable form of a WMO weather interpretation code.
+type weatherCondition struct {
+	description string
+	icon        string
+}
+
+// weatherConditions maps WMO weather interpretation codes (WW) as used by Open-Meteo
+// to a description and an icon name.
+var weatherConditions = map[int]weatherCondition{
+	0:  {"Clear sky", "clear"},
+	1:  {"Mainly clear", "mostly-clear"},
+	2:  {"Partly cloudy", "partly-cloudy"},
+	3:  {"Overcast", "overcast"},
+	45: {"Fog", "fog"},
+	48: {"Depositing rime fog", "fog"},
+	51: {"Light drizzle", "drizzle"},
+	53: {"Moderate drizzle", "drizzle"},
+	55: {"Dense drizzle", "drizzle"},
+	56: {"Light freezing drizzle", "freezing-drizzle"},
+	57: {"Dense freezing drizzle", "freezing-drizzle"},
+	61: {"Slight rain", "rain"},
+	63: {"Moderate rain", "rain"},
+	65: {"Heavy rain", "heavy-rain"},
+	66: {"Light freezing rain", "freezing-rain"},
+	67: {"Heavy freezing rain", "freezing-rain"},
+	71: {"Slight snow fall", "snow"},
+	73: {"Moderate snow fall", "snow"},
+	75: {"Heavy snow fall", "heavy-snow"},
+	77: {"Snow grains", "snow"},
+	80: {"Slight rain showers", "showers"},
+	81: {"Moderate rain showers", "showers"},
+	82: {"Violent rain showers", "heavy-showers"},
+	85: {"Slight snow showers", "snow-showers"},
+	86: {"Heavy snow showers", "snow-showers"},
+	95: {"Thunderstorm", "thunderstorm"},
+	96: {"Thunderstorm with slight hail", "thunderstorm-hail"},
+	99: {"Thunderstorm with heavy hail", "thunderstorm-hail"},
+}
+
+// DescribeWeatherCode returns the description and icon name for a WMO weather code.
+// Unknown codes are reported as "Unknown" with the "unknown" icon.
+func DescribeWeatherCode(code int) (description, icon string) {
+	c, ok := weatherConditions[code]
+	if !ok {
+		return "Unknown", "unknown"
+	}
+	return c.description, c.icon
+}
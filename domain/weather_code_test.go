@@ -0,0 +1,30 @@
+package domain
+
+import "testing"
+
+func TestDescribeWeatherCode(t *testing.T) {
+	tests := []struct {
+		name                string
+		value               int
+		expectedDescription string
+		expectedIcon        string
+	}{
+		{name: "clear", value: 0, expectedDescription: "Clear sky", expectedIcon: "clear"},
+		{name: "overcast", value: 3, expectedDescription: "Overcast", expectedIcon: "overcast"},
+		{name: "moderate rain", value: 63, expectedDescription: "Moderate rain", expectedIcon: "rain"},
+		{name: "thunderstorm with hail", value: 99, expectedDescription: "Thunderstorm with heavy hail", expectedIcon: "thunderstorm-hail"},
+		{name: "unknown", value: 42, expectedDescription: "Unknown", expectedIcon: "unknown"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			description, icon := DescribeWeatherCode(tc.value)
+			if description != tc.expectedDescription {
+				t.Errorf("Expected description %q, got %q", tc.expectedDescription, description)
+			}
+			if icon != tc.expectedIcon {
+				t.Errorf("Expected icon %q, got %q", tc.expectedIcon, icon)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestFetchWeatherByCities(t *testing.T) {
+	london := City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	tokyo := City{Name: "Tokyo", Latitude: 35.6895, Longitude: 139.6917}
+
+	t.Run("batch client", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := NewMockBatchWeatherClient(ctrl)
+		client.EXPECT().FetchWeatherByCities(gomock.Any(), []City{london, tokyo}, Metric).
+			Return([]*Weather{{City: "London"}, nil}, []error{nil, ErrUpstreamUnavailable})
+
+		weathers, errs := FetchWeatherByCities(context.Background(), client, []City{london, tokyo}, Metric, 2)
+		if !reflect.DeepEqual(weathers, []*Weather{{City: "London"}, nil}) || !reflect.DeepEqual(errs, []error{nil, ErrUpstreamUnavailable}) {
+			t.Errorf("Expected the batch client's results, but got %v, %v", weathers, errs)
+		}
+	})
+
+	t.Run("city by city", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := NewMockWeatherClient(ctrl)
+		var inFlight, maxInFlight atomic.Int32
+		fetch := func(ctx context.Context, city City, units Units) (*Weather, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			if city.Name == "Tokyo" {
+				return nil, ErrUpstreamTimeout
+			}
+			return &Weather{City: city.Name}, nil
+		}
+		client.EXPECT().FetchWeatherByCity(gomock.Any(), gomock.Any(), Metric).DoAndReturn(fetch).Times(4)
+
+		weathers, errs := FetchWeatherByCities(context.Background(), client, []City{london, tokyo, london, london}, Metric, 2)
+		expectedWeathers := []*Weather{{City: "London"}, nil, {City: "London"}, {City: "London"}}
+		if !reflect.DeepEqual(weathers, expectedWeathers) {
+			t.Errorf("Expected %v, but got %v", expectedWeathers, weathers)
+		}
+		if !reflect.DeepEqual(errs, []error{nil, ErrUpstreamTimeout, nil, nil}) {
+			t.Errorf("Expected only Tokyo to fail, but got %v", errs)
+		}
+		if n := maxInFlight.Load(); n > 2 {
+			t.Errorf("Expected at most 2 requests in flight, but got %d", n)
+		}
+	})
+}
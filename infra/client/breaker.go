@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/softstone1/woc/domain"
+)
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState string
+
+const (
+	// CircuitClosed lets every request through.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen fails every request fast until the cool-down has elapsed.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single probe request through to decide whether to close again.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// BreakerConfig configures a CircuitBreaker.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive upstream failures that opens the circuit.
+	FailureThreshold int
+	// CoolDown is how long the circuit stays open before a probe request is allowed.
+	CoolDown time.Duration
+}
+
+// CircuitBreaker is a domain.WeatherClient decorator that stops calling the provider after
+// repeated upstream failures and fails fast with domain.ErrProviderUnavailable instead.
+type CircuitBreaker struct {
+	next      domain.WeatherClient
+	threshold int
+	coolDown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker wraps next with a circuit breaker.
+func NewCircuitBreaker(next domain.WeatherClient, cfg BreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		next:      next,
+		threshold: cfg.FailureThreshold,
+		coolDown:  cfg.CoolDown,
+		now:       time.Now,
+		state:     CircuitClosed,
+	}
+}
+
+func (b *CircuitBreaker) FetchWeatherByCity(ctx context.Context, city domain.City, units domain.Units) (*domain.Weather, error) {
+	return guarded(ctx, b, func(ctx context.Context) (*domain.Weather, error) {
+		return b.next.FetchWeatherByCity(ctx, city, units)
+	})
+}
+
+func (b *CircuitBreaker) FetchForecastByCity(ctx context.Context, city domain.City, hours int, units domain.Units) (*domain.Forecast, error) {
+	return guarded(ctx, b, func(ctx context.Context) (*domain.Forecast, error) {
+		return b.next.FetchForecastByCity(ctx, city, hours, units)
+	})
+}
+
+func (b *CircuitBreaker) FetchDailyForecastByCity(ctx context.Context, city domain.City, days int, units domain.Units) (*domain.DailyForecast, error) {
+	return guarded(ctx, b, func(ctx context.Context) (*domain.DailyForecast, error) {
+		return b.next.FetchDailyForecastByCity(ctx, city, days, units)
+	})
+}
+
+// FetchWeatherByCities lets the batch through as a single request, which counts as failed only if
+// the provider failed for every city.
+func (b *CircuitBreaker) FetchWeatherByCities(ctx context.Context, cities []domain.City, units domain.Units) ([]*domain.Weather, []error) {
+	if err := b.allow(); err != nil {
+		return failAll(len(cities), err)
+	}
+	weathers, errs := domain.FetchWeatherByCities(ctx, b.next, cities, units, maxConcurrentProviderRequests)
+	b.record(batchError(errs))
+	return weathers, errs
+}
+
+// State returns the current state of the circuit.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Health reports the breaker as healthy only while the circuit is closed.
+func (b *CircuitBreaker) Health() domain.Health {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	health := domain.Health{Healthy: b.state == CircuitClosed, State: string(b.state)}
+	switch b.state {
+	case CircuitClosed:
+		if b.failures > 0 {
+			health.Detail = fmt.Sprintf("%d consecutive failures", b.failures)
+		}
+	case CircuitOpen:
+		health.Detail = fmt.Sprintf("open since %s", b.openedAt.Format(time.RFC3339))
+	}
+	return health
+}
+
+// guarded runs call if the circuit allows it and records the outcome.
+func guarded[T any](ctx context.Context, b *CircuitBreaker, call func(context.Context) (*T, error)) (*T, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	v, err := call(ctx)
+	b.record(err)
+	return v, err
+}
+
+// allow reports whether a request may be sent upstream, moving an open circuit to
+// half-open once the cool-down has elapsed.
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		remaining := b.coolDown - b.now().Sub(b.openedAt)
+		if remaining > 0 {
+			return fmt.Errorf("%w: circuit breaker open, retry in %s", domain.ErrProviderUnavailable, remaining.Round(time.Second))
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+	case CircuitHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%w: circuit breaker half-open, probe in progress", domain.ErrProviderUnavailable)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record updates the circuit with the outcome of a request that was let through.
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case isUpstreamFailure(err):
+		b.failures++
+		if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+			b.state = CircuitOpen
+			b.openedAt = b.now()
+		}
+	case err != nil && errors.Is(err, context.Canceled):
+		// the caller gave up, which says nothing about the provider
+	default:
+		b.state = CircuitClosed
+		b.failures = 0
+	}
+	b.probing = false
+}
+
+// isUpstreamFailure reports whether err means the provider is failing, as opposed to
+// a problem with the request itself.
+func isUpstreamFailure(err error) bool {
+	return errors.Is(err, domain.ErrUpstreamUnavailable) ||
+		errors.Is(err, domain.ErrUpstreamTimeout) ||
+		errors.Is(err, domain.ErrRateLimited)
+}
+
+// isProviderFailure reports whether err means the provider failed or is disabled by its
+// circuit breaker, so another source of weather may stand in for it.
+func isProviderFailure(err error) bool {
+	return isUpstreamFailure(err) || errors.Is(err, domain.ErrProviderUnavailable)
+}
@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/softstone1/woc/domain"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	weather := &domain.Weather{City: "London", Temperature: 12}
+
+	tests := []struct {
+		name          string
+		setupMocks    func(m *domain.MockWeatherClient)
+		run           func(t *testing.T, b *CircuitBreaker, advance func(time.Duration))
+		expectedState CircuitState
+	}{
+		{
+			name: "opens after consecutive upstream failures and fails fast",
+			setupMocks: func(m *domain.MockWeatherClient) {
+				m.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+					Return(nil, domain.ErrUpstreamUnavailable).Times(3)
+			},
+			run: func(t *testing.T, b *CircuitBreaker, advance func(time.Duration)) {
+				for i := 0; i < 3; i++ {
+					if _, err := b.FetchWeatherByCity(context.Background(), city, domain.Metric); !errors.Is(err, domain.ErrUpstreamUnavailable) {
+						t.Errorf("Expected upstream error, got %v", err)
+					}
+				}
+				if _, err := b.FetchWeatherByCity(context.Background(), city, domain.Metric); !errors.Is(err, domain.ErrProviderUnavailable) {
+					t.Errorf("Expected provider unavailable, got %v", err)
+				}
+			},
+			expectedState: CircuitOpen,
+		},
+		{
+			name: "success resets the failure count",
+			setupMocks: func(m *domain.MockWeatherClient) {
+				gomock.InOrder(
+					m.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+						Return(nil, domain.ErrUpstreamTimeout).Times(2),
+					m.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+						Return(weather, nil),
+					m.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+						Return(nil, domain.ErrUpstreamTimeout).Times(2),
+				)
+			},
+			run: func(t *testing.T, b *CircuitBreaker, advance func(time.Duration)) {
+				for i := 0; i < 5; i++ {
+					b.FetchWeatherByCity(context.Background(), city, domain.Metric)
+				}
+			},
+			expectedState: CircuitClosed,
+		},
+		{
+			name: "invalid requests and cancellations do not count as failures",
+			setupMocks: func(m *domain.MockWeatherClient) {
+				m.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+					Return(nil, domain.ErrInvalidInput).Times(3)
+				m.EXPECT().FetchForecastByCity(gomock.Any(), city, 48, domain.Metric).
+					Return(nil, context.Canceled).Times(3)
+			},
+			run: func(t *testing.T, b *CircuitBreaker, advance func(time.Duration)) {
+				for i := 0; i < 3; i++ {
+					b.FetchWeatherByCity(context.Background(), city, domain.Metric)
+					b.FetchForecastByCity(context.Background(), city, 48, domain.Metric)
+				}
+			},
+			expectedState: CircuitClosed,
+		},
+		{
+			name: "a successful probe after the cool-down closes the circuit",
+			setupMocks: func(m *domain.MockWeatherClient) {
+				gomock.InOrder(
+					m.EXPECT().FetchDailyForecastByCity(gomock.Any(), city, 7, domain.Metric).
+						Return(nil, domain.ErrRateLimited).Times(3),
+					m.EXPECT().FetchDailyForecastByCity(gomock.Any(), city, 7, domain.Metric).
+						Return(&domain.DailyForecast{City: "London"}, nil),
+				)
+			},
+			run: func(t *testing.T, b *CircuitBreaker, advance func(time.Duration)) {
+				for i := 0; i < 3; i++ {
+					b.FetchDailyForecastByCity(context.Background(), city, 7, domain.Metric)
+				}
+				advance(10 * time.Second)
+				if _, err := b.FetchDailyForecastByCity(context.Background(), city, 7, domain.Metric); !errors.Is(err, domain.ErrProviderUnavailable) {
+					t.Errorf("Expected provider unavailable during the cool-down, got %v", err)
+				}
+				advance(20 * time.Second)
+				if _, err := b.FetchDailyForecastByCity(context.Background(), city, 7, domain.Metric); err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			},
+			expectedState: CircuitClosed,
+		},
+		{
+			name: "a failed probe reopens the circuit",
+			setupMocks: func(m *domain.MockWeatherClient) {
+				m.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+					Return(nil, domain.ErrUpstreamUnavailable).Times(4)
+			},
+			run: func(t *testing.T, b *CircuitBreaker, advance func(time.Duration)) {
+				for i := 0; i < 3; i++ {
+					b.FetchWeatherByCity(context.Background(), city, domain.Metric)
+				}
+				advance(30 * time.Second)
+				b.FetchWeatherByCity(context.Background(), city, domain.Metric)
+				if _, err := b.FetchWeatherByCity(context.Background(), city, domain.Metric); !errors.Is(err, domain.ErrProviderUnavailable) {
+					t.Errorf("Expected provider unavailable, got %v", err)
+				}
+			},
+			expectedState: CircuitOpen,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockWeatherClient := domain.NewMockWeatherClient(mockCtrl)
+			tc.setupMocks(mockWeatherClient)
+
+			now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			breaker := NewCircuitBreaker(mockWeatherClient, BreakerConfig{FailureThreshold: 3, CoolDown: 30 * time.Second})
+			breaker.now = func() time.Time { return now }
+
+			tc.run(t, breaker, func(d time.Duration) { now = now.Add(d) })
+
+			if state := breaker.State(); state != tc.expectedState {
+				t.Errorf("Expected state %q, got %q", tc.expectedState, state)
+			}
+			if healthy := breaker.Health().Healthy; healthy != (tc.expectedState == CircuitClosed) {
+				t.Errorf("Expected healthy to be %v in state %q", !healthy, tc.expectedState)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_HalfOpenAllowsSingleProbe(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	mockWeatherClient := domain.NewMockWeatherClient(mockCtrl)
+	gomock.InOrder(
+		mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+			Return(nil, domain.ErrUpstreamUnavailable),
+		mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+			DoAndReturn(func(ctx context.Context, city domain.City, units domain.Units) (*domain.Weather, error) {
+				close(started)
+				<-release
+				return &domain.Weather{City: city.Name}, nil
+			}),
+	)
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(mockWeatherClient, BreakerConfig{FailureThreshold: 1, CoolDown: time.Minute})
+	breaker.now = func() time.Time { return now }
+
+	breaker.FetchWeatherByCity(context.Background(), city, domain.Metric)
+	now = now.Add(time.Minute)
+
+	probe := make(chan error, 1)
+	go func() {
+		_, err := breaker.FetchWeatherByCity(context.Background(), city, domain.Metric)
+		probe <- err
+	}()
+	<-started
+	if state := breaker.State(); state != CircuitHalfOpen {
+		t.Errorf("Expected state %q while probing, got %q", CircuitHalfOpen, state)
+	}
+	if _, err := breaker.FetchWeatherByCity(context.Background(), city, domain.Metric); !errors.Is(err, domain.ErrProviderUnavailable) {
+		t.Errorf("Expected provider unavailable while probing, got %v", err)
+	}
+	close(release)
+	if err := <-probe; err != nil {
+		t.Errorf("Unexpected probe error: %v", err)
+	}
+	if state := breaker.State(); state != CircuitClosed {
+		t.Errorf("Expected state %q after the probe, got %q", CircuitClosed, state)
+	}
+}
+
+func TestCircuitBreaker_FetchWeatherByCities(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	london := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	tokyo := domain.City{Name: "Tokyo", Latitude: 35.6895, Longitude: 139.6917}
+	cities := []domain.City{london, tokyo}
+	mockWeatherClient := domain.NewMockBatchWeatherClient(mockCtrl)
+	gomock.InOrder(
+		// a batch with a city fetched counts as a success
+		mockWeatherClient.EXPECT().FetchWeatherByCities(gomock.Any(), cities, domain.Metric).
+			Return([]*domain.Weather{{City: "London"}, nil}, []error{nil, domain.ErrUpstreamUnavailable}),
+		mockWeatherClient.EXPECT().FetchWeatherByCities(gomock.Any(), cities, domain.Metric).
+			Return(make([]*domain.Weather, 2), []error{domain.ErrUpstreamTimeout, domain.ErrUpstreamTimeout}),
+	)
+
+	breaker := NewCircuitBreaker(mockWeatherClient, BreakerConfig{FailureThreshold: 1, CoolDown: time.Minute})
+	breaker.FetchWeatherByCities(context.Background(), cities, domain.Metric)
+	if state := breaker.State(); state != CircuitClosed {
+		t.Errorf("Expected state %q, got %q", CircuitClosed, state)
+	}
+	breaker.FetchWeatherByCities(context.Background(), cities, domain.Metric)
+	if state := breaker.State(); state != CircuitOpen {
+		t.Errorf("Expected state %q, got %q", CircuitOpen, state)
+	}
+	weathers, errs := breaker.FetchWeatherByCities(context.Background(), cities, domain.Metric)
+	for i := range cities {
+		if weathers[i] != nil || !errors.Is(errs[i], domain.ErrProviderUnavailable) {
+			t.Errorf("Expected provider unavailable, got %v, %v", weathers[i], errs[i])
+		}
+	}
+}
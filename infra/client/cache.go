@@ -0,0 +1,366 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/softstone1/woc/domain"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheConfig configures a Cache.
+type CacheConfig struct {
+	// TTL is how long a response is served from the cache.
+	TTL time.Duration
+	// MaxEntries bounds the cache size; the least recently used entry is evicted first.
+	MaxEntries int
+	// StaleTTL is how long past the TTL the last known current weather is kept and served,
+	// flagged as stale, when the provider fails. 0 disables serving stale weather.
+	StaleTTL time.Duration
+	// RefreshAhead is how long before expiry a hit refreshes the entry in the background.
+	// 0 disables background refreshes.
+	RefreshAhead time.Duration
+}
+
+// CacheStats are the counters reported by Cache.Stats.
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	// Stale counts responses served stale because the provider failed.
+	Stale uint64 `json:"stale"`
+	// Refreshes counts entries refreshed in the background before they expired.
+	Refreshes uint64 `json:"refreshes"`
+	Entries   int    `json:"entries"`
+}
+
+// backgroundRefreshTimeout bounds a background refresh, which has no caller deadline.
+const backgroundRefreshTimeout = 10 * time.Second
+
+// Cache is a domain.WeatherClient decorator that keeps responses in memory for a TTL.
+// Entries are keyed by coordinates, the requested horizon and units, and concurrent
+// identical requests are collapsed into a single upstream call. When the provider fails,
+// the last known current weather is served flagged as stale. Current weather expires at the end
+// of the hour it applies to at the latest.
+type Cache struct {
+	next         domain.WeatherClient
+	ttl          time.Duration
+	staleTTL     time.Duration
+	refreshAhead time.Duration
+	maxEntries   int
+	now          func() time.Time
+
+	group singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front is most recently used
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	stale     atomic.Uint64
+	refreshes atomic.Uint64
+}
+
+type cacheEntry struct {
+	key       string
+	value     any
+	storedAt  time.Time
+	expiresAt time.Time
+}
+
+// NewCache wraps next with an in-memory cache.
+func NewCache(next domain.WeatherClient, cfg CacheConfig) *Cache {
+	return &Cache{
+		next:         next,
+		ttl:          cfg.TTL,
+		staleTTL:     cfg.StaleTTL,
+		refreshAhead: cfg.RefreshAhead,
+		maxEntries:   cfg.MaxEntries,
+		now:          time.Now,
+		entries:      make(map[string]*list.Element),
+		lru:          list.New(),
+	}
+}
+
+func (c *Cache) FetchWeatherByCity(ctx context.Context, city domain.City, units domain.Units) (*domain.Weather, error) {
+	weather, staleSince, err := cached(ctx, c, weatherKey(city, units), c.staleTTL > 0, func(ctx context.Context) (*domain.Weather, error) {
+		return c.next.FetchWeatherByCity(ctx, city, units)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.labelled(weather, city, staleSince), nil
+}
+
+// FetchWeatherByCities serves the cities found in the cache and fetches the others from the next
+// client in a single batch. Cities at the same location are looked up once, and each lookup is
+// cached like a single request: it shares a fetch already in flight and falls back to stale
+// weather when the provider fails.
+func (c *Cache) FetchWeatherByCities(ctx context.Context, cities []domain.City, units domain.Units) ([]*domain.Weather, []error) {
+	keys := make([]string, len(cities))
+	// first maps the key of every location to the first city at it
+	first := map[string]int{}
+	batch := &weatherBatch{next: c.next, units: units, index: map[string]int{}}
+	for i, city := range cities {
+		keys[i] = weatherKey(city, units)
+		if _, ok := first[keys[i]]; ok {
+			continue
+		}
+		first[keys[i]] = i
+		if _, fresh, _ := c.get(keys[i]); !fresh {
+			batch.add(keys[i], city)
+		}
+	}
+	type lookup struct {
+		weather    *domain.Weather
+		staleSince time.Time
+		err        error
+	}
+	lookups := make([]lookup, len(cities))
+	var g errgroup.Group
+	for key, i := range first {
+		g.Go(func() error {
+			weather, staleSince, err := cached(ctx, c, key, c.staleTTL > 0, func(ctx context.Context) (*domain.Weather, error) {
+				return batch.fetch(ctx, key, cities[i])
+			})
+			lookups[i] = lookup{weather, staleSince, err}
+			return nil
+		})
+	}
+	g.Wait()
+	weathers := make([]*domain.Weather, len(cities))
+	errs := make([]error, len(cities))
+	for i, city := range cities {
+		l := lookups[first[keys[i]]]
+		if l.err != nil {
+			errs[i] = l.err
+			continue
+		}
+		weathers[i] = c.labelled(l.weather, city, l.staleSince)
+	}
+	return weathers, errs
+}
+
+// weatherBatch fetches the current weather of the cities a batch request misses in the cache with
+// a single call to the next client, made when the first of them is fetched.
+type weatherBatch struct {
+	next  domain.WeatherClient
+	units domain.Units
+	// index maps the key of every city in the batch to its position
+	index  map[string]int
+	cities []domain.City
+
+	once     sync.Once
+	weathers []*domain.Weather
+	errs     []error
+}
+
+// add queues the city for the batch.
+func (b *weatherBatch) add(key string, city domain.City) {
+	b.index[key] = len(b.cities)
+	b.cities = append(b.cities, city)
+}
+
+// fetch returns the weather of the city from the batch, fetching the batch if it has not been
+// yet. A city left out of the batch, such as one refreshed ahead of expiry, is fetched on its own.
+func (b *weatherBatch) fetch(ctx context.Context, key string, city domain.City) (*domain.Weather, error) {
+	i, ok := b.index[key]
+	if !ok {
+		return b.next.FetchWeatherByCity(ctx, city, b.units)
+	}
+	b.once.Do(func() {
+		b.weathers, b.errs = domain.FetchWeatherByCities(ctx, b.next, b.cities, b.units, maxConcurrentProviderRequests)
+	})
+	return b.weathers[i], b.errs[i]
+}
+
+// weatherKey is the cache key of the current weather of city in units.
+func weatherKey(city domain.City, units domain.Units) string {
+	return fmt.Sprintf("weather|%v|%v|%v", city.Latitude, city.Longitude, units)
+}
+
+// labelled returns a copy of the shared entry labelled with the requested city, and flagged as
+// stale if it is served past its TTL.
+func (c *Cache) labelled(weather *domain.Weather, city domain.City, staleSince time.Time) *domain.Weather {
+	w := *weather
+	w.City = city.Name
+	if !staleSince.IsZero() {
+		w.Stale = true
+		w.AgeSeconds = int64(c.now().Sub(staleSince) / time.Second)
+	}
+	return &w
+}
+
+func (c *Cache) FetchForecastByCity(ctx context.Context, city domain.City, hours int, units domain.Units) (*domain.Forecast, error) {
+	key := fmt.Sprintf("forecast|%v|%v|%d|%v", city.Latitude, city.Longitude, hours, units)
+	forecast, _, err := cached(ctx, c, key, false, func(ctx context.Context) (*domain.Forecast, error) {
+		return c.next.FetchForecastByCity(ctx, city, hours, units)
+	})
+	if err != nil {
+		return nil, err
+	}
+	f := *forecast
+	f.City = city.Name
+	return &f, nil
+}
+
+func (c *Cache) FetchDailyForecastByCity(ctx context.Context, city domain.City, days int, units domain.Units) (*domain.DailyForecast, error) {
+	key := fmt.Sprintf("daily|%v|%v|%d|%v", city.Latitude, city.Longitude, days, units)
+	forecast, _, err := cached(ctx, c, key, false, func(ctx context.Context) (*domain.DailyForecast, error) {
+		return c.next.FetchDailyForecastByCity(ctx, city, days, units)
+	})
+	if err != nil {
+		return nil, err
+	}
+	f := *forecast
+	f.City = city.Name
+	return &f, nil
+}
+
+// Stats returns the cache hit and miss counters and the current number of entries.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Stale:     c.stale.Load(),
+		Refreshes: c.refreshes.Load(),
+		Entries:   entries,
+	}
+}
+
+// cached returns the entry for key, or fetches and stores it on a miss.
+// Concurrent misses for the same key share one fetch, and a hit close to expiry refreshes
+// the entry in the background. If serveStale is set and the provider fails, an expired entry
+// still within the stale TTL is returned together with the time it was fetched; staleSince
+// is zero otherwise.
+func cached[T any](ctx context.Context, c *Cache, key string, serveStale bool, fetch func(context.Context) (*T, error)) (v *T, staleSince time.Time, err error) {
+	entry, fresh, ok := c.get(key)
+	if ok && fresh {
+		c.hits.Add(1)
+		if c.refreshAhead > 0 && !c.now().Before(entry.expiresAt.Add(-c.refreshAhead)) {
+			c.refresh(key, func(ctx context.Context) (any, error) { return fetch(ctx) })
+		}
+		return entry.value.(*T), time.Time{}, nil
+	}
+	c.misses.Add(1)
+	v, err = load(ctx, c, key, fetch)
+	if err != nil && ok && serveStale && isProviderFailure(err) {
+		c.stale.Add(1)
+		return entry.value.(*T), entry.storedAt, nil
+	}
+	return v, time.Time{}, err
+}
+
+// load fetches key upstream and stores the result, sharing the fetch with concurrent callers.
+func load[T any](ctx context.Context, c *Cache, key string, fetch func(context.Context) (*T, error)) (*T, error) {
+	for {
+		ch := c.group.DoChan(key, func() (any, error) {
+			v, err := fetch(ctx)
+			if err != nil {
+				return nil, err
+			}
+			c.set(key, v)
+			return v, nil
+		})
+		select {
+		case <-ctx.Done():
+			return nil, contextError(ctx)
+		case res := <-ch:
+			// the caller that started a shared fetch gave up; retry on behalf of this one
+			if res.Err != nil && res.Shared && errors.Is(res.Err, context.Canceled) && ctx.Err() == nil {
+				continue
+			}
+			if res.Err != nil {
+				return nil, res.Err
+			}
+			return res.Val.(*T), nil
+		}
+	}
+}
+
+// refresh reloads key in the background unless a fetch for it is already in flight.
+// Failures are ignored, the current entry is served until it expires.
+func (c *Cache) refresh(key string, fetch func(context.Context) (any, error)) {
+	c.group.DoChan(key, func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), backgroundRefreshTimeout)
+		defer cancel()
+		v, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.set(key, v)
+		c.refreshes.Add(1)
+		return v, nil
+	})
+}
+
+// get returns a copy of the entry for key, whether it is still fresh and whether it was found.
+// Entries past the stale TTL are removed; a found entry is marked as recently used.
+func (c *Cache) get(key string) (cacheEntry, bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return cacheEntry{}, false, false
+	}
+	entry := el.Value.(*cacheEntry)
+	now := c.now()
+	if !now.Before(entry.expiresAt.Add(c.staleTTL)) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return cacheEntry{}, false, false
+	}
+	c.lru.MoveToFront(el)
+	return *entry, now.Before(entry.expiresAt), true
+}
+
+// set stores value under key, evicting the least recently used entries beyond MaxEntries.
+func (c *Cache) set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	expiresAt := c.expiry(value, now)
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*cacheEntry)
+		entry.value = value
+		entry.storedAt = now
+		entry.expiresAt = expiresAt
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, value: value, storedAt: now, expiresAt: expiresAt})
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// expiry returns when a value stored now expires: after the TTL, and for current weather no later
+// than the end of the local hour the reading applies to, when the provider moves on to the next one.
+func (c *Cache) expiry(value any, now time.Time) time.Time {
+	expiresAt := now.Add(c.ttl)
+	if weather, ok := value.(*domain.Weather); ok && !weather.Time.IsZero() {
+		if hourEnd := weather.Time.Add(time.Hour); hourEnd.Before(expiresAt) {
+			return hourEnd
+		}
+	}
+	return expiresAt
+}
+
+// contextError reports why the caller stopped waiting, matching the errors of the HTTP client.
+func contextError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: weather request aborted: %w", domain.ErrUpstreamTimeout, ctx.Err())
+	}
+	return fmt.Errorf("weather request aborted: %w", ctx.Err())
+}
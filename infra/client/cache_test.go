@@ -0,0 +1,468 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/softstone1/woc/domain"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCache_FetchWeatherByCity(t *testing.T) {
+	london := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	paris := domain.City{Name: "Paris", Latitude: 48.8566, Longitude: 2.3522}
+	tokyo := domain.City{Name: "Tokyo", Latitude: 35.6895, Longitude: 139.6917}
+
+	tests := []struct {
+		name           string
+		maxEntries     int
+		staleTTL       time.Duration
+		setupMocks     func(m *domain.MockWeatherClient)
+		run            func(t *testing.T, c *Cache, advance func(time.Duration))
+		expectedHits   uint64
+		expectedMisses uint64
+	}{
+		{
+			name: "second request is served from the cache",
+			setupMocks: func(m *domain.MockWeatherClient) {
+				m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+					Return(&domain.Weather{City: "London", Temperature: 12}, nil).Times(1)
+			},
+			run: func(t *testing.T, c *Cache, advance func(time.Duration)) {
+				for i := 0; i < 2; i++ {
+					weather, err := c.FetchWeatherByCity(context.Background(), london, domain.Metric)
+					if err != nil {
+						t.Fatalf("Unexpected error: %v", err)
+					}
+					if weather.Temperature != 12 {
+						t.Errorf("Expected temperature 12, got %v", weather.Temperature)
+					}
+				}
+			},
+			expectedHits:   1,
+			expectedMisses: 1,
+		},
+		{
+			name: "entries expire after the TTL",
+			setupMocks: func(m *domain.MockWeatherClient) {
+				m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+					Return(&domain.Weather{City: "London"}, nil).Times(2)
+			},
+			run: func(t *testing.T, c *Cache, advance func(time.Duration)) {
+				c.FetchWeatherByCity(context.Background(), london, domain.Metric)
+				advance(time.Minute)
+				c.FetchWeatherByCity(context.Background(), london, domain.Metric)
+			},
+			expectedHits:   0,
+			expectedMisses: 2,
+		},
+		{
+			name: "current weather expires at the end of its local hour",
+			setupMocks: func(m *domain.MockWeatherClient) {
+				// 18:00 in India is 12:30 UTC, the hour ends at 13:30 UTC
+				hour := time.Date(2024, 5, 1, 18, 0, 0, 0, time.FixedZone("IST", 5*3600+1800))
+				m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+					Return(&domain.Weather{City: "London", Time: hour}, nil).Times(2)
+			},
+			run: func(t *testing.T, c *Cache, advance func(time.Duration)) {
+				advance(89*time.Minute + 30*time.Second)
+				c.FetchWeatherByCity(context.Background(), london, domain.Metric)
+				// within the TTL but past the hour
+				advance(45 * time.Second)
+				c.FetchWeatherByCity(context.Background(), london, domain.Metric)
+			},
+			expectedHits:   0,
+			expectedMisses: 2,
+		},
+		{
+			name: "units are part of the key",
+			setupMocks: func(m *domain.MockWeatherClient) {
+				m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+					Return(&domain.Weather{City: "London", Temperature: 12}, nil).Times(1)
+				m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Imperial).
+					Return(&domain.Weather{City: "London", Temperature: 53.6}, nil).Times(1)
+			},
+			run: func(t *testing.T, c *Cache, advance func(time.Duration)) {
+				metric, _ := c.FetchWeatherByCity(context.Background(), london, domain.Metric)
+				imperial, _ := c.FetchWeatherByCity(context.Background(), london, domain.Imperial)
+				if metric.Temperature == imperial.Temperature {
+					t.Errorf("Expected different readings per unit system, got %v for both", metric.Temperature)
+				}
+			},
+			expectedHits:   0,
+			expectedMisses: 2,
+		},
+		{
+			name: "cities sharing coordinates keep their own name",
+			setupMocks: func(m *domain.MockWeatherClient) {
+				m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+					Return(&domain.Weather{City: "London"}, nil).Times(1)
+			},
+			run: func(t *testing.T, c *Cache, advance func(time.Duration)) {
+				c.FetchWeatherByCity(context.Background(), london, domain.Metric)
+				alias := london
+				alias.Name = "City of London"
+				weather, _ := c.FetchWeatherByCity(context.Background(), alias, domain.Metric)
+				if weather.City != "City of London" {
+					t.Errorf("Expected city %q, got %q", "City of London", weather.City)
+				}
+			},
+			expectedHits:   1,
+			expectedMisses: 1,
+		},
+		{
+			name:       "least recently used entry is evicted",
+			maxEntries: 2,
+			setupMocks: func(m *domain.MockWeatherClient) {
+				m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+					Return(&domain.Weather{City: "London"}, nil).Times(1)
+				m.EXPECT().FetchWeatherByCity(gomock.Any(), paris, domain.Metric).
+					Return(&domain.Weather{City: "Paris"}, nil).Times(2)
+				m.EXPECT().FetchWeatherByCity(gomock.Any(), tokyo, domain.Metric).
+					Return(&domain.Weather{City: "Tokyo"}, nil).Times(1)
+			},
+			run: func(t *testing.T, c *Cache, advance func(time.Duration)) {
+				c.FetchWeatherByCity(context.Background(), london, domain.Metric)
+				c.FetchWeatherByCity(context.Background(), paris, domain.Metric)
+				// touch London so Paris becomes the least recently used entry
+				c.FetchWeatherByCity(context.Background(), london, domain.Metric)
+				c.FetchWeatherByCity(context.Background(), tokyo, domain.Metric)
+				c.FetchWeatherByCity(context.Background(), paris, domain.Metric)
+				if entries := c.Stats().Entries; entries != 2 {
+					t.Errorf("Expected 2 entries, got %d", entries)
+				}
+			},
+			expectedHits:   1,
+			expectedMisses: 4,
+		},
+		{
+			name: "errors are not cached",
+			setupMocks: func(m *domain.MockWeatherClient) {
+				gomock.InOrder(
+					m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+						Return(nil, domain.ErrUpstreamUnavailable),
+					m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+						Return(&domain.Weather{City: "London"}, nil),
+				)
+			},
+			run: func(t *testing.T, c *Cache, advance func(time.Duration)) {
+				if _, err := c.FetchWeatherByCity(context.Background(), london, domain.Metric); !errors.Is(err, domain.ErrUpstreamUnavailable) {
+					t.Errorf("Expected upstream error, got %v", err)
+				}
+				if _, err := c.FetchWeatherByCity(context.Background(), london, domain.Metric); err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			},
+			expectedHits:   0,
+			expectedMisses: 2,
+		},
+		{
+			name:     "stale weather is served when the provider fails",
+			staleTTL: time.Hour,
+			setupMocks: func(m *domain.MockWeatherClient) {
+				gomock.InOrder(
+					m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+						Return(&domain.Weather{City: "London", Temperature: 12}, nil),
+					m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+						Return(nil, domain.ErrUpstreamUnavailable),
+					m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+						Return(nil, domain.ErrProviderUnavailable),
+				)
+			},
+			run: func(t *testing.T, c *Cache, advance func(time.Duration)) {
+				c.FetchWeatherByCity(context.Background(), london, domain.Metric)
+				advance(2 * time.Minute)
+				for _, age := range []int64{120, 150} {
+					weather, err := c.FetchWeatherByCity(context.Background(), london, domain.Metric)
+					if err != nil {
+						t.Fatalf("Unexpected error: %v", err)
+					}
+					if !weather.Stale || weather.AgeSeconds != age || weather.Temperature != 12 {
+						t.Errorf("Expected stale reading of 12 aged %ds, got %+v", age, weather)
+					}
+					advance(30 * time.Second)
+				}
+				if stale := c.Stats().Stale; stale != 2 {
+					t.Errorf("Expected 2 stale responses, got %d", stale)
+				}
+			},
+			expectedHits:   0,
+			expectedMisses: 3,
+		},
+		{
+			name:     "request errors are not hidden by stale weather",
+			staleTTL: time.Hour,
+			setupMocks: func(m *domain.MockWeatherClient) {
+				gomock.InOrder(
+					m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+						Return(&domain.Weather{City: "London"}, nil),
+					m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+						Return(nil, domain.ErrInvalidInput),
+				)
+			},
+			run: func(t *testing.T, c *Cache, advance func(time.Duration)) {
+				c.FetchWeatherByCity(context.Background(), london, domain.Metric)
+				advance(2 * time.Minute)
+				if _, err := c.FetchWeatherByCity(context.Background(), london, domain.Metric); !errors.Is(err, domain.ErrInvalidInput) {
+					t.Errorf("Expected invalid input error, got %v", err)
+				}
+			},
+			expectedHits:   0,
+			expectedMisses: 2,
+		},
+		{
+			name:     "stale weather is dropped after the stale TTL",
+			staleTTL: time.Hour,
+			setupMocks: func(m *domain.MockWeatherClient) {
+				gomock.InOrder(
+					m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+						Return(&domain.Weather{City: "London"}, nil),
+					m.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+						Return(nil, domain.ErrUpstreamTimeout),
+				)
+			},
+			run: func(t *testing.T, c *Cache, advance func(time.Duration)) {
+				c.FetchWeatherByCity(context.Background(), london, domain.Metric)
+				advance(2 * time.Hour)
+				if _, err := c.FetchWeatherByCity(context.Background(), london, domain.Metric); !errors.Is(err, domain.ErrUpstreamTimeout) {
+					t.Errorf("Expected upstream timeout, got %v", err)
+				}
+			},
+			expectedHits:   0,
+			expectedMisses: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockWeatherClient := domain.NewMockWeatherClient(mockCtrl)
+			tc.setupMocks(mockWeatherClient)
+
+			now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			cache := NewCache(mockWeatherClient, CacheConfig{TTL: time.Minute, MaxEntries: tc.maxEntries, StaleTTL: tc.staleTTL})
+			cache.now = func() time.Time { return now }
+
+			tc.run(t, cache, func(d time.Duration) { now = now.Add(d) })
+
+			stats := cache.Stats()
+			if stats.Hits != tc.expectedHits || stats.Misses != tc.expectedMisses {
+				t.Errorf("Expected %d hits and %d misses, got %d hits and %d misses", tc.expectedHits, tc.expectedMisses, stats.Hits, stats.Misses)
+			}
+		})
+	}
+}
+
+func TestCache_FetchWeatherByCities(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	london := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	paris := domain.City{Name: "Paris", Latitude: 48.8566, Longitude: 2.3522}
+	tokyo := domain.City{Name: "Tokyo", Latitude: 35.6895, Longitude: 139.6917}
+	edo := domain.City{Name: "Edo", Latitude: 35.6895, Longitude: 139.6917}
+	mockWeatherClient := domain.NewMockBatchWeatherClient(mockCtrl)
+	gomock.InOrder(
+		mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), paris, domain.Metric).
+			Return(&domain.Weather{City: "Paris", Temperature: 17}, nil),
+		mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), london, domain.Metric).
+			Return(&domain.Weather{City: "London", Temperature: 12}, nil),
+		// only the cities missing from the cache are fetched, in a single batch, once per location
+		mockWeatherClient.EXPECT().FetchWeatherByCities(gomock.Any(), []domain.City{paris, tokyo}, domain.Metric).
+			Return([]*domain.Weather{nil, {City: "Tokyo", Temperature: 21}}, []error{domain.ErrUpstreamTimeout, nil}),
+	)
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewCache(mockWeatherClient, CacheConfig{TTL: time.Minute, StaleTTL: time.Hour})
+	cache.now = func() time.Time { return now }
+	cache.FetchWeatherByCity(context.Background(), paris, domain.Metric)
+	now = now.Add(50 * time.Second)
+	cache.FetchWeatherByCity(context.Background(), london, domain.Metric)
+	now = now.Add(20 * time.Second)
+
+	weathers, errs := cache.FetchWeatherByCities(context.Background(), []domain.City{london, paris, tokyo, edo}, domain.Metric)
+	expected := []*domain.Weather{
+		{City: "London", Temperature: 12},
+		{City: "Paris", Temperature: 17, Stale: true, AgeSeconds: 70},
+		{City: "Tokyo", Temperature: 21},
+		{City: "Edo", Temperature: 21},
+	}
+	for i := range expected {
+		if errs[i] != nil {
+			t.Errorf("Unexpected error: %v", errs[i])
+		} else if *weathers[i] != *expected[i] {
+			t.Errorf("Expected weather %+v, got %+v", expected[i], weathers[i])
+		}
+	}
+	if weather, err := cache.FetchWeatherByCity(context.Background(), tokyo, domain.Metric); err != nil || weather.Temperature != 21 {
+		t.Errorf("Expected Tokyo from the cache, got %v, %v", weather, err)
+	}
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 4 || stats.Stale != 1 {
+		t.Errorf("Expected 2 hits, 4 misses and 1 stale response, got %+v", stats)
+	}
+}
+
+func TestCache_FetchWeatherByCities_SharesFetchesInFlight(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	london := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	fetching := make(chan struct{})
+	release := make(chan struct{})
+	mockWeatherClient := domain.NewMockBatchWeatherClient(mockCtrl)
+	mockWeatherClient.EXPECT().FetchWeatherByCities(gomock.Any(), []domain.City{london}, domain.Metric).
+		DoAndReturn(func(ctx context.Context, cities []domain.City, units domain.Units) ([]*domain.Weather, []error) {
+			close(fetching)
+			<-release
+			return []*domain.Weather{{City: "London", Temperature: 12}}, []error{nil}
+		}).Times(1)
+
+	cache := NewCache(mockWeatherClient, CacheConfig{TTL: time.Minute})
+	batchDone := make(chan []error)
+	go func() {
+		_, errs := cache.FetchWeatherByCities(context.Background(), []domain.City{london}, domain.Metric)
+		batchDone <- errs
+	}()
+	<-fetching
+	// a single request for a city of the batch waits for the batch instead of fetching it again
+	singleDone := make(chan error)
+	go func() {
+		_, err := cache.FetchWeatherByCity(context.Background(), london, domain.Metric)
+		singleDone <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if errs := <-batchDone; errs[0] != nil {
+		t.Errorf("Unexpected error: %v", errs[0])
+	}
+	if err := <-singleDone; err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestCache_CollapsesConcurrentRequests(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	release := make(chan struct{})
+	mockWeatherClient := domain.NewMockWeatherClient(mockCtrl)
+	mockWeatherClient.EXPECT().FetchForecastByCity(gomock.Any(), city, 48, domain.Metric).
+		DoAndReturn(func(ctx context.Context, city domain.City, hours int, units domain.Units) (*domain.Forecast, error) {
+			<-release
+			return &domain.Forecast{City: city.Name}, nil
+		}).Times(1)
+
+	cache := NewCache(mockWeatherClient, CacheConfig{TTL: time.Minute})
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.FetchForecastByCity(context.Background(), city, 48, domain.Metric)
+			errs <- err
+		}()
+	}
+	// give the callers time to queue up behind the first fetch
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+}
+
+func TestCache_WaiterHonorsContext(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	mockWeatherClient := domain.NewMockWeatherClient(mockCtrl)
+	mockWeatherClient.EXPECT().FetchDailyForecastByCity(gomock.Any(), city, 7, domain.Metric).
+		DoAndReturn(func(ctx context.Context, city domain.City, days int, units domain.Units) (*domain.DailyForecast, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).Times(1)
+
+	cache := NewCache(mockWeatherClient, CacheConfig{TTL: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := cache.FetchDailyForecastByCity(ctx, city, 7, domain.Metric)
+	if !errors.Is(err, domain.ErrUpstreamTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected upstream timeout wrapping the deadline, got %v", err)
+	}
+}
+
+func TestCache_RefreshesAheadOfExpiry(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	mockWeatherClient := domain.NewMockWeatherClient(mockCtrl)
+	gomock.InOrder(
+		mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+			Return(&domain.Weather{City: "London", Temperature: 12}, nil),
+		mockWeatherClient.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+			Return(&domain.Weather{City: "London", Temperature: 13}, nil),
+	)
+
+	var mu sync.Mutex
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewCache(mockWeatherClient, CacheConfig{TTL: time.Minute, RefreshAhead: 10 * time.Second})
+	cache.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+
+	cache.FetchWeatherByCity(context.Background(), city, domain.Metric)
+	advance(55 * time.Second)
+	weather, err := cache.FetchWeatherByCity(context.Background(), city, domain.Metric)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if weather.Temperature != 12 {
+		t.Errorf("Expected the cached reading while refreshing, got %v", weather.Temperature)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for cache.Stats().Refreshes == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the entry to be refreshed in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// past the original expiry, the refreshed entry is served without another upstream call
+	advance(30 * time.Second)
+	weather, err = cache.FetchWeatherByCity(context.Background(), city, domain.Metric)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if weather.Temperature != 13 {
+		t.Errorf("Expected the refreshed reading, got %v", weather.Temperature)
+	}
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("Expected 2 hits and 1 miss, got %d hits and %d misses", stats.Hits, stats.Misses)
+	}
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/softstone1/woc/domain"
+)
+
+// Consensus is a domain.ConsensusClient that queries all providers concurrently and merges
+// their current weather.
+type Consensus struct {
+	providers []Provider
+}
+
+// NewConsensus creates a consensus client over the given providers.
+func NewConsensus(providers ...Provider) *Consensus {
+	return &Consensus{
+		providers: providers,
+	}
+}
+
+// FetchConsensusWeatherByCity returns the merged weather of every provider that answered,
+// together with each provider's reading or error. It fails only if no provider answered.
+func (c *Consensus) FetchConsensusWeatherByCity(ctx context.Context, city domain.City, units domain.Units, aggregation domain.Aggregation) (*domain.ConsensusWeather, error) {
+	readings := make([]domain.ProviderWeather, len(c.providers))
+	errs := make([]error, len(c.providers))
+	var wg sync.WaitGroup
+	for i, p := range c.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			weather, err := p.Client.FetchWeatherByCity(ctx, city, units)
+			readings[i] = domain.ProviderWeather{Provider: p.Name, Weather: weather}
+			if err != nil {
+				readings[i].Err = err
+				errs[i] = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	consensus := domain.MergeWeather(readings, aggregation)
+	if consensus == nil {
+		for i, err := range errs {
+			if err != nil {
+				return nil, fmt.Errorf("no weather provider answered, %s: %w", c.providers[i].Name, err)
+			}
+		}
+		return nil, fmt.Errorf("%w: no weather provider configured", domain.ErrProviderUnavailable)
+	}
+	consensus.Weather.City = city.Name
+	return consensus, nil
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/softstone1/woc/domain"
+	"go.uber.org/mock/gomock"
+)
+
+func TestConsensus_FetchConsensusWeatherByCity(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	a := domain.NewMockWeatherClient(mockCtrl)
+	b := domain.NewMockWeatherClient(mockCtrl)
+	c := domain.NewMockWeatherClient(mockCtrl)
+	a.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).Return(&domain.Weather{City: "London", Temperature: 10}, nil)
+	b.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).Return(&domain.Weather{City: "London", Temperature: 14}, nil)
+	c.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).Return(nil, domain.ErrUpstreamTimeout)
+
+	consensus := NewConsensus(Provider{Name: "a", Client: a}, Provider{Name: "b", Client: b}, Provider{Name: "c", Client: c})
+	result, err := consensus.FetchConsensusWeatherByCity(context.Background(), city, domain.Metric, domain.AggregateMean)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Weather.Temperature != 12 {
+		t.Errorf("Expected mean temperature 12, got %v", result.Weather.Temperature)
+	}
+	if result.Spread["temperature"].Range != 4 {
+		t.Errorf("Expected temperature range 4, got %v", result.Spread["temperature"].Range)
+	}
+	if len(result.Providers) != 3 || result.Providers[2].Provider != "c" || !errors.Is(result.Providers[2].Err, domain.ErrUpstreamTimeout) {
+		t.Errorf("Expected the readings of all providers in order, got %+v", result.Providers)
+	}
+}
+
+func TestConsensus_AllProvidersFailing(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	a := domain.NewMockWeatherClient(mockCtrl)
+	a.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).Return(nil, domain.ErrUpstreamUnavailable)
+
+	_, err := NewConsensus(Provider{Name: "a", Client: a}).FetchConsensusWeatherByCity(context.Background(), city, domain.Metric, domain.AggregateMedian)
+	if !errors.Is(err, domain.ErrUpstreamUnavailable) {
+		t.Errorf("Expected upstream unavailable, got %v", err)
+	}
+}
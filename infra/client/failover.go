@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/softstone1/woc/domain"
+)
+
+// Provider is a weather provider client with the name it is configured under.
+type Provider struct {
+	Name   string
+	Client domain.WeatherClient
+}
+
+// Failover is a domain.WeatherClient that tries providers in order and moves on to the next one
+// when a provider fails. Requests a provider rejects as invalid are not tried elsewhere.
+type Failover struct {
+	providers []Provider
+}
+
+// NewFailover creates a client trying the providers in the given order.
+func NewFailover(providers ...Provider) *Failover {
+	return &Failover{
+		providers: providers,
+	}
+}
+
+func (f *Failover) FetchWeatherByCity(ctx context.Context, city domain.City, units domain.Units) (*domain.Weather, error) {
+	return failover(ctx, f, func(c domain.WeatherClient) (*domain.Weather, error) {
+		return c.FetchWeatherByCity(ctx, city, units)
+	})
+}
+
+func (f *Failover) FetchForecastByCity(ctx context.Context, city domain.City, hours int, units domain.Units) (*domain.Forecast, error) {
+	return failover(ctx, f, func(c domain.WeatherClient) (*domain.Forecast, error) {
+		return c.FetchForecastByCity(ctx, city, hours, units)
+	})
+}
+
+func (f *Failover) FetchDailyForecastByCity(ctx context.Context, city domain.City, days int, units domain.Units) (*domain.DailyForecast, error) {
+	return failover(ctx, f, func(c domain.WeatherClient) (*domain.DailyForecast, error) {
+		return c.FetchDailyForecastByCity(ctx, city, days, units)
+	})
+}
+
+// FetchWeatherByCities asks each provider in turn for the cities the providers before it failed.
+func (f *Failover) FetchWeatherByCities(ctx context.Context, cities []domain.City, units domain.Units) ([]*domain.Weather, []error) {
+	weathers := make([]*domain.Weather, len(cities))
+	errs := make([]error, len(cities))
+	// pending holds the indexes of the cities still to be fetched
+	pending := make([]int, len(cities))
+	for i := range pending {
+		pending[i] = i
+	}
+	for i, p := range f.providers {
+		batch := make([]domain.City, len(pending))
+		for j, k := range pending {
+			batch[j] = cities[k]
+		}
+		fetched, fetchErrs := domain.FetchWeatherByCities(ctx, p.Client, batch, units, maxConcurrentProviderRequests)
+		var failed []int
+		for j, k := range pending {
+			weathers[k], errs[k] = fetched[j], fetchErrs[j]
+			if errs[k] != nil && isProviderFailure(errs[k]) && ctx.Err() == nil {
+				failed = append(failed, k)
+			}
+		}
+		pending = failed
+		if len(pending) == 0 {
+			return weathers, errs
+		}
+		if i < len(f.providers)-1 {
+			slog.Warn("weather provider failed, trying the next one", "provider", p.Name, "cities", len(pending), "error", errs[pending[0]])
+		}
+	}
+	for _, k := range pending {
+		errs[k] = fmt.Errorf("all weather providers failed: %w", errs[k])
+	}
+	return weathers, errs
+}
+
+// Health reports the failover client as healthy while any of its providers is. Providers that do
+// not report their health are assumed to be healthy.
+func (f *Failover) Health() domain.Health {
+	health := domain.Health{State: "degraded"}
+	states := make([]string, 0, len(f.providers))
+	for _, p := range f.providers {
+		h := domain.Health{Healthy: true}
+		if reporter, ok := p.Client.(domain.HealthReporter); ok {
+			h = reporter.Health()
+		}
+		if h.Healthy {
+			health.Healthy = true
+		}
+		state := h.State
+		if state == "" {
+			state = "unknown"
+		}
+		states = append(states, p.Name+": "+state)
+	}
+	if health.Healthy {
+		health.State = "available"
+	}
+	health.Detail = strings.Join(states, ", ")
+	return health
+}
+
+// failover calls the providers in order until one succeeds or fails for a reason other than
+// a provider failure.
+func failover[T any](ctx context.Context, f *Failover, call func(domain.WeatherClient) (*T, error)) (*T, error) {
+	var err error
+	for i, p := range f.providers {
+		var v *T
+		v, err = call(p.Client)
+		if err == nil {
+			return v, nil
+		}
+		if !isProviderFailure(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		if i < len(f.providers)-1 {
+			slog.Warn("weather provider failed, trying the next one", "provider", p.Name, "error", err)
+		}
+	}
+	return nil, fmt.Errorf("all weather providers failed: %w", err)
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/softstone1/woc/domain"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFailover_FetchWeatherByCity(t *testing.T) {
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+
+	tests := []struct {
+		name            string
+		setupMocks      func(primary, secondary *domain.MockWeatherClient)
+		expectedWeather *domain.Weather
+		expectedErr     error
+	}{
+		{
+			name: "primary provider answers",
+			setupMocks: func(primary, secondary *domain.MockWeatherClient) {
+				primary.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+					Return(&domain.Weather{City: "London", Temperature: 12}, nil)
+			},
+			expectedWeather: &domain.Weather{City: "London", Temperature: 12},
+		},
+		{
+			name: "fails over when the primary provider is down",
+			setupMocks: func(primary, secondary *domain.MockWeatherClient) {
+				primary.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+					Return(nil, fmt.Errorf("%w: circuit breaker open", domain.ErrProviderUnavailable))
+				secondary.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+					Return(&domain.Weather{City: "London", Temperature: 13}, nil)
+			},
+			expectedWeather: &domain.Weather{City: "London", Temperature: 13},
+		},
+		{
+			name: "invalid requests are not tried elsewhere",
+			setupMocks: func(primary, secondary *domain.MockWeatherClient) {
+				primary.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+					Return(nil, domain.ErrInvalidInput)
+			},
+			expectedErr: domain.ErrInvalidInput,
+		},
+		{
+			name: "all providers failing",
+			setupMocks: func(primary, secondary *domain.MockWeatherClient) {
+				primary.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+					Return(nil, domain.ErrUpstreamTimeout)
+				secondary.EXPECT().FetchWeatherByCity(gomock.Any(), city, domain.Metric).
+					Return(nil, domain.ErrRateLimited)
+			},
+			expectedErr: domain.ErrRateLimited,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			primary := domain.NewMockWeatherClient(mockCtrl)
+			secondary := domain.NewMockWeatherClient(mockCtrl)
+			tc.setupMocks(primary, secondary)
+
+			f := NewFailover(Provider{Name: "primary", Client: primary}, Provider{Name: "secondary", Client: secondary})
+			weather, err := f.FetchWeatherByCity(context.Background(), city, domain.Metric)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf("Expected error %v, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if *weather != *tc.expectedWeather {
+				t.Errorf("Expected weather %+v, got %+v", tc.expectedWeather, weather)
+			}
+		})
+	}
+}
+
+func TestFailover_FetchWeatherByCities(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	london := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	paris := domain.City{Name: "Paris", Latitude: 48.8566, Longitude: 2.3522}
+	tokyo := domain.City{Name: "Tokyo", Latitude: 35.6895, Longitude: 139.6917}
+	primary := domain.NewMockBatchWeatherClient(mockCtrl)
+	primary.EXPECT().FetchWeatherByCities(gomock.Any(), []domain.City{london, paris, tokyo}, domain.Metric).
+		Return([]*domain.Weather{{City: "London"}, nil, nil}, []error{nil, domain.ErrUpstreamTimeout, domain.ErrInvalidInput})
+	// the secondary provider cannot fetch batches, it is asked city by city for the failed ones only
+	secondary := domain.NewMockWeatherClient(mockCtrl)
+	secondary.EXPECT().FetchWeatherByCity(gomock.Any(), paris, domain.Metric).Return(nil, domain.ErrRateLimited)
+
+	f := NewFailover(Provider{Name: "primary", Client: primary}, Provider{Name: "secondary", Client: secondary})
+	weathers, errs := f.FetchWeatherByCities(context.Background(), []domain.City{london, paris, tokyo}, domain.Metric)
+	if errs[0] != nil || weathers[0] == nil || weathers[0].City != "London" {
+		t.Errorf("Expected London from the primary provider, got %v, %v", weathers[0], errs[0])
+	}
+	if !errors.Is(errs[1], domain.ErrRateLimited) || errs[1].Error() != "all weather providers failed: rate limited" {
+		t.Errorf("Expected all providers to fail for Paris, got %v", errs[1])
+	}
+	if !errors.Is(errs[2], domain.ErrInvalidInput) {
+		t.Errorf("Expected Tokyo to be rejected as invalid, got %v", errs[2])
+	}
+}
+
+func TestFailover_Health(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	primary := NewCircuitBreaker(domain.NewMockWeatherClient(mockCtrl), BreakerConfig{FailureThreshold: 1})
+	primary.state = CircuitOpen
+	secondary := NewCircuitBreaker(domain.NewMockWeatherClient(mockCtrl), BreakerConfig{FailureThreshold: 1})
+
+	f := NewFailover(Provider{Name: "primary", Client: primary}, Provider{Name: "secondary", Client: secondary})
+	expected := domain.Health{Healthy: true, State: "available", Detail: "primary: open, secondary: closed"}
+	if health := f.Health(); health != expected {
+		t.Errorf("Expected health %+v, got %+v", expected, health)
+	}
+
+	secondary.state = CircuitOpen
+	expected = domain.Health{Healthy: false, State: "degraded", Detail: "primary: open, secondary: open"}
+	if health := f.Health(); health != expected {
+		t.Errorf("Expected health %+v, got %+v", expected, health)
+	}
+}
+
+func TestRegistry_New(t *testing.T) {
+	r := NewRegistry()
+	tests := []struct {
+		name      string
+		provider  string
+		expectErr bool
+	}{
+		{name: "Open-Meteo", provider: ProviderOpenMeteo},
+		{name: "MET Norway", provider: ProviderMetNorway},
+		{name: "Unknown provider", provider: "weather-rock", expectErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := r.New(tc.provider, "http://localhost")
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, got client %T", client)
+				}
+				return
+			}
+			if err != nil || client == nil {
+				t.Errorf("Expected client, got error %v", err)
+			}
+		})
+	}
+}
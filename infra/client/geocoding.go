@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/softstone1/woc/domain"
+)
+
+// geocodingResultCount is the number of candidate places requested, enough to disambiguate
+// common names by country or administrative area.
+const geocodingResultCount = 10
+
+// OpenMeteoGeocoder is a domain.Geocoder for the Open-Meteo geocoding API, or any API compatible with it.
+type OpenMeteoGeocoder struct {
+	httpProvider
+}
+
+func NewOpenMeteoGeocoder(url string, opts ...Option) *OpenMeteoGeocoder {
+	return &OpenMeteoGeocoder{httpProvider: newHTTPProvider("open-meteo-geocoding", url, opts)}
+}
+
+type GeocodingResponse struct {
+	// Results is missing when nothing matches
+	Results []struct {
+		Name        string   `json:"name"`
+		Latitude    float64  `json:"latitude"`
+		Longitude   float64  `json:"longitude"`
+		Country     string   `json:"country"`
+		CountryCode string   `json:"country_code"`
+		Admin1      string   `json:"admin1"`
+		Population  int      `json:"population"`
+		Elevation   *float64 `json:"elevation"`
+		Timezone    string   `json:"timezone"`
+	} `json:"results"`
+}
+
+// Geocode returns the places matching name, most relevant first.
+func (g *OpenMeteoGeocoder) Geocode(ctx context.Context, name string) ([]domain.Place, error) {
+	query := url.Values{
+		"name":     {name},
+		"count":    {strconv.Itoa(geocodingResultCount)},
+		"language": {"en"},
+		"format":   {"json"},
+	}
+	var data GeocodingResponse
+	if err := g.getJSON(ctx, g.baseUrl+"/v1/search?"+query.Encode(), &data); err != nil {
+		return nil, err
+	}
+	places := make([]domain.Place, 0, len(data.Results))
+	for _, r := range data.Results {
+		places = append(places, domain.Place{
+			Name:        r.Name,
+			Latitude:    r.Latitude,
+			Longitude:   r.Longitude,
+			Country:     r.Country,
+			CountryCode: r.CountryCode,
+			Admin1:      r.Admin1,
+			Population:  r.Population,
+			Elevation:   r.Elevation,
+			Timezone:    r.Timezone,
+		})
+	}
+	return places, nil
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/softstone1/woc/domain"
+)
+
+func TestGeocode(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/search" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+		switch query.Get("name") {
+		case "Paris":
+			fmt.Fprint(w, `{"results": [
+				{"id": 2988507, "name": "Paris", "latitude": 48.85341, "longitude": 2.3488, "elevation": 42.0,
+					"country_code": "FR", "admin1": "Île-de-France", "timezone": "Europe/Paris", "population": 2138551, "country": "France"},
+				{"id": 4717560, "name": "Paris", "latitude": 33.66094, "longitude": -95.55551, "elevation": 183.0,
+					"country_code": "US", "admin1": "Texas", "timezone": "America/Chicago", "population": 24171, "country": "United States"}
+			], "generationtime_ms": 0.9}`)
+		case "Nowhere":
+			fmt.Fprint(w, `{"generationtime_ms": 0.3}`)
+		case "x":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": true, "reason": "Parameter name must be at least 2 characters"}`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	geocoder := NewOpenMeteoGeocoder(server.URL)
+	parisElevation, texasElevation := 42.0, 183.0
+	testCases := []struct {
+		name           string
+		place          string
+		expectedPlaces []domain.Place
+		expectedErr    error
+	}{
+		{
+			name:  "Places found",
+			place: "Paris",
+			expectedPlaces: []domain.Place{
+				{Name: "Paris", Latitude: 48.85341, Longitude: 2.3488, Country: "France", CountryCode: "FR", Admin1: "Île-de-France", Population: 2138551,
+					Elevation: &parisElevation, Timezone: "Europe/Paris"},
+				{Name: "Paris", Latitude: 33.66094, Longitude: -95.55551, Country: "United States", CountryCode: "US", Admin1: "Texas", Population: 24171,
+					Elevation: &texasElevation, Timezone: "America/Chicago"},
+			},
+		},
+		{name: "No results", place: "Nowhere", expectedPlaces: []domain.Place{}},
+		{name: "Rejected name", place: "x", expectedErr: domain.ErrInvalidInput},
+		{name: "Server error", place: "Boom", expectedErr: domain.ErrUpstreamUnavailable},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			places, err := geocoder.Geocode(context.Background(), tc.place)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Expected error %v, but got %v", tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(places, tc.expectedPlaces) {
+				t.Errorf("Expected places %v, but got %v", tc.expectedPlaces, places)
+			}
+			if got := query.Get("count"); got != "10" {
+				t.Errorf("Expected count 10, but got %q", got)
+			}
+		})
+	}
+}
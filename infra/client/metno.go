@@ -0,0 +1,322 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/softstone1/woc/domain"
+)
+
+// unknownWeatherCode is reported for MET Norway symbols without a WMO equivalent.
+const unknownWeatherCode = -1
+
+// metNorwaySymbolCodes maps MET Norway symbol codes, without their _day/_night/_polartwilight
+// variant, to the closest WMO weather code. WMO has no sleet code, so sleet is reported as rain.
+var metNorwaySymbolCodes = map[string]int{
+	"clearsky":          0,
+	"fair":              1,
+	"partlycloudy":      2,
+	"cloudy":            3,
+	"fog":               45,
+	"lightrain":         61,
+	"rain":              63,
+	"heavyrain":         65,
+	"lightsleet":        61,
+	"sleet":             63,
+	"heavysleet":        65,
+	"lightsnow":         71,
+	"snow":              73,
+	"heavysnow":         75,
+	"lightrainshowers":  80,
+	"rainshowers":       81,
+	"heavyrainshowers":  82,
+	"lightsleetshowers": 80,
+	"sleetshowers":      81,
+	"heavysleetshowers": 82,
+	"lightsnowshowers":  85,
+	"snowshowers":       85,
+	"heavysnowshowers":  86,
+}
+
+// MetNorway is a domain.WeatherClient for the MET Norway locationforecast 2.0 API.
+// MET Norway reports metric values in UTC; they are converted to the requested units locally
+// and times are returned in UTC.
+type MetNorway struct {
+	httpProvider
+}
+
+func NewMetNorway(url string, opts ...Option) *MetNorway {
+	return &MetNorway{httpProvider: newHTTPProvider("met-norway", url, opts)}
+}
+
+// LocationForecastResponse is the part of a locationforecast "complete" response the client uses.
+type LocationForecastResponse struct {
+	Geometry struct {
+		// Coordinates are the longitude, latitude and, if known, the altitude in metres
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		Timeseries []LocationForecastStep `json:"timeseries"`
+	} `json:"properties"`
+}
+
+// LocationForecastStep is a single point of the locationforecast timeseries. Steps are hourly
+// for the first days and six-hourly after that.
+type LocationForecastStep struct {
+	Time time.Time `json:"time"`
+	Data struct {
+		Instant struct {
+			Details struct {
+				AirPressureAtSeaLevel float64 `json:"air_pressure_at_sea_level"`
+				AirTemperature        float64 `json:"air_temperature"`
+				CloudAreaFraction     float64 `json:"cloud_area_fraction"`
+				RelativeHumidity      float64 `json:"relative_humidity"`
+				WindFromDirection     float64 `json:"wind_from_direction"`
+				WindSpeed             float64 `json:"wind_speed"`
+				WindSpeedOfGust       float64 `json:"wind_speed_of_gust"`
+			} `json:"details"`
+		} `json:"instant"`
+		Next1Hours *LocationForecastPeriod `json:"next_1_hours"`
+		Next6Hours *LocationForecastPeriod `json:"next_6_hours"`
+	} `json:"data"`
+}
+
+// LocationForecastPeriod summarises the period following a step.
+type LocationForecastPeriod struct {
+	Summary struct {
+		SymbolCode string `json:"symbol_code"`
+	} `json:"summary"`
+	Details struct {
+		AirTemperatureMax          *float64 `json:"air_temperature_max"`
+		AirTemperatureMin          *float64 `json:"air_temperature_min"`
+		PrecipitationAmount        float64  `json:"precipitation_amount"`
+		ProbabilityOfPrecipitation float64  `json:"probability_of_precipitation"`
+	} `json:"details"`
+}
+
+// elevation returns the altitude of the forecast point, nil if the response does not include it.
+func (r *LocationForecastResponse) elevation() *float64 {
+	if coordinates := r.Geometry.Coordinates; len(coordinates) > 2 {
+		return &coordinates[2]
+	}
+	return nil
+}
+
+// period returns the summary of the shortest period following the step, nil if there is none.
+func (s *LocationForecastStep) period() *LocationForecastPeriod {
+	if s.Data.Next1Hours != nil {
+		return s.Data.Next1Hours
+	}
+	return s.Data.Next6Hours
+}
+
+// currentStepIndex returns the index of the step covering now.
+func (r *LocationForecastResponse) currentStepIndex(now time.Time) (int, error) {
+	for i, step := range r.Properties.Timeseries {
+		if !now.Before(step.Time) && now.Before(step.Time.Add(time.Hour)) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: no met-norway reading for the current hour", domain.ErrUpstreamUnavailable)
+}
+
+func (c *MetNorway) FetchWeatherByCity(ctx context.Context, city domain.City, units domain.Units) (*domain.Weather, error) {
+	data, err := c.fetch(ctx, city)
+	if err != nil {
+		return nil, err
+	}
+	i, err := data.currentStepIndex(c.now())
+	if err != nil {
+		return nil, err
+	}
+	step := data.Properties.Timeseries[i]
+	d := step.Data.Instant.Details
+	// MET Norway reports UTC, the reading is shown in the city's local time where it is known
+	location, timezone := time.UTC, "UTC"
+	if cityLocation, ok := city.Location(); ok {
+		location, timezone = cityLocation, city.Timezone
+	}
+	weather := &domain.Weather{
+		City:                city.Name,
+		CountryCode:         city.CountryCode,
+		Timezone:            timezone,
+		Elevation:           data.elevation(),
+		Time:                step.Time.In(location),
+		Temperature:         units.Temperature.FromCelsius(d.AirTemperature),
+		WindSpeed:           units.WindSpeed.FromMetresPerSecond(d.WindSpeed),
+		ApparentTemperature: units.Temperature.FromCelsius(apparentTemperature(d.AirTemperature, d.RelativeHumidity, d.WindSpeed)),
+		Humidity:            d.RelativeHumidity,
+		SurfacePressure:     surfacePressure(d.AirPressureAtSeaLevel, data.elevation()),
+		SeaLevelPressure:    d.AirPressureAtSeaLevel,
+		CloudCover:          d.CloudAreaFraction,
+		WindDirection:       d.WindFromDirection,
+		WindGusts:           units.WindSpeed.FromMetresPerSecond(d.WindSpeedOfGust),
+		WeatherCode:         unknownWeatherCode,
+		Units:               units.Labels(),
+	}
+	if next := step.Data.Next1Hours; next != nil {
+		weather.Precipitation = units.Precipitation.FromMillimetres(next.Details.PrecipitationAmount)
+	}
+	if period := step.period(); period != nil {
+		weather.WeatherCode = symbolWeatherCode(period.Summary.SymbolCode)
+	}
+	weather.Description, weather.Icon = domain.DescribeWeatherCode(weather.WeatherCode)
+	return weather, nil
+}
+
+// FetchForecastByCity returns the forecast for the next hours, starting at the current hour.
+// Beyond the first days MET Norway only provides six-hourly points.
+func (c *MetNorway) FetchForecastByCity(ctx context.Context, city domain.City, hours int, units domain.Units) (*domain.Forecast, error) {
+	if hours <= 0 || hours > domain.MaxForecastHours {
+		return nil, fmt.Errorf("%w: hours must be between 1 and %d", domain.ErrInvalidInput, domain.MaxForecastHours)
+	}
+	data, err := c.fetch(ctx, city)
+	if err != nil {
+		return nil, err
+	}
+	start, err := data.currentStepIndex(c.now())
+	if err != nil {
+		return nil, err
+	}
+	steps := data.Properties.Timeseries[start:]
+	end := steps[0].Time.Add(time.Duration(hours) * time.Hour)
+	forecast := &domain.Forecast{
+		City:  city.Name,
+		Units: units.Labels(),
+	}
+	for _, step := range steps {
+		if !step.Time.Before(end) {
+			break
+		}
+		d := step.Data.Instant.Details
+		forecast.Hourly = append(forecast.Hourly, domain.HourlyWeather{
+			Time:        step.Time,
+			Temperature: units.Temperature.FromCelsius(d.AirTemperature),
+			WindSpeed:   units.WindSpeed.FromMetresPerSecond(d.WindSpeed),
+		})
+	}
+	return forecast, nil
+}
+
+// FetchDailyForecastByCity returns day-by-day summaries for the given number of days in the city's
+// time zone, or UTC days if it has none, starting today. MET Norway forecasts about ten days ahead,
+// so fewer days may be returned. Sunrise and sunset are calculated locally.
+func (c *MetNorway) FetchDailyForecastByCity(ctx context.Context, city domain.City, days int, units domain.Units) (*domain.DailyForecast, error) {
+	if days <= 0 || days > domain.MaxForecastDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", domain.ErrInvalidInput, domain.MaxForecastDays)
+	}
+	data, err := c.fetch(ctx, city)
+	if err != nil {
+		return nil, err
+	}
+	start, err := data.currentStepIndex(c.now())
+	if err != nil {
+		return nil, err
+	}
+	location, ok := city.Location()
+	if !ok {
+		location = time.UTC
+	}
+	steps := data.Properties.Timeseries[start:]
+	var daily []domain.DailyWeather
+	for i, step := range steps {
+		y, m, d := step.Time.In(location).Date()
+		date := time.Date(y, m, d, 0, 0, 0, 0, location)
+		if len(daily) == 0 || !daily[len(daily)-1].Date.Equal(date) {
+			if len(daily) == days {
+				break
+			}
+			sunrise, sunset := sunTimes(date, city.Latitude, city.Longitude)
+			temperature := step.Data.Instant.Details.AirTemperature
+			daily = append(daily, domain.DailyWeather{
+				Date:           date,
+				WeatherCode:    unknownWeatherCode,
+				TemperatureMin: temperature,
+				TemperatureMax: temperature,
+				Sunrise:        sunrise,
+				Sunset:         sunset,
+			})
+		}
+		day := &daily[len(daily)-1]
+		day.TemperatureMin = math.Min(day.TemperatureMin, step.Data.Instant.Details.AirTemperature)
+		day.TemperatureMax = math.Max(day.TemperatureMax, step.Data.Instant.Details.AirTemperature)
+		// count every hour once: use the six-hour period where the series is six-hourly
+		period := step.period()
+		if next6 := step.Data.Next6Hours; next6 != nil && (i+1 == len(steps) || steps[i+1].Time.Sub(step.Time) >= 6*time.Hour) {
+			period = next6
+		}
+		if period == nil {
+			continue
+		}
+		day.PrecipitationSum += period.Details.PrecipitationAmount
+		day.PrecipitationProbabilityMax = math.Max(day.PrecipitationProbabilityMax, period.Details.ProbabilityOfPrecipitation)
+		if period.Details.AirTemperatureMin != nil {
+			day.TemperatureMin = math.Min(day.TemperatureMin, *period.Details.AirTemperatureMin)
+		}
+		if period.Details.AirTemperatureMax != nil {
+			day.TemperatureMax = math.Max(day.TemperatureMax, *period.Details.AirTemperatureMax)
+		}
+		// like Open-Meteo, report the most severe condition of the day
+		day.WeatherCode = max(day.WeatherCode, symbolWeatherCode(period.Summary.SymbolCode))
+	}
+	for i := range daily {
+		day := &daily[i]
+		day.Description, day.Icon = domain.DescribeWeatherCode(day.WeatherCode)
+		day.TemperatureMin = units.Temperature.FromCelsius(day.TemperatureMin)
+		day.TemperatureMax = units.Temperature.FromCelsius(day.TemperatureMax)
+		day.PrecipitationSum = units.Precipitation.FromMillimetres(day.PrecipitationSum)
+	}
+	return &domain.DailyForecast{
+		City:  city.Name,
+		Units: units.Labels(),
+		Daily: daily,
+	}, nil
+}
+
+// fetch requests the complete locationforecast for the city.
+func (c *MetNorway) fetch(ctx context.Context, city domain.City) (*LocationForecastResponse, error) {
+	query := url.Values{
+		"lat": {formatCoordinate(city.Latitude)},
+		"lon": {formatCoordinate(city.Longitude)},
+	}
+	var data LocationForecastResponse
+	if err := c.getJSON(ctx, c.baseUrl+"/weatherapi/locationforecast/2.0/complete?"+query.Encode(), &data); err != nil {
+		return nil, err
+	}
+	if len(data.Properties.Timeseries) == 0 {
+		return nil, fmt.Errorf("%w: met-norway returned an empty timeseries", domain.ErrUpstreamUnavailable)
+	}
+	return &data, nil
+}
+
+// symbolWeatherCode returns the WMO weather code for a MET Norway symbol code.
+func symbolWeatherCode(symbol string) int {
+	base, _, _ := strings.Cut(symbol, "_")
+	if strings.HasSuffix(base, "andthunder") {
+		return 95
+	}
+	if code, ok := metNorwaySymbolCodes[base]; ok {
+		return code
+	}
+	return unknownWeatherCode
+}
+
+// apparentTemperature is the Australian apparent temperature (Steadman) in °C for a temperature
+// in °C, relative humidity in % and wind speed in m/s, the same measure Open-Meteo reports.
+// surfacePressure reduces the sea-level pressure to the elevation with the international barometric
+// formula, as MET Norway only reports the pressure at sea level.
+func surfacePressure(seaLevel float64, elevation *float64) float64 {
+	if elevation == nil {
+		return seaLevel
+	}
+	return math.Round(seaLevel*math.Pow(1-2.25577e-5**elevation, 5.25588)*10) / 10
+}
+
+func apparentTemperature(temperature, humidity, windSpeed float64) float64 {
+	vapourPressure := humidity / 100 * 6.105 * math.Exp(17.27*temperature/(237.7+temperature))
+	return temperature + 0.33*vapourPressure - 0.70*windSpeed - 4.00
+}
@@ -0,0 +1,338 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/softstone1/woc/domain"
+)
+
+const locationForecastResponse = `{
+	"type": "Feature",
+	"geometry": {"type": "Point", "coordinates": [-0.1278, 51.5074, 19]},
+	"properties": {
+		"timeseries": [
+			{"time": "2024-05-01T22:00:00Z", "data": {
+				"instant": {"details": {"air_pressure_at_sea_level": 1012.0, "air_temperature": 12.0, "cloud_area_fraction": 90, "relative_humidity": 80, "wind_from_direction": 200, "wind_speed": 2.0, "wind_speed_of_gust": 5.0}},
+				"next_1_hours": {"summary": {"symbol_code": "lightrain"}, "details": {"precipitation_amount": 0.4, "probability_of_precipitation": 60}},
+				"next_6_hours": {"summary": {"symbol_code": "rain"}, "details": {"air_temperature_max": 12.5, "air_temperature_min": 9.5, "precipitation_amount": 2.0, "probability_of_precipitation": 70}}
+			}},
+			{"time": "2024-05-01T23:00:00Z", "data": {
+				"instant": {"details": {"air_pressure_at_sea_level": 1011.8, "air_temperature": 11.5, "cloud_area_fraction": 95, "relative_humidity": 82, "wind_from_direction": 210, "wind_speed": 2.5, "wind_speed_of_gust": 5.5}},
+				"next_1_hours": {"summary": {"symbol_code": "rain"}, "details": {"precipitation_amount": 0.8, "probability_of_precipitation": 70}},
+				"next_6_hours": {"summary": {"symbol_code": "rain"}, "details": {"air_temperature_max": 12.0, "air_temperature_min": 9.0, "precipitation_amount": 2.5, "probability_of_precipitation": 75}}
+			}},
+			{"time": "2024-05-02T00:00:00Z", "data": {
+				"instant": {"details": {"air_pressure_at_sea_level": 1011.5, "air_temperature": 11.0, "cloud_area_fraction": 100, "relative_humidity": 85, "wind_from_direction": 220, "wind_speed": 3.0, "wind_speed_of_gust": 7.0}},
+				"next_1_hours": {"summary": {"symbol_code": "cloudy"}, "details": {"precipitation_amount": 0, "probability_of_precipitation": 20}},
+				"next_6_hours": {"summary": {"symbol_code": "lightrainshowersandthunder_night"}, "details": {"air_temperature_max": 11.5, "air_temperature_min": 9.0, "precipitation_amount": 3.0, "probability_of_precipitation": 80}}
+			}},
+			{"time": "2024-05-02T06:00:00Z", "data": {
+				"instant": {"details": {"air_pressure_at_sea_level": 1013.0, "air_temperature": 10.0, "cloud_area_fraction": 20, "relative_humidity": 70, "wind_from_direction": 250, "wind_speed": 4.0, "wind_speed_of_gust": 8.0}},
+				"next_6_hours": {"summary": {"symbol_code": "fair_day"}, "details": {"air_temperature_max": 17.0, "air_temperature_min": 10.0, "precipitation_amount": 0, "probability_of_precipitation": 5}}
+			}}
+		]
+	}
+}`
+
+func newMetNorwayServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/weatherapi/locationforecast/2.0/complete" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("lat") != "51.5074" || r.URL.Query().Get("lon") != "-0.1278" {
+			t.Errorf("Unexpected coordinates in query %q", r.URL.RawQuery)
+		}
+		// MET Norway rejects requests without an identifying User-Agent
+		if r.Header.Get("User-Agent") == "" || r.Header.Get("User-Agent") == "Go-http-client/1.1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, locationForecastResponse)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMetNorway_FetchWeatherByCity(t *testing.T) {
+	server := newMetNorwayServer(t)
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	now := time.Date(2024, 5, 1, 22, 30, 0, 0, time.UTC)
+	elevation := 19.0
+
+	testCases := []struct {
+		name                string
+		units               domain.Units
+		expectedWeather     *domain.Weather
+		expectedApparentTmp float64
+	}{
+		{
+			name:  "Metric",
+			units: domain.Metric,
+			expectedWeather: &domain.Weather{
+				City:             "London",
+				Timezone:         "UTC",
+				Elevation:        &elevation,
+				Time:             time.Date(2024, 5, 1, 22, 0, 0, 0, time.UTC),
+				Temperature:      12.0,
+				WindSpeed:        7.2,
+				Humidity:         80,
+				Precipitation:    0.4,
+				SurfacePressure:  1009.7,
+				SeaLevelPressure: 1012.0,
+				CloudCover:       90,
+				WindDirection:    200,
+				WindGusts:        18,
+				WeatherCode:      61,
+				Description:      "Slight rain",
+				Icon:             "rain",
+				Units:            domain.Metric.Labels(),
+			},
+			expectedApparentTmp: 10.3,
+		},
+		{
+			name:  "Imperial",
+			units: domain.Imperial,
+			expectedWeather: &domain.Weather{
+				City:             "London",
+				Timezone:         "UTC",
+				Elevation:        &elevation,
+				Time:             time.Date(2024, 5, 1, 22, 0, 0, 0, time.UTC),
+				Temperature:      53.6,
+				WindSpeed:        4.474,
+				Humidity:         80,
+				Precipitation:    0.016,
+				SurfacePressure:  1009.7,
+				SeaLevelPressure: 1012.0,
+				CloudCover:       90,
+				WindDirection:    200,
+				WindGusts:        11.185,
+				WeatherCode:      61,
+				Description:      "Slight rain",
+				Icon:             "rain",
+				Units:            domain.Imperial.Labels(),
+			},
+			expectedApparentTmp: 50.53,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewMetNorway(server.URL, WithClock(func() time.Time { return now }))
+			weather, err := client.FetchWeatherByCity(context.Background(), city, tc.units)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if math.Abs(weather.ApparentTemperature-tc.expectedApparentTmp) > 0.01 {
+				t.Errorf("Expected apparent temperature %v, but got %v", tc.expectedApparentTmp, weather.ApparentTemperature)
+			}
+			for _, v := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"temperature", weather.Temperature, tc.expectedWeather.Temperature},
+				{"wind speed", weather.WindSpeed, tc.expectedWeather.WindSpeed},
+				{"wind gusts", weather.WindGusts, tc.expectedWeather.WindGusts},
+				{"precipitation", weather.Precipitation, tc.expectedWeather.Precipitation},
+			} {
+				if math.Abs(v.got-v.want) > 0.001 {
+					t.Errorf("Expected %s %v, but got %v", v.name, v.want, v.got)
+				}
+			}
+			expected := *tc.expectedWeather
+			expected.ApparentTemperature = weather.ApparentTemperature
+			expected.Temperature, expected.WindSpeed = weather.Temperature, weather.WindSpeed
+			expected.WindGusts, expected.Precipitation = weather.WindGusts, weather.Precipitation
+			if !reflect.DeepEqual(weather, &expected) {
+				t.Errorf("Expected weather %v, but got %v", expected, weather)
+			}
+		})
+	}
+}
+
+func TestMetNorway_FetchWeatherByCity_LocalTime(t *testing.T) {
+	server := newMetNorwayServer(t)
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278, Timezone: "Europe/London"}
+	client := NewMetNorway(server.URL, WithClock(func() time.Time {
+		return time.Date(2024, 5, 1, 22, 30, 0, 0, time.UTC)
+	}))
+
+	weather, err := client.FetchWeatherByCity(context.Background(), city, domain.Metric)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if zone := weather.Time.Location().String(); zone != "Europe/London" || weather.Timezone != "Europe/London" {
+		t.Errorf("Expected the reading in Europe/London, but got time zone %q and timezone %q", zone, weather.Timezone)
+	}
+	if expected := time.Date(2024, 5, 1, 22, 0, 0, 0, time.UTC); !weather.Time.Equal(expected) {
+		t.Errorf("Expected time %v, but got %v", expected, weather.Time)
+	}
+}
+
+func TestMetNorway_FetchForecastByCity(t *testing.T) {
+	server := newMetNorwayServer(t)
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	client := NewMetNorway(server.URL, WithClock(func() time.Time {
+		return time.Date(2024, 5, 1, 23, 10, 0, 0, time.UTC)
+	}))
+
+	forecast, err := client.FetchForecastByCity(context.Background(), city, 2, domain.Metric)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := &domain.Forecast{
+		City:  "London",
+		Units: domain.Metric.Labels(),
+		Hourly: []domain.HourlyWeather{
+			{Time: time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC), Temperature: 11.5, WindSpeed: 9},
+			{Time: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), Temperature: 11.0, WindSpeed: 10.8},
+		},
+	}
+	if !reflect.DeepEqual(forecast, expected) {
+		t.Errorf("Expected forecast %v, but got %v", expected, forecast)
+	}
+
+	if _, err := client.FetchForecastByCity(context.Background(), city, domain.MaxForecastHours+1, domain.Metric); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("Expected invalid input error, but got %v", err)
+	}
+}
+
+func TestMetNorway_FetchDailyForecastByCity(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	server := newMetNorwayServer(t)
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278, Timezone: "Europe/London"}
+	client := NewMetNorway(server.URL, WithClock(func() time.Time {
+		return time.Date(2024, 5, 1, 22, 30, 0, 0, time.UTC)
+	}))
+
+	forecast, err := client.FetchDailyForecastByCity(context.Background(), city, 7, domain.Metric)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(forecast.Daily) != 2 {
+		t.Fatalf("Expected the 2 days MET Norway returned, but got %d", len(forecast.Daily))
+	}
+	// days run from midnight to midnight BST, so 23:00 UTC already belongs to 2 May
+	expected := []domain.DailyWeather{
+		{
+			Date:                        time.Date(2024, 5, 1, 0, 0, 0, 0, london),
+			WeatherCode:                 61,
+			Description:                 "Slight rain",
+			Icon:                        "rain",
+			TemperatureMin:              12.0,
+			TemperatureMax:              12.0,
+			PrecipitationSum:            0.4,
+			PrecipitationProbabilityMax: 60,
+			Sunrise:                     time.Date(2024, 5, 1, 5, 33, 0, 0, london),
+			Sunset:                      time.Date(2024, 5, 1, 20, 25, 0, 0, london),
+		},
+		{
+			Date:                        time.Date(2024, 5, 2, 0, 0, 0, 0, london),
+			WeatherCode:                 95,
+			Description:                 "Thunderstorm",
+			Icon:                        "thunderstorm",
+			TemperatureMin:              9.0,
+			TemperatureMax:              17.0,
+			PrecipitationSum:            3.8,
+			PrecipitationProbabilityMax: 80,
+			Sunrise:                     time.Date(2024, 5, 2, 5, 31, 0, 0, london),
+			Sunset:                      time.Date(2024, 5, 2, 20, 27, 0, 0, london),
+		},
+	}
+	for i := range expected {
+		got := forecast.Daily[i]
+		if !got.Date.Equal(expected[i].Date) || got.Date.Location().String() != "Europe/London" {
+			t.Errorf("Expected day %d to start at %v, but got %v", i, expected[i].Date, got.Date)
+		}
+		if !closeTo(got.Sunrise, expected[i].Sunrise) || !closeTo(got.Sunset, expected[i].Sunset) {
+			t.Errorf("Expected sunrise %v and sunset %v on day %d, but got %v and %v",
+				expected[i].Sunrise, expected[i].Sunset, i, got.Sunrise, got.Sunset)
+		}
+		if math.Abs(got.PrecipitationSum-expected[i].PrecipitationSum) > 0.001 {
+			t.Errorf("Expected precipitation sum %v on day %d, but got %v", expected[i].PrecipitationSum, i, got.PrecipitationSum)
+		}
+		got.Date, got.Sunrise, got.Sunset = expected[i].Date, expected[i].Sunrise, expected[i].Sunset
+		got.PrecipitationSum = expected[i].PrecipitationSum
+		if !reflect.DeepEqual(got, expected[i]) {
+			t.Errorf("Expected day %d to be %v, but got %v", i, expected[i], got)
+		}
+	}
+
+	forecast, err = client.FetchDailyForecastByCity(context.Background(), city, 1, domain.Metric)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(forecast.Daily) != 1 {
+		t.Errorf("Expected 1 day, but got %d", len(forecast.Daily))
+	}
+
+	// without a time zone days are UTC days
+	city.Timezone = ""
+	forecast, err = client.FetchDailyForecastByCity(context.Background(), city, 7, domain.Metric)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(forecast.Daily) != 2 || !forecast.Daily[1].Date.Equal(time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected UTC days, but got %v", forecast.Daily)
+	}
+}
+
+func TestMetNorway_Errors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		status        int
+		body          string
+		expectedErr   error
+		expectedMatch string
+	}{
+		{name: "Throttled", status: http.StatusTooManyRequests, expectedErr: domain.ErrRateLimited},
+		{name: "Server error", status: http.StatusBadGateway, expectedErr: domain.ErrUpstreamUnavailable, expectedMatch: "upstream unavailable: met-norway returned status 502"},
+		{name: "Empty timeseries", status: http.StatusOK, body: `{"properties": {"timeseries": []}}`, expectedErr: domain.ErrUpstreamUnavailable},
+	}
+
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			}))
+			defer server.Close()
+
+			_, err := NewMetNorway(server.URL).FetchWeatherByCity(context.Background(), city, domain.Metric)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Expected error %v, but got %v", tc.expectedErr, err)
+			}
+			if tc.expectedMatch != "" && err.Error() != tc.expectedMatch {
+				t.Errorf("Expected error message %q, but got %q", tc.expectedMatch, err.Error())
+			}
+		})
+	}
+}
+
+func TestSymbolWeatherCode(t *testing.T) {
+	testCases := map[string]int{
+		"clearsky_day":                     0,
+		"partlycloudy_polartwilight":       2,
+		"heavysnowshowers_night":           86,
+		"heavyrainandthunder":              95,
+		"lightssleetshowersandthunder_day": 95,
+		"somethingnew":                     unknownWeatherCode,
+	}
+	for symbol, expected := range testCases {
+		if code := symbolWeatherCode(symbol); code != expected {
+			t.Errorf("Expected code %d for %q, but got %d", expected, symbol, code)
+		}
+	}
+}
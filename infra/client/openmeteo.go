@@ -2,75 +2,325 @@ package client
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net"
-	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/softstone1/woc/domain"
 )
 
 const (
-	// client request timeout
-	timeout               = 30 * time.Second
-	maxIdleConns          = 5
-	maxIdleConnsPerHost   = 3
-	dialTimeout           = time.Second
-	keepAlive             = time.Minute
-	responseHeaderTimeout = time.Second
-	tlsHandshakeTimeout   = 2 * time.Second
+	// hourlyTimeLayout is the ISO8601 layout Open-Meteo uses for hourly timestamps (local time, no offset)
+	hourlyTimeLayout = "2006-01-02T15:04"
+	// dailyTimeLayout is the layout Open-Meteo uses for daily dates
+	dailyTimeLayout = "2006-01-02"
+
+	hourlyVariables  = "temperature_2m,wind_speed_10m"
+	currentVariables = hourlyVariables + ",apparent_temperature,relative_humidity_2m,precipitation,surface_pressure,pressure_msl,cloud_cover,wind_direction_10m,wind_gusts_10m,weather_code"
+	dailyVariables   = "weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum,precipitation_probability_max,sunrise,sunset"
+
+	// maxLocationsPerRequest bounds the coordinates sent in a single multi-location request,
+	// keeping the URL short
+	maxLocationsPerRequest = 50
 )
 
+// OpenMeteo is a domain.WeatherClient for the Open-Meteo forecast API.
 type OpenMeteo struct {
-	baseUrl string
-	client  *http.Client
+	httpProvider
 }
 
-func NewOpenMeteo(url string) *OpenMeteo {
-	return &OpenMeteo{
-		baseUrl: url,
-		client: &http.Client{
-			Timeout: timeout,
-			Transport: &http.Transport{
-				MaxIdleConns:        maxIdleConns,
-				MaxIdleConnsPerHost: maxIdleConnsPerHost,
-				DialContext: (&net.Dialer{
-					Timeout:   dialTimeout,
-					KeepAlive: keepAlive,
-				}).DialContext,
-				ResponseHeaderTimeout: responseHeaderTimeout,
-				TLSHandshakeTimeout:   tlsHandshakeTimeout,
-			},
-		},
-	}
+func NewOpenMeteo(url string, opts ...Option) *OpenMeteo {
+	return &OpenMeteo{httpProvider: newHTTPProvider("open-meteo", url, opts)}
 }
 
 type WeatherReponse struct {
-	Hourly struct {
-        Temperature2m []float64 `json:"temperature_2m"`
+	// Elevation is the elevation in metres the forecast is downscaled to
+	Elevation        *float64 `json:"elevation"`
+	Timezone         string   `json:"timezone"`
+	UtcOffsetSeconds int      `json:"utc_offset_seconds"`
+	Hourly           struct {
+		Time          []string  `json:"time"`
+		Temperature2m []float64 `json:"temperature_2m"`
 		WindSpeed10m  []float64 `json:"wind_speed_10m"`
-    } `json:"hourly"`
+		// current conditions only, empty for forecasts
+		ApparentTemperature []float64 `json:"apparent_temperature"`
+		RelativeHumidity2m  []float64 `json:"relative_humidity_2m"`
+		Precipitation       []float64 `json:"precipitation"`
+		SurfacePressure     []float64 `json:"surface_pressure"`
+		PressureMsl         []float64 `json:"pressure_msl"`
+		CloudCover          []float64 `json:"cloud_cover"`
+		WindDirection10m    []float64 `json:"wind_direction_10m"`
+		WindGusts10m        []float64 `json:"wind_gusts_10m"`
+		WeatherCode         []int     `json:"weather_code"`
+	} `json:"hourly"`
+	Daily struct {
+		Time                        []string  `json:"time"`
+		WeatherCode                 []int     `json:"weather_code"`
+		Temperature2mMax            []float64 `json:"temperature_2m_max"`
+		Temperature2mMin            []float64 `json:"temperature_2m_min"`
+		PrecipitationSum            []float64 `json:"precipitation_sum"`
+		PrecipitationProbabilityMax []float64 `json:"precipitation_probability_max"`
+		Sunrise                     []string  `json:"sunrise"`
+		Sunset                      []string  `json:"sunset"`
+	} `json:"daily"`
+}
+
+// location returns the time zone the timestamps are expressed in. The IANA zone is used so that
+// times past a daylight saving change are right, the current UTC offset if the zone is unknown.
+func (r *WeatherReponse) location() *time.Location {
+	if location, ok := (domain.City{Timezone: r.Timezone}).Location(); ok {
+		return location
+	}
+	return time.FixedZone(r.Timezone, r.UtcOffsetSeconds)
 }
 
-func (c *OpenMeteo) FetchWeatherByCity(ctx context.Context, city domain.City) (*domain.Weather, error) {
-	url := fmt.Sprintf("%s/v1/forecast?latitude=%s&longitude=%s&hourly=temperature_2m,wind_speed_10m", c.baseUrl, city.Latitude, city.Longitude)
-	resp, err := c.client.Get(url)
+// currentHourIndex returns the index of the hourly reading covering now.
+func (r *WeatherReponse) currentHourIndex(now time.Time) (int, time.Time, error) {
+	n := len(r.Hourly.Time)
+	if len(r.Hourly.Temperature2m) < n || len(r.Hourly.WindSpeed10m) < n {
+		return 0, time.Time{}, fmt.Errorf("%w: hourly series have mismatched lengths", domain.ErrUpstreamUnavailable)
+	}
+	loc := r.location()
+	for i, raw := range r.Hourly.Time {
+		t, err := time.ParseInLocation(hourlyTimeLayout, raw, loc)
+		if err != nil {
+			return 0, time.Time{}, fmt.Errorf("%w: invalid hourly time %q: %w", domain.ErrUpstreamUnavailable, raw, err)
+		}
+		if !now.Before(t) && now.Before(t.Add(time.Hour)) {
+			return i, t, nil
+		}
+	}
+	return 0, time.Time{}, fmt.Errorf("%w: no hourly reading for the current hour", domain.ErrUpstreamUnavailable)
+}
+
+func (c *OpenMeteo) FetchWeatherByCity(ctx context.Context, city domain.City, units domain.Units) (*domain.Weather, error) {
+	data, err := c.fetch(ctx, city, units, currentQuery())
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	return c.currentWeather(city, data, units)
+}
+
+// FetchWeatherByCities returns the current weather of several cities, asking for up to
+// maxLocationsPerRequest of them in a single request with comma-separated coordinates.
+// A failed request fails every city it asked for.
+func (c *OpenMeteo) FetchWeatherByCities(ctx context.Context, cities []domain.City, units domain.Units) ([]*domain.Weather, []error) {
+	weathers := make([]*domain.Weather, len(cities))
+	errs := make([]error, len(cities))
+	for start := 0; start < len(cities); start += maxLocationsPerRequest {
+		chunk := cities[start:min(start+maxLocationsPerRequest, len(cities))]
+		data, err := c.fetchLocations(ctx, chunk, units, currentQuery())
+		for i, city := range chunk {
+			if err != nil {
+				errs[start+i] = err
+				continue
+			}
+			weathers[start+i], errs[start+i] = c.currentWeather(city, &data[i], units)
+		}
 	}
-	var data WeatherReponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+	return weathers, errs
+}
+
+// currentQuery returns the variable query for the current weather.
+func currentQuery() url.Values {
+	return url.Values{
+		"hourly":        {currentVariables},
+		"forecast_days": {"1"},
+	}
+}
+
+// currentWeather picks the reading for the current hour out of the city's hourly series.
+func (c *OpenMeteo) currentWeather(city domain.City, data *WeatherReponse, units domain.Units) (*domain.Weather, error) {
+	i, observedAt, err := data.currentHourIndex(c.now())
+	if err != nil {
 		return nil, err
 	}
+	h := data.Hourly
+	for _, series := range [][]float64{h.ApparentTemperature, h.RelativeHumidity2m, h.Precipitation, h.SurfacePressure, h.PressureMsl, h.CloudCover, h.WindDirection10m, h.WindGusts10m} {
+		if len(series) <= i {
+			return nil, fmt.Errorf("%w: hourly series have mismatched lengths", domain.ErrUpstreamUnavailable)
+		}
+	}
+	if len(h.WeatherCode) <= i {
+		return nil, fmt.Errorf("%w: hourly series have mismatched lengths", domain.ErrUpstreamUnavailable)
+	}
+	description, icon := domain.DescribeWeatherCode(h.WeatherCode[i])
 	return &domain.Weather{
-		City:        city.Name,
-		Temperature: data.Hourly.Temperature2m[0],
-		WindSpeed:   data.Hourly.WindSpeed10m[0],
+		City:                city.Name,
+		CountryCode:         city.CountryCode,
+		Timezone:            data.Timezone,
+		Elevation:           data.Elevation,
+		Time:                observedAt,
+		Temperature:         h.Temperature2m[i],
+		WindSpeed:           h.WindSpeed10m[i],
+		ApparentTemperature: h.ApparentTemperature[i],
+		Humidity:            h.RelativeHumidity2m[i],
+		Precipitation:       h.Precipitation[i],
+		SurfacePressure:     h.SurfacePressure[i],
+		SeaLevelPressure:    h.PressureMsl[i],
+		CloudCover:          h.CloudCover[i],
+		WindDirection:       h.WindDirection10m[i],
+		WindGusts:           h.WindGusts10m[i],
+		WeatherCode:         h.WeatherCode[i],
+		Description:         description,
+		Icon:                icon,
+		Units:               units.Labels(),
 	}, nil
+}
+
+// FetchForecastByCity returns the hourly forecast for the next hours, starting at the current hour.
+func (c *OpenMeteo) FetchForecastByCity(ctx context.Context, city domain.City, hours int, units domain.Units) (*domain.Forecast, error) {
+	if hours <= 0 || hours > domain.MaxForecastHours {
+		return nil, fmt.Errorf("%w: hours must be between 1 and %d", domain.ErrInvalidInput, domain.MaxForecastHours)
+	}
+	// the series starts at local midnight, so one extra day covers the hours already elapsed today
+	days := min(hours/24+2, domain.MaxForecastDays)
+	data, err := c.fetch(ctx, city, units, url.Values{
+		"hourly":        {hourlyVariables},
+		"forecast_days": {strconv.Itoa(days)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	start, _, err := data.currentHourIndex(c.now())
+	if err != nil {
+		return nil, err
+	}
+	end := start + hours
+	if end > len(data.Hourly.Time) {
+		return nil, fmt.Errorf("%w: open-meteo returned %d hours, %d requested", domain.ErrUpstreamUnavailable, len(data.Hourly.Time)-start, hours)
+	}
+	loc := data.location()
+	forecast := &domain.Forecast{
+		City:   city.Name,
+		Units:  units.Labels(),
+		Hourly: make([]domain.HourlyWeather, 0, end-start),
+	}
+	for i := start; i < end; i++ {
+		t, err := time.ParseInLocation(hourlyTimeLayout, data.Hourly.Time[i], loc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid hourly time %q: %w", domain.ErrUpstreamUnavailable, data.Hourly.Time[i], err)
+		}
+		forecast.Hourly = append(forecast.Hourly, domain.HourlyWeather{
+			Time:        t,
+			Temperature: data.Hourly.Temperature2m[i],
+			WindSpeed:   data.Hourly.WindSpeed10m[i],
+		})
+	}
+	return forecast, nil
+}
 
+// FetchDailyForecastByCity returns day-by-day summaries for the given number of days, starting today.
+func (c *OpenMeteo) FetchDailyForecastByCity(ctx context.Context, city domain.City, days int, units domain.Units) (*domain.DailyForecast, error) {
+	if days <= 0 || days > domain.MaxForecastDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", domain.ErrInvalidInput, domain.MaxForecastDays)
+	}
+	data, err := c.fetch(ctx, city, units, url.Values{
+		"daily":         {dailyVariables},
+		"forecast_days": {strconv.Itoa(days)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	d := data.Daily
+	n := len(d.Time)
+	if len(d.WeatherCode) < n || len(d.Temperature2mMax) < n || len(d.Temperature2mMin) < n ||
+		len(d.PrecipitationSum) < n || len(d.PrecipitationProbabilityMax) < n || len(d.Sunrise) < n || len(d.Sunset) < n {
+		return nil, fmt.Errorf("%w: daily series have mismatched lengths", domain.ErrUpstreamUnavailable)
+	}
+	loc := data.location()
+	forecast := &domain.DailyForecast{
+		City:  city.Name,
+		Units: units.Labels(),
+		Daily: make([]domain.DailyWeather, 0, n),
+	}
+	for i := range n {
+		date, err := time.ParseInLocation(dailyTimeLayout, d.Time[i], loc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid daily date %q: %w", domain.ErrUpstreamUnavailable, d.Time[i], err)
+		}
+		sunrise, err := time.ParseInLocation(hourlyTimeLayout, d.Sunrise[i], loc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid sunrise time %q: %w", domain.ErrUpstreamUnavailable, d.Sunrise[i], err)
+		}
+		sunset, err := time.ParseInLocation(hourlyTimeLayout, d.Sunset[i], loc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid sunset time %q: %w", domain.ErrUpstreamUnavailable, d.Sunset[i], err)
+		}
+		description, icon := domain.DescribeWeatherCode(d.WeatherCode[i])
+		forecast.Daily = append(forecast.Daily, domain.DailyWeather{
+			Date:                        date,
+			WeatherCode:                 d.WeatherCode[i],
+			Description:                 description,
+			Icon:                        icon,
+			TemperatureMin:              d.Temperature2mMin[i],
+			TemperatureMax:              d.Temperature2mMax[i],
+			PrecipitationSum:            d.PrecipitationSum[i],
+			PrecipitationProbabilityMax: d.PrecipitationProbabilityMax[i],
+			Sunrise:                     sunrise,
+			Sunset:                      sunset,
+		})
+	}
+	return forecast, nil
+}
+
+// fetch requests a forecast for the city with the given variable query, in the city's local time zone
+// and the requested units.
+func (c *OpenMeteo) fetch(ctx context.Context, city domain.City, units domain.Units, query url.Values) (*WeatherReponse, error) {
+	query.Set("latitude", formatCoordinate(city.Latitude))
+	query.Set("longitude", formatCoordinate(city.Longitude))
+	query.Set("timezone", "auto")
+	setUnits(query, units)
+	var data WeatherReponse
+	if err := c.getJSON(ctx, c.baseUrl+"/v1/forecast?"+query.Encode(), &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// fetchLocations requests a forecast for several cities at once, returning one response per city
+// in the same order.
+func (c *OpenMeteo) fetchLocations(ctx context.Context, cities []domain.City, units domain.Units, query url.Values) ([]WeatherReponse, error) {
+	// a single location is answered with an object rather than an array
+	if len(cities) == 1 {
+		data, err := c.fetch(ctx, cities[0], units, query)
+		if err != nil {
+			return nil, err
+		}
+		return []WeatherReponse{*data}, nil
+	}
+	latitudes := make([]string, len(cities))
+	longitudes := make([]string, len(cities))
+	for i, city := range cities {
+		latitudes[i] = formatCoordinate(city.Latitude)
+		longitudes[i] = formatCoordinate(city.Longitude)
+	}
+	query.Set("latitude", strings.Join(latitudes, ","))
+	query.Set("longitude", strings.Join(longitudes, ","))
+	query.Set("timezone", "auto")
+	setUnits(query, units)
+	var data []WeatherReponse
+	if err := c.getJSON(ctx, c.baseUrl+"/v1/forecast?"+query.Encode(), &data); err != nil {
+		return nil, err
+	}
+	if len(data) != len(cities) {
+		return nil, fmt.Errorf("%w: %s returned %d locations for %d requested", domain.ErrUpstreamUnavailable, c.name, len(data), len(cities))
+	}
+	return data, nil
+}
+
+// setUnits adds the unit query parameters for the units that are set.
+// Open-Meteo defaults to metric units for the ones left out.
+func setUnits(query url.Values, units domain.Units) {
+	if units.Temperature != "" {
+		query.Set("temperature_unit", string(units.Temperature))
+	}
+	if units.WindSpeed != "" {
+		query.Set("wind_speed_unit", string(units.WindSpeed))
+	}
+	if units.Precipitation != "" {
+		query.Set("precipitation_unit", string(units.Precipitation))
+	}
 }
@@ -2,77 +2,209 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/softstone1/woc/domain"
 )
 
 func TestFetchWeatherByCity(t *testing.T) {
+	var query url.Values
 	// Create a test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Check the URL and respond with mock data
 		if r.URL.Path == "/v1/forecast" {
-			fmt.Fprint(w, `{"hourly": {"temperature_2m": [25.5], "wind_speed_10m": [10.2]}}`)
+			query = r.URL.Query()
+			fmt.Fprint(w, `{
+				"elevation": 40.0,
+				"timezone": "Asia/Tokyo",
+				"utc_offset_seconds": 32400,
+				"hourly": {
+					"time": ["2024-05-01T00:00", "2024-05-01T01:00", "2024-05-01T02:00"],
+					"temperature_2m": [25.5, 24.1, 23.8],
+					"wind_speed_10m": [10.2, 9.7, 8.4],
+					"apparent_temperature": [27.1, 25.0, 24.2],
+					"relative_humidity_2m": [78, 80, 83],
+					"precipitation": [0, 0.2, 1.4],
+					"surface_pressure": [1006.1, 1005.8, 1005.3],
+					"pressure_msl": [1008.4, 1008.1, 1007.6],
+					"cloud_cover": [40, 75, 100],
+					"wind_direction_10m": [180, 190, 200],
+					"wind_gusts_10m": [18.7, 17.3, 15.1],
+					"weather_code": [2, 51, 63]
+				}
+			}`)
 		} else {
 			http.NotFound(w, r)
 		}
 	}))
 	defer server.Close()
 
-	// Create a new OpenMeteo client with the test server URL
-	client := NewOpenMeteo(server.URL)
+	tokyo := time.FixedZone("Asia/Tokyo", 9*60*60)
+	elevation := 40.0
 
 	// Define test cases
 	testCases := []struct {
-		name           string
-		city           domain.City
+		name            string
+		city            domain.City
+		units           domain.Units
+		now             time.Time
 		expectedWeather *domain.Weather
+		expectErr       bool
 	}{
 		{
 			name: "Test case 1",
 			city: domain.City{
 				Name:      "New York",
-				Latitude:  "40.7128",
-				Longitude: "-74.0060",
+				Latitude:  40.7128,
+				Longitude: -74.0060,
 			},
+			units: domain.Metric,
+			now:   time.Date(2024, 5, 1, 0, 30, 0, 0, tokyo),
 			expectedWeather: &domain.Weather{
-				City:        "New York",
-				Temperature: 25.5,
-				WindSpeed:   10.2,
+				City:                "New York",
+				Timezone:            "Asia/Tokyo",
+				Elevation:           &elevation,
+				Time:                time.Date(2024, 5, 1, 0, 0, 0, 0, tokyo),
+				Temperature:         25.5,
+				WindSpeed:           10.2,
+				ApparentTemperature: 27.1,
+				Humidity:            78,
+				Precipitation:       0,
+				SurfacePressure:     1006.1,
+				SeaLevelPressure:    1008.4,
+				CloudCover:          40,
+				WindDirection:       180,
+				WindGusts:           18.7,
+				WeatherCode:         2,
+				Description:         "Partly cloudy",
+				Icon:                "partly-cloudy",
+				Units:               domain.UnitLabels{Temperature: "°C", WindSpeed: "km/h", Precipitation: "mm"},
 			},
 		},
 		{
 			name: "Test case 2",
 			city: domain.City{
 				Name:      "TestCity",
-				Latitude:  "123.456",
-				Longitude: "789.012",
+				Latitude:  123.456,
+				Longitude: 789.012,
 			},
+			units: domain.Units{},
+			now:   time.Date(2024, 5, 1, 0, 30, 0, 0, tokyo),
 			expectedWeather: &domain.Weather{
-				City:        "TestCity",
-				Temperature: 25.5,
-				WindSpeed:   10.2,
+				City:                "TestCity",
+				Timezone:            "Asia/Tokyo",
+				Elevation:           &elevation,
+				Time:                time.Date(2024, 5, 1, 0, 0, 0, 0, tokyo),
+				Temperature:         25.5,
+				WindSpeed:           10.2,
+				ApparentTemperature: 27.1,
+				Humidity:            78,
+				Precipitation:       0,
+				SurfacePressure:     1006.1,
+				SeaLevelPressure:    1008.4,
+				CloudCover:          40,
+				WindDirection:       180,
+				WindGusts:           18.7,
+				WeatherCode:         2,
+				Description:         "Partly cloudy",
+				Icon:                "partly-cloudy",
+				Units:               domain.UnitLabels{Temperature: "°C", WindSpeed: "km/h", Precipitation: "mm"},
+			},
+		},
+		{
+			name: "Current hour selected from UTC clock",
+			city: domain.City{
+				Name:      "Tokyo",
+				Latitude:  35.6895,
+				Longitude: 139.6917,
+			},
+			units: domain.Imperial,
+			// 2024-05-01T02:15+09:00
+			now: time.Date(2024, 4, 30, 17, 15, 0, 0, time.UTC),
+			expectedWeather: &domain.Weather{
+				City:                "Tokyo",
+				Timezone:            "Asia/Tokyo",
+				Elevation:           &elevation,
+				Time:                time.Date(2024, 5, 1, 2, 0, 0, 0, tokyo),
+				Temperature:         23.8,
+				WindSpeed:           8.4,
+				ApparentTemperature: 24.2,
+				Humidity:            83,
+				Precipitation:       1.4,
+				SurfacePressure:     1005.3,
+				SeaLevelPressure:    1007.6,
+				CloudCover:          100,
+				WindDirection:       200,
+				WindGusts:           15.1,
+				WeatherCode:         63,
+				Description:         "Moderate rain",
+				Icon:                "rain",
+				Units:               domain.UnitLabels{Temperature: "°F", WindSpeed: "mph", Precipitation: "in"},
+			},
+		},
+		{
+			name: "No reading for the current hour",
+			city: domain.City{
+				Name:      "Tokyo",
+				Latitude:  35.6895,
+				Longitude: 139.6917,
 			},
+			now:       time.Date(2024, 5, 2, 12, 0, 0, 0, tokyo),
+			expectErr: true,
 		},
 	}
 
 	// Run test cases
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
+			// Create a new OpenMeteo client with the test server URL and a fixed clock
+			client := NewOpenMeteo(server.URL, WithClock(func() time.Time { return tc.now }))
+
 			// Call the FetchWeatherByCity function
-			weather, err := client.FetchWeatherByCity(context.Background(), tc.city)
+			weather, err := client.FetchWeatherByCity(context.Background(), tc.city, tc.units)
 
 			// Check the result
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, but got nil")
+				}
+				return
+			}
 			if err != nil {
 				t.Errorf("Unexpected error: %v", err)
 			}
+			if got, expected := query.Get("latitude"), formatCoordinate(tc.city.Latitude); got != expected {
+				t.Errorf("Expected latitude %q, but got %q", expected, got)
+			}
+			if got, expected := query.Get("longitude"), formatCoordinate(tc.city.Longitude); got != expected {
+				t.Errorf("Expected longitude %q, but got %q", expected, got)
+			}
+			if got := query.Get("hourly"); got != currentVariables {
+				t.Errorf("Expected hourly %q, but got %q", currentVariables, got)
+			}
+			if got := query.Get("temperature_unit"); got != string(tc.units.Temperature) {
+				t.Errorf("Expected temperature_unit %q, but got %q", tc.units.Temperature, got)
+			}
+			if got := query.Get("wind_speed_unit"); got != string(tc.units.WindSpeed) {
+				t.Errorf("Expected wind_speed_unit %q, but got %q", tc.units.WindSpeed, got)
+			}
+			if got := query.Get("precipitation_unit"); got != string(tc.units.Precipitation) {
+				t.Errorf("Expected precipitation_unit %q, but got %q", tc.units.Precipitation, got)
+			}
 			if weather == nil {
 				t.Errorf("Expected weather to be fetched, but it was not")
 			} else {
+				if !weather.Time.Equal(tc.expectedWeather.Time) {
+					t.Errorf("Expected time %v, but got %v", tc.expectedWeather.Time, weather.Time)
+				}
+				weather.Time = tc.expectedWeather.Time
 				if !reflect.DeepEqual(weather, tc.expectedWeather) {
 					t.Errorf("Expected weather %v, but got %v", tc.expectedWeather, weather)
 				}
@@ -80,3 +212,411 @@ func TestFetchWeatherByCity(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchWeatherByCities(t *testing.T) {
+	// location returns a forecast response with a single current-weather reading
+	location := func(timezone string, offset int, hour string, temperature float64) string {
+		return fmt.Sprintf(`{"timezone": %q, "utc_offset_seconds": %d, "hourly": {
+			"time": [%q], "temperature_2m": [%v], "wind_speed_10m": [5], "apparent_temperature": [%v],
+			"relative_humidity_2m": [70], "precipitation": [0], "surface_pressure": [1009], "pressure_msl": [1012], "cloud_cover": [50],
+			"wind_direction_10m": [270], "wind_gusts_10m": [12], "weather_code": [3]}}`, timezone, offset, hour, temperature, temperature)
+	}
+	london := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	tokyo := domain.City{Name: "Tokyo", Latitude: 35.6895, Longitude: 139.6917}
+	londonWeather := location("Europe/London", 3600, "2024-05-01T13:00", 14.5)
+	tokyoWeather := location("Asia/Tokyo", 32400, "2024-05-01T21:00", 18.2)
+	// 2024-05-01T13:30+01:00, which is 21:30 in Tokyo
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name              string
+		cities            []domain.City
+		response          string
+		expectedLatitude  string
+		expectedLongitude string
+		expectedTemps     []float64
+		expectedErrs      []error
+	}{
+		{
+			name:              "one request for several cities",
+			cities:            []domain.City{london, tokyo},
+			response:          "[" + londonWeather + "," + tokyoWeather + "]",
+			expectedLatitude:  "51.5074,35.6895",
+			expectedLongitude: "-0.1278,139.6917",
+			expectedTemps:     []float64{14.5, 18.2},
+			expectedErrs:      []error{nil, nil},
+		},
+		{
+			name:              "a single city is answered with an object",
+			cities:            []domain.City{tokyo},
+			response:          tokyoWeather,
+			expectedLatitude:  "35.6895",
+			expectedLongitude: "139.6917",
+			expectedTemps:     []float64{18.2},
+			expectedErrs:      []error{nil},
+		},
+		{
+			name:              "a city without a current reading fails on its own",
+			cities:            []domain.City{london, tokyo},
+			response:          "[" + londonWeather + "," + location("Asia/Tokyo", 32400, "2024-05-01T09:00", 11) + "]",
+			expectedLatitude:  "51.5074,35.6895",
+			expectedLongitude: "-0.1278,139.6917",
+			expectedTemps:     []float64{14.5, 0},
+			expectedErrs:      []error{nil, domain.ErrUpstreamUnavailable},
+		},
+		{
+			name:              "missing locations fail the request",
+			cities:            []domain.City{london, tokyo},
+			response:          "[" + londonWeather + "]",
+			expectedLatitude:  "51.5074,35.6895",
+			expectedLongitude: "-0.1278,139.6917",
+			expectedTemps:     []float64{0, 0},
+			expectedErrs:      []error{domain.ErrUpstreamUnavailable, domain.ErrUpstreamUnavailable},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests []url.Values
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.URL.Query())
+				fmt.Fprint(w, tc.response)
+			}))
+			defer server.Close()
+
+			client := NewOpenMeteo(server.URL, WithClock(func() time.Time { return now }))
+			weathers, errs := client.FetchWeatherByCities(context.Background(), tc.cities, domain.Metric)
+			if len(requests) != 1 {
+				t.Fatalf("Expected a single request, but got %d", len(requests))
+			}
+			if got := requests[0].Get("latitude"); got != tc.expectedLatitude {
+				t.Errorf("Expected latitude %q, but got %q", tc.expectedLatitude, got)
+			}
+			if got := requests[0].Get("longitude"); got != tc.expectedLongitude {
+				t.Errorf("Expected longitude %q, but got %q", tc.expectedLongitude, got)
+			}
+			for i, city := range tc.cities {
+				if !errors.Is(errs[i], tc.expectedErrs[i]) || (errs[i] != nil) != (tc.expectedErrs[i] != nil) {
+					t.Errorf("%s: expected error %v, but got %v", city.Name, tc.expectedErrs[i], errs[i])
+					continue
+				}
+				if errs[i] != nil {
+					continue
+				}
+				if weathers[i].City != city.Name || weathers[i].Temperature != tc.expectedTemps[i] {
+					t.Errorf("%s: expected %v°C, but got %+v", city.Name, tc.expectedTemps[i], weathers[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFetchForecastByCity(t *testing.T) {
+	var forecastDays string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forecastDays = r.URL.Query().Get("forecast_days")
+		fmt.Fprint(w, `{
+			"timezone": "GMT",
+			"utc_offset_seconds": 0,
+			"hourly": {
+				"time": ["2024-05-01T00:00", "2024-05-01T01:00", "2024-05-01T02:00", "2024-05-01T03:00"],
+				"temperature_2m": [10, 11, 12, 13],
+				"wind_speed_10m": [1, 2, 3, 4]
+			}
+		}`)
+	}))
+	defer server.Close()
+
+	gmt := time.FixedZone("GMT", 0)
+	client := NewOpenMeteo(server.URL, WithClock(func() time.Time {
+		return time.Date(2024, 5, 1, 1, 45, 0, 0, time.UTC)
+	}))
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+
+	testCases := []struct {
+		name         string
+		hours        int
+		expectedDays string
+		expected     []domain.HourlyWeather
+		expectErr    bool
+	}{
+		{
+			name:         "Series starts at the current hour",
+			hours:        2,
+			expectedDays: "2",
+			expected: []domain.HourlyWeather{
+				{Time: time.Date(2024, 5, 1, 1, 0, 0, 0, gmt), Temperature: 11, WindSpeed: 2},
+				{Time: time.Date(2024, 5, 1, 2, 0, 0, 0, gmt), Temperature: 12, WindSpeed: 3},
+			},
+		},
+		{
+			name:      "Series shorter than the requested hours",
+			hours:     48,
+			expectErr: true,
+		},
+		{
+			name:      "Hours out of range",
+			hours:     domain.MaxForecastHours + 1,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forecast, err := client.FetchForecastByCity(context.Background(), city, tc.hours, domain.Metric)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if forecastDays != tc.expectedDays {
+				t.Errorf("Expected forecast_days %s, but got %s", tc.expectedDays, forecastDays)
+			}
+			if forecast.City != city.Name {
+				t.Errorf("Expected city %s, but got %s", city.Name, forecast.City)
+			}
+			if len(forecast.Hourly) != len(tc.expected) {
+				t.Fatalf("Expected %d hourly points, but got %d", len(tc.expected), len(forecast.Hourly))
+			}
+			for i, p := range forecast.Hourly {
+				if !p.Time.Equal(tc.expected[i].Time) || p.Temperature != tc.expected[i].Temperature || p.WindSpeed != tc.expected[i].WindSpeed {
+					t.Errorf("Expected point %d to be %v, but got %v", i, tc.expected[i], p)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchDailyForecastByCity(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		fmt.Fprint(w, `{
+			"timezone": "Europe/Paris",
+			"utc_offset_seconds": 7200,
+			"daily": {
+				"time": ["2024-05-01", "2024-05-02"],
+				"weather_code": [3, 61],
+				"temperature_2m_max": [21.4, 18.2],
+				"temperature_2m_min": [11.0, 10.3],
+				"precipitation_sum": [0, 4.6],
+				"precipitation_probability_max": [5, 80],
+				"sunrise": ["2024-05-01T06:31", "2024-05-02T06:29"],
+				"sunset": ["2024-05-01T21:04", "2024-05-02T21:05"]
+			}
+		}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenMeteo(server.URL)
+	city := domain.City{Name: "Paris", Latitude: 48.8566, Longitude: 2.3522}
+
+	forecast, err := client.FetchDailyForecastByCity(context.Background(), city, 2, domain.Imperial)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := query.Get("forecast_days"); got != "2" {
+		t.Errorf("Expected forecast_days 2, but got %s", got)
+	}
+	if got := query.Get("daily"); got != dailyVariables {
+		t.Errorf("Expected daily variables %s, but got %s", dailyVariables, got)
+	}
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []domain.DailyWeather{
+		{
+			Date:                        time.Date(2024, 5, 1, 0, 0, 0, 0, paris),
+			WeatherCode:                 3,
+			Description:                 "Overcast",
+			Icon:                        "overcast",
+			TemperatureMin:              11.0,
+			TemperatureMax:              21.4,
+			PrecipitationSum:            0,
+			PrecipitationProbabilityMax: 5,
+			Sunrise:                     time.Date(2024, 5, 1, 6, 31, 0, 0, paris),
+			Sunset:                      time.Date(2024, 5, 1, 21, 4, 0, 0, paris),
+		},
+		{
+			Date:                        time.Date(2024, 5, 2, 0, 0, 0, 0, paris),
+			WeatherCode:                 61,
+			Description:                 "Slight rain",
+			Icon:                        "rain",
+			TemperatureMin:              10.3,
+			TemperatureMax:              18.2,
+			PrecipitationSum:            4.6,
+			PrecipitationProbabilityMax: 80,
+			Sunrise:                     time.Date(2024, 5, 2, 6, 29, 0, 0, paris),
+			Sunset:                      time.Date(2024, 5, 2, 21, 5, 0, 0, paris),
+		},
+	}
+	if got := query.Get("temperature_unit"); got != "fahrenheit" {
+		t.Errorf("Expected temperature_unit fahrenheit, but got %s", got)
+	}
+	if forecast.City != "Paris" {
+		t.Errorf("Expected city Paris, but got %s", forecast.City)
+	}
+	if expectedUnits := domain.Imperial.Labels(); forecast.Units != expectedUnits {
+		t.Errorf("Expected units %v, but got %v", expectedUnits, forecast.Units)
+	}
+	if !reflect.DeepEqual(forecast.Daily, expected) {
+		t.Errorf("Expected daily forecast %v, but got %v", expected, forecast.Daily)
+	}
+
+	if _, err := client.FetchDailyForecastByCity(context.Background(), city, domain.MaxForecastDays+1, domain.Metric); err == nil {
+		t.Errorf("Expected error for out of range days, but got nil")
+	}
+}
+
+func TestFetchWeatherByCity_Cancellation(t *testing.T) {
+	// A slow upstream that only returns once the client gives up (or after a long delay)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := NewOpenMeteo(server.URL)
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+
+	testCases := []struct {
+		name        string
+		ctx         func() (context.Context, context.CancelFunc)
+		expectedErr error
+	}{
+		{
+			name: "Deadline exceeded",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			expectedErr: domain.ErrUpstreamTimeout,
+		},
+		{
+			name: "Cancelled by caller",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			expectedErr: context.Canceled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := tc.ctx()
+			defer cancel()
+
+			start := time.Now()
+			_, err := client.FetchWeatherByCity(ctx, city, domain.Metric)
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("Expected request to be aborted promptly, but it took %v", elapsed)
+			}
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Expected error %v, but got %v", tc.expectedErr, err)
+			}
+			if !errors.Is(err, ctx.Err()) {
+				t.Errorf("Expected error to wrap %v, but got %v", ctx.Err(), err)
+			}
+		})
+	}
+}
+
+func TestFetchWeatherByCity_UpstreamFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	// Close the server so the connection is refused
+	server.Close()
+
+	client := NewOpenMeteo(server.URL)
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+
+	_, err := client.FetchWeatherByCity(context.Background(), city, domain.Metric)
+	if err == nil {
+		t.Fatalf("Expected error, but got nil")
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected upstream failure, but got context error %v", err)
+	}
+	if !errors.Is(err, domain.ErrUpstreamUnavailable) {
+		t.Errorf("Expected error to be domain.ErrUpstreamUnavailable, but got %v", err)
+	}
+}
+
+func TestFetchWeatherByCity_StatusErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		status        int
+		body          string
+		expectedErr   error
+		expectedMatch string
+	}{
+		{name: "Rate limited", status: http.StatusTooManyRequests, expectedErr: domain.ErrRateLimited},
+		{name: "Server error", status: http.StatusServiceUnavailable, expectedErr: domain.ErrUpstreamUnavailable},
+		{
+			name:          "Bad request with reason",
+			status:        http.StatusBadRequest,
+			body:          `{"error": true, "reason": "Latitude must be in range of -90 to 90°. Given: 123.456."}`,
+			expectedErr:   domain.ErrInvalidInput,
+			expectedMatch: "invalid input: Latitude must be in range of -90 to 90°. Given: 123.456.",
+		},
+	}
+
+	city := domain.City{Name: "TestCity", Latitude: 123.456, Longitude: 789.012}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			}))
+			defer server.Close()
+
+			_, err := NewOpenMeteo(server.URL).FetchWeatherByCity(context.Background(), city, domain.Metric)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Expected error %v, but got %v", tc.expectedErr, err)
+			}
+			if tc.expectedMatch != "" && err.Error() != tc.expectedMatch {
+				t.Errorf("Expected error message %q, but got %q", tc.expectedMatch, err.Error())
+			}
+		})
+	}
+}
+
+func TestWeatherReponse_Location(t *testing.T) {
+	testCases := []struct {
+		name     string
+		response WeatherReponse
+		expected time.Time
+	}{
+		{
+			// the offset is the one at the start of the forecast, before summer time begins
+			name:     "IANA time zone",
+			response: WeatherReponse{Timezone: "Europe/London", UtcOffsetSeconds: 0},
+			expected: time.Date(2024, 3, 31, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "unknown time zone",
+			response: WeatherReponse{Timezone: "Atlantis/Capital", UtcOffsetSeconds: 0},
+			expected: time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := time.ParseInLocation(hourlyTimeLayout, "2024-03-31T12:00", tc.response.location())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !got.Equal(tc.expected) {
+				t.Errorf("Expected %v, but got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/softstone1/woc/domain"
+)
+
+const (
+	// client request timeout
+	timeout               = 30 * time.Second
+	maxIdleConns          = 5
+	maxIdleConnsPerHost   = 3
+	dialTimeout           = time.Second
+	keepAlive             = time.Minute
+	responseHeaderTimeout = time.Second
+	tlsHandshakeTimeout   = 2 * time.Second
+
+	// maxConcurrentProviderRequests bounds the concurrent requests a batch is split into when a
+	// provider cannot fetch several cities at once
+	maxConcurrentProviderRequests = 4
+
+	// userAgent identifies the application to weather providers, some of which reject anonymous clients.
+	userAgent = "woc/1.0 (+https://github.com/softstone1/woc)"
+)
+
+// httpProvider is the HTTP plumbing shared by the weather provider clients.
+type httpProvider struct {
+	// name identifies the provider in error messages
+	name    string
+	baseUrl string
+	client  *http.Client
+	now     func() time.Time
+	retry   RetryPolicy
+}
+
+// Option configures a weather provider client.
+type Option func(*httpProvider)
+
+// WithClock overrides the clock used to select the reading for the current hour.
+func WithClock(now func() time.Time) Option {
+	return func(p *httpProvider) {
+		p.now = now
+	}
+}
+
+func newHTTPProvider(name, url string, opts []Option) httpProvider {
+	p := httpProvider{
+		name:    name,
+		baseUrl: url,
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				MaxIdleConns:        maxIdleConns,
+				MaxIdleConnsPerHost: maxIdleConnsPerHost,
+				DialContext: (&net.Dialer{
+					Timeout:   dialTimeout,
+					KeepAlive: keepAlive,
+				}).DialContext,
+				ResponseHeaderTimeout: responseHeaderTimeout,
+				TLSHandshakeTimeout:   tlsHandshakeTimeout,
+			},
+		},
+		now:   time.Now,
+		retry: RetryPolicy{MaxAttempts: 1},
+	}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+// getJSON requests url, retrying transient failures, and decodes the JSON response into v.
+func (p *httpProvider) getJSON(ctx context.Context, url string, v any) error {
+	return p.retry.do(ctx, func() error {
+		return p.get(ctx, url, v)
+	})
+}
+
+// get performs a single request.
+func (p *httpProvider) get(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return p.requestError(ctx, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return p.statusError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return p.requestError(ctx, err)
+	}
+	return nil
+}
+
+// errorResponse is the body Open-Meteo returns alongside a 400 status.
+type errorResponse struct {
+	Reason string `json:"reason"`
+}
+
+// statusError classifies a non-200 response into a domain error.
+// Rate limiting and server errors are marked as retryable.
+func (p *httpProvider) statusError(resp *http.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &retryableError{
+			err:        fmt.Errorf("%w: %s returned status %d", domain.ErrRateLimited, p.name, resp.StatusCode),
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), p.now()),
+		}
+	case resp.StatusCode == http.StatusBadRequest:
+		var body errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Reason == "" {
+			return fmt.Errorf("%w: %s returned status %d", domain.ErrInvalidInput, p.name, resp.StatusCode)
+		}
+		return fmt.Errorf("%w: %s", domain.ErrInvalidInput, body.Reason)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return &retryableError{
+			err:        fmt.Errorf("%w: %s returned status %d", domain.ErrUpstreamUnavailable, p.name, resp.StatusCode),
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), p.now()),
+		}
+	default:
+		return fmt.Errorf("%w: %s returned status %d", domain.ErrUpstreamUnavailable, p.name, resp.StatusCode)
+	}
+}
+
+// requestError tells a cancelled or expired caller context apart from an upstream failure.
+// Context errors wrap ctx.Err() so callers can match them with errors.Is.
+func (p *httpProvider) requestError(ctx context.Context, err error) error {
+	switch ctxErr := ctx.Err(); {
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %s request aborted: %w", domain.ErrUpstreamTimeout, p.name, ctxErr)
+	case ctxErr != nil:
+		return fmt.Errorf("%s request aborted: %w", p.name, ctxErr)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return &retryableError{err: fmt.Errorf("%w: %s request failed: %w", domain.ErrUpstreamTimeout, p.name, err)}
+	}
+	return fmt.Errorf("%w: %s request failed: %w", domain.ErrUpstreamUnavailable, p.name, err)
+}
+
+// failAll returns the result of a batch of n cities that failed as a whole with err.
+func failAll(n int, err error) ([]*domain.Weather, []error) {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return make([]*domain.Weather, n), errs
+}
+
+// batchError returns the error of a batch that failed for every city, nil if any city succeeded.
+func batchError(errs []error) error {
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs[0]
+}
+
+// formatCoordinate formats degrees for a query parameter, without trailing zeros.
+func formatCoordinate(degrees float64) string {
+	return strconv.FormatFloat(degrees, 'f', -1, 64)
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/softstone1/woc/domain"
+)
+
+// Names of the built-in weather providers.
+const (
+	ProviderOpenMeteo = "open-meteo"
+	ProviderMetNorway = "met-norway"
+)
+
+// ProviderFactory creates a weather provider client talking to baseURL.
+type ProviderFactory func(baseURL string, opts ...Option) domain.WeatherClient
+
+// Registry maps weather provider names to the factories creating them.
+type Registry struct {
+	factories map[string]ProviderFactory
+}
+
+// NewRegistry creates a registry with the built-in providers registered.
+func NewRegistry() *Registry {
+	r := &Registry{
+		factories: make(map[string]ProviderFactory),
+	}
+	r.Register(ProviderOpenMeteo, func(baseURL string, opts ...Option) domain.WeatherClient {
+		return NewOpenMeteo(baseURL, opts...)
+	})
+	r.Register(ProviderMetNorway, func(baseURL string, opts ...Option) domain.WeatherClient {
+		return NewMetNorway(baseURL, opts...)
+	})
+	return r
+}
+
+// Register adds a provider, replacing any provider registered under the same name.
+func (r *Registry) Register(name string, factory ProviderFactory) {
+	r.factories[name] = factory
+}
+
+// Names returns the registered provider names in alphabetical order.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// New creates the provider registered under name.
+func (r *Registry) New(name, baseURL string, opts ...Option) (domain.WeatherClient, error) {
+	factory, ok := r.factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown weather provider %q, expected one of %s", name, strings.Join(r.Names(), ", "))
+	}
+	return factory(baseURL, opts...), nil
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how transient upstream failures (5xx, 429 and timeouts) are retried.
+// Waits grow exponentially from BaseBackoff up to MaxBackoff with random jitter, a Retry-After
+// header takes precedence, and no retry is attempted if it cannot finish before the caller's deadline.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// WithRetry sets the retry policy for transient upstream failures.
+func WithRetry(policy RetryPolicy) Option {
+	return func(p *httpProvider) {
+		p.retry = policy
+	}
+}
+
+// retryableError marks a transient failure worth another attempt.
+type retryableError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+
+func (e *retryableError) Unwrap() error { return e.err }
+
+// do calls attempt until it succeeds, fails permanently or the policy is exhausted.
+func (p RetryPolicy) do(ctx context.Context, attempt func() error) error {
+	for n := 1; ; n++ {
+		err := attempt()
+		var retryable *retryableError
+		if err == nil || !errors.As(err, &retryable) || n >= p.MaxAttempts {
+			return err
+		}
+		wait := max(p.backoff(n), retryable.retryAfter)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return contextError(ctx)
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the jittered wait before retrying after the given attempt:
+// half of the exponential delay plus a random share of the other half.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseBackoff << (attempt - 1)
+	if d <= 0 || d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/softstone1/woc/domain"
+)
+
+const dailyResponse = `{
+	"timezone": "GMT",
+	"utc_offset_seconds": 0,
+	"daily": {
+		"time": ["2024-05-01"],
+		"weather_code": [0],
+		"temperature_2m_max": [20],
+		"temperature_2m_min": [10],
+		"precipitation_sum": [0],
+		"precipitation_probability_max": [0],
+		"sunrise": ["2024-05-01T05:30"],
+		"sunset": ["2024-05-01T20:30"]
+	}
+}`
+
+func TestFetchWithRetry(t *testing.T) {
+	testCases := []struct {
+		name             string
+		failures         int
+		status           int
+		retryAfter       string
+		maxAttempts      int
+		timeout          time.Duration
+		expectedAttempts int32
+		expectedErr      error
+	}{
+		{name: "Succeeds after transient server errors", failures: 2, status: http.StatusServiceUnavailable, maxAttempts: 3, expectedAttempts: 3},
+		{name: "Succeeds after rate limiting", failures: 1, status: http.StatusTooManyRequests, maxAttempts: 3, expectedAttempts: 2},
+		{name: "Gives up after max attempts", failures: 5, status: http.StatusBadGateway, maxAttempts: 3, expectedAttempts: 3, expectedErr: domain.ErrUpstreamUnavailable},
+		{name: "Client errors are not retried", failures: 5, status: http.StatusBadRequest, maxAttempts: 3, expectedAttempts: 1, expectedErr: domain.ErrInvalidInput},
+		{name: "Retries disabled", failures: 1, status: http.StatusInternalServerError, maxAttempts: 1, expectedAttempts: 1, expectedErr: domain.ErrUpstreamUnavailable},
+		{
+			name:             "Retry-After beyond the caller deadline is not awaited",
+			failures:         1,
+			status:           http.StatusTooManyRequests,
+			retryAfter:       "30",
+			maxAttempts:      3,
+			timeout:          time.Second,
+			expectedAttempts: 1,
+			expectedErr:      domain.ErrRateLimited,
+		},
+	}
+
+	city := domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) <= int32(tc.failures) {
+					if tc.retryAfter != "" {
+						w.Header().Set("Retry-After", tc.retryAfter)
+					}
+					w.WriteHeader(tc.status)
+					return
+				}
+				fmt.Fprint(w, dailyResponse)
+			}))
+			defer server.Close()
+
+			client := NewOpenMeteo(server.URL, WithRetry(RetryPolicy{
+				MaxAttempts: tc.maxAttempts,
+				BaseBackoff: time.Millisecond,
+				MaxBackoff:  10 * time.Millisecond,
+			}))
+			ctx := context.Background()
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			_, err := client.FetchDailyForecastByCity(ctx, city, 1, domain.Metric)
+			if tc.expectedErr == nil && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tc.expectedErr != nil && !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Expected error %v, but got %v", tc.expectedErr, err)
+			}
+			if got := attempts.Load(); got != tc.expectedAttempts {
+				t.Errorf("Expected %d attempts, but got %d", tc.expectedAttempts, got)
+			}
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("Expected retries to finish quickly, but took %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	testCases := []struct {
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{attempt: 1, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{attempt: 2, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{attempt: 3, min: 150 * time.Millisecond, max: 300 * time.Millisecond},
+		{attempt: 40, min: 150 * time.Millisecond, max: 300 * time.Millisecond},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("attempt %d", tc.attempt), func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if d := policy.backoff(tc.attempt); d < tc.min || d > tc.max {
+					t.Fatalf("Expected backoff between %v and %v, but got %v", tc.min, tc.max, d)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		header   string
+		expected time.Duration
+	}{
+		{name: "Missing", header: "", expected: 0},
+		{name: "Seconds", header: "3", expected: 3 * time.Second},
+		{name: "HTTP date", header: "Wed, 01 May 2024 12:00:05 GMT", expected: 5 * time.Second},
+		{name: "Date in the past", header: "Wed, 01 May 2024 11:00:00 GMT", expected: 0},
+		{name: "Garbage", header: "soon", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseRetryAfter(tc.header, now); got != tc.expected {
+				t.Errorf("Expected %v, but got %v", tc.expected, got)
+			}
+		})
+	}
+}
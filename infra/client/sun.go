@@ -0,0 +1,45 @@
+package client
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// julianUnixEpoch is the Julian date of 1970-01-01T00:00:00Z.
+	julianUnixEpoch = 2440587.5
+	// julianJ2000 is the Julian date of 2000-01-01T12:00:00Z.
+	julianJ2000 = 2451545.0
+)
+
+// sunTimes returns sunrise and sunset for the date of day in its location at the given position,
+// using the sunrise equation, expressed in that location. Both are zero during polar day and polar
+// night.
+func sunTimes(day time.Time, lat, lon float64) (sunrise, sunset time.Time) {
+	const rad = math.Pi / 180
+	y, m, d := day.Date()
+	noon := time.Date(y, m, d, 12, 0, 0, 0, time.UTC)
+	n := math.Round(toJulian(noon) - julianJ2000)
+	// mean solar time at the longitude
+	j := n - lon/360
+	anomaly := math.Mod(357.5291+0.98560028*j, 360)
+	center := 1.9148*math.Sin(anomaly*rad) + 0.02*math.Sin(2*anomaly*rad) + 0.0003*math.Sin(3*anomaly*rad)
+	longitude := math.Mod(anomaly+center+180+102.9372, 360)
+	transit := julianJ2000 + j + 0.0053*math.Sin(anomaly*rad) - 0.0069*math.Sin(2*longitude*rad)
+	declination := math.Asin(math.Sin(longitude*rad) * math.Sin(23.4397*rad))
+	// -0.833° accounts for refraction and the size of the solar disc
+	cosHourAngle := (math.Sin(-0.833*rad) - math.Sin(lat*rad)*math.Sin(declination)) / (math.Cos(lat*rad) * math.Cos(declination))
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		return time.Time{}, time.Time{}
+	}
+	hourAngle := math.Acos(cosHourAngle) / rad
+	return fromJulian(transit - hourAngle/360).In(day.Location()), fromJulian(transit + hourAngle/360).In(day.Location())
+}
+
+func toJulian(t time.Time) float64 {
+	return float64(t.Unix())/86400 + julianUnixEpoch
+}
+
+func fromJulian(j float64) time.Time {
+	return time.Unix(0, 0).Add(time.Duration((j - julianUnixEpoch) * 86400 * float64(time.Second))).UTC().Truncate(time.Second)
+}
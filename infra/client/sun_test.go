@@ -0,0 +1,70 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSunTimes(t *testing.T) {
+	testCases := []struct {
+		name            string
+		day             time.Time
+		lat, lon        float64
+		expectedSunrise time.Time
+		expectedSunset  time.Time
+	}{
+		{
+			name:            "London",
+			day:             time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			lat:             51.5074,
+			lon:             -0.1278,
+			expectedSunrise: time.Date(2024, 5, 1, 4, 33, 0, 0, time.UTC),
+			expectedSunset:  time.Date(2024, 5, 1, 19, 25, 0, 0, time.UTC),
+		},
+		{
+			name:            "Tokyo",
+			day:             time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			lat:             35.6895,
+			lon:             139.6917,
+			expectedSunrise: time.Date(2024, 4, 30, 19, 49, 0, 0, time.UTC),
+			expectedSunset:  time.Date(2024, 5, 1, 9, 29, 0, 0, time.UTC),
+		},
+		{
+			name:            "Tokyo local date",
+			day:             time.Date(2024, 5, 1, 0, 0, 0, 0, time.FixedZone("JST", 9*60*60)),
+			lat:             35.6895,
+			lon:             139.6917,
+			expectedSunrise: time.Date(2024, 5, 1, 4, 49, 0, 0, time.FixedZone("JST", 9*60*60)),
+			expectedSunset:  time.Date(2024, 5, 1, 18, 29, 0, 0, time.FixedZone("JST", 9*60*60)),
+		},
+		{
+			name: "Polar day",
+			day:  time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC),
+			lat:  78.2232,
+			lon:  15.6267,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sunrise, sunset := sunTimes(tc.day, tc.lat, tc.lon)
+			if !closeTo(sunrise, tc.expectedSunrise) {
+				t.Errorf("Expected sunrise %v, but got %v", tc.expectedSunrise, sunrise)
+			}
+			if !closeTo(sunset, tc.expectedSunset) {
+				t.Errorf("Expected sunset %v, but got %v", tc.expectedSunset, sunset)
+			}
+			if sunrise.Location() != tc.day.Location() {
+				t.Errorf("Expected sunrise in %v, but got %v", tc.day.Location(), sunrise.Location())
+			}
+		})
+	}
+}
+
+// closeTo reports whether got is within a few minutes of expected, or both are zero.
+func closeTo(got, expected time.Time) bool {
+	if expected.IsZero() {
+		return got.IsZero()
+	}
+	return got.Sub(expected).Abs() <= 3*time.Minute
+}
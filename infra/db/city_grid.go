@@ -0,0 +1,71 @@
+package db
+
+import (
+	"math"
+
+	"github.com/softstone1/woc/domain"
+)
+
+// gridCellDegrees is the size of a city grid cell, about 111 km north to south.
+const gridCellDegrees = 1.0
+
+// gridCell identifies a cell of the city grid by its south-west corner in whole cells.
+type gridCell struct {
+	latitude, longitude int
+}
+
+func cellOf(latitude, longitude float64) gridCell {
+	return gridCell{
+		latitude:  int(math.Floor(latitude / gridCellDegrees)),
+		longitude: int(math.Floor(longitude / gridCellDegrees)),
+	}
+}
+
+// cityGrid is a spatial index bucketing city IDs by the grid cell their coordinates fall in.
+type cityGrid map[gridCell]map[string]struct{}
+
+func (g cityGrid) add(city domain.City) {
+	cell := cellOf(city.Latitude, city.Longitude)
+	if g[cell] == nil {
+		g[cell] = map[string]struct{}{}
+	}
+	g[cell][city.ID] = struct{}{}
+}
+
+func (g cityGrid) remove(city domain.City) {
+	cell := cellOf(city.Latitude, city.Longitude)
+	delete(g[cell], city.ID)
+	if len(g[cell]) == 0 {
+		delete(g, cell)
+	}
+}
+
+// candidates returns the IDs of the cities in the cells overlapping the box. Cities outside the
+// box may be included.
+func (g cityGrid) candidates(box domain.BoundingBox) []string {
+	var cells []gridCell
+	south, north := cellOf(box.MinLatitude, 0).latitude, cellOf(box.MaxLatitude, 0).latitude
+	for _, r := range box.LongitudeRanges() {
+		west, east := cellOf(0, r[0]).longitude, cellOf(0, r[1]).longitude
+		if len(cells)+(north-south+1)*(east-west+1) > len(g) {
+			// visiting every occupied cell is cheaper than the cells of a large box
+			cells = nil
+			for cell := range g {
+				cells = append(cells, cell)
+			}
+			break
+		}
+		for latitude := south; latitude <= north; latitude++ {
+			for longitude := west; longitude <= east; longitude++ {
+				cells = append(cells, gridCell{latitude, longitude})
+			}
+		}
+	}
+	var names []string
+	for _, cell := range cells {
+		for name := range g[cell] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
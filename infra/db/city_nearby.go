@@ -0,0 +1,22 @@
+package db
+
+import "github.com/softstone1/woc/domain"
+
+// nearestSearchRadiusKm is the radius the nearest city search starts with. It is doubled until
+// enough cities are found.
+const nearestSearchRadiusKm = 100.0
+
+// nearest returns the k cities closest to a point, searching with within, which returns the
+// cities within a radius of the point nearest first, in ever larger radii.
+func nearest(k int, within func(km float64) ([]domain.NearbyCity, error)) ([]domain.NearbyCity, error) {
+	for km := nearestSearchRadiusKm; ; km *= 2 {
+		km = min(km, domain.MaxDistanceKm)
+		cities, err := within(km)
+		if err != nil {
+			return nil, err
+		}
+		if len(cities) >= k || km == domain.MaxDistanceKm {
+			return cities[:min(k, len(cities))], nil
+		}
+	}
+}
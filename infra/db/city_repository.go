@@ -1,40 +1,227 @@
 package db
 
 import (
-    "errors"
-    "github.com/softstone1/woc/domain"
+	"slices"
+	"sync"
+
+	"github.com/softstone1/woc/domain"
 )
 
 // InMemoryCityRepository is an in-memory implementation of CityRepository.
 type InMemoryCityRepository struct {
-    cities map[string]domain.City
+	mu sync.RWMutex
+	// cities maps the ID of every city to the city
+	cities map[string]domain.City
+	// ids maps the lookup keys of every city to the IDs of the cities found by them
+	ids map[string][]string
+	// grid indexes the city IDs by location
+	grid cityGrid
 }
 
 // NewInMemoryCityRepository creates a new instance of InMemoryCityRepository with preloaded data.
 func NewInMemoryCityRepository() *InMemoryCityRepository {
-    return &InMemoryCityRepository{
-        cities: map[string]domain.City{
-            "Tokyo":    {Name: "Tokyo", Latitude: "35.6895", Longitude: "139.6917"},
-            "New York": {Name: "New York", Latitude: "40.7128", Longitude: "-74.0060"},
-            "London":   {Name: "London", Latitude: "51.5074", Longitude: "-0.1278"},
-            "Paris":    {Name: "Paris", Latitude: "48.8566", Longitude: "2.3522"},
-        },
-    }
+	repo := &InMemoryCityRepository{
+		cities: map[string]domain.City{},
+		ids:    map[string][]string{},
+		grid:   cityGrid{},
+	}
+	for _, city := range []domain.City{
+		{ID: "tokyo-jp", Name: "Tokyo", CountryCode: "JP", Admin1: "Tokyo", Latitude: 35.6895, Longitude: 139.6917,
+			Elevation: metres(44), Timezone: "Asia/Tokyo", Population: 8336599, Aliases: []string{"東京"}},
+		{ID: "new-york-us", Name: "New York", CountryCode: "US", Admin1: "New York", Latitude: 40.7128, Longitude: -74.0060,
+			Elevation: metres(10), Timezone: "America/New_York", Population: 8804190, Aliases: []string{"NYC", "New York City"}},
+		{ID: "london-england-gb", Name: "London", CountryCode: "GB", Admin1: "England", Latitude: 51.5074, Longitude: -0.1278,
+			Elevation: metres(25), Timezone: "Europe/London", Population: 8961989},
+		{ID: "paris-ile-de-france-fr", Name: "Paris", CountryCode: "FR", Admin1: "Île-de-France", Latitude: 48.8566, Longitude: 2.3522,
+			Elevation: metres(42), Timezone: "Europe/Paris", Population: 2138551},
+	} {
+		repo.add(city)
+	}
+	return repo
 }
 
-// GetCity retrieves city information by name.
+// GetCity retrieves city information by ID, name or alias.
 func (repo *InMemoryCityRepository) GetCity(name string) (*domain.City, error) {
-    if city, ok := repo.cities[name]; ok {
-        return &city, nil
-    }
-    return nil, errors.New("city not found")
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	if city, ok := repo.lookup(name); ok {
+		return &city, nil
+	}
+	return nil, domain.ErrCityNotFound
+}
+
+// FindCities returns the cities with the name or alias, most populous first.
+func (repo *InMemoryCityRepository) FindCities(name string) ([]domain.City, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return repo.find(name), nil
 }
 
 // Returns all cities in the repository.
 func (repo *InMemoryCityRepository) GetAllCities() ([]domain.City, error) {
-    allCities := make([]domain.City, 0, len(repo.cities))
-    for _, city := range repo.cities {
-        allCities = append(allCities, city)
-    }
-    return allCities, nil
-}
\ No newline at end of file
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	allCities := make([]domain.City, 0, len(repo.cities))
+	for _, city := range repo.cities {
+		allCities = append(allCities, clone(city))
+	}
+	return allCities, nil
+}
+
+// CreateCity adds a city unless its ID is taken.
+func (repo *InMemoryCityRepository) CreateCity(city domain.City) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.cities[city.ID]; ok {
+		return domain.ErrCityExists
+	}
+	repo.add(city)
+	return nil
+}
+
+// CreateCities adds the cities whose ID is not taken.
+func (repo *InMemoryCityRepository) CreateCities(cities []domain.City) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	created := 0
+	for _, city := range cities {
+		if _, ok := repo.cities[city.ID]; ok {
+			continue
+		}
+		repo.add(city)
+		created++
+	}
+	return created, nil
+}
+
+// UpdateCity replaces the city found by ID, name or alias, which may rename it or change its ID.
+func (repo *InMemoryCityRepository) UpdateCity(name string, city domain.City) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	current, ok := repo.lookup(name)
+	if !ok {
+		return domain.ErrCityNotFound
+	}
+	if _, ok := repo.cities[city.ID]; ok && city.ID != current.ID {
+		return domain.ErrCityExists
+	}
+	repo.remove(current)
+	repo.add(city)
+	return nil
+}
+
+// DeleteCity removes the city found by ID, name or alias.
+func (repo *InMemoryCityRepository) DeleteCity(name string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	city, ok := repo.lookup(name)
+	if !ok {
+		return domain.ErrCityNotFound
+	}
+	repo.remove(city)
+	return nil
+}
+
+// SearchCities returns up to limit cities whose name or an alias matches query, best match first.
+func (repo *InMemoryCityRepository) SearchCities(query string, limit int) ([]domain.City, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	matches := domain.NewCityMatches[string](query)
+	for key, ids := range repo.ids {
+		for _, id := range ids {
+			matches.Add(id, key)
+		}
+	}
+	ids := matches.Top(limit)
+	cities := make([]domain.City, 0, len(ids))
+	for _, id := range ids {
+		cities = append(cities, clone(repo.cities[id]))
+	}
+	return cities, nil
+}
+
+// Nearest returns the k cities closest to the point, nearest first.
+func (repo *InMemoryCityRepository) Nearest(latitude, longitude float64, k int) ([]domain.NearbyCity, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return nearest(k, func(km float64) ([]domain.NearbyCity, error) {
+		return repo.withinRadius(latitude, longitude, km), nil
+	})
+}
+
+// WithinRadius returns the cities at most km kilometres from the point, nearest first.
+func (repo *InMemoryCityRepository) WithinRadius(latitude, longitude, km float64) ([]domain.NearbyCity, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return repo.withinRadius(latitude, longitude, km), nil
+}
+
+func (repo *InMemoryCityRepository) withinRadius(latitude, longitude, km float64) []domain.NearbyCity {
+	nearby := []domain.NearbyCity{}
+	for _, id := range repo.grid.candidates(domain.NewBoundingBox(latitude, longitude, km)) {
+		city := repo.cities[id]
+		if distance := domain.DistanceKm(latitude, longitude, city.Latitude, city.Longitude); distance <= km {
+			nearby = append(nearby, domain.NearbyCity{City: clone(city), DistanceKm: distance})
+		}
+	}
+	domain.SortByDistance(nearby)
+	return nearby
+}
+
+// lookup returns a copy of the city with the ID, or else of the most populous city found by name or alias.
+func (repo *InMemoryCityRepository) lookup(name string) (domain.City, bool) {
+	if city, ok := repo.cities[name]; ok {
+		return clone(city), true
+	}
+	if cities := repo.find(name); len(cities) > 0 {
+		return cities[0], true
+	}
+	return domain.City{}, false
+}
+
+// find returns copies of the cities found by name or alias, most populous first.
+func (repo *InMemoryCityRepository) find(name string) []domain.City {
+	ids := repo.ids[domain.NormalizeCityName(name)]
+	cities := make([]domain.City, 0, len(ids))
+	for _, id := range ids {
+		cities = append(cities, clone(repo.cities[id]))
+	}
+	domain.SortByPopulation(cities)
+	return cities
+}
+
+func (repo *InMemoryCityRepository) add(city domain.City) {
+	repo.cities[city.ID] = clone(city)
+	repo.grid.add(city)
+	for _, key := range city.LookupKeys() {
+		repo.ids[key] = append(repo.ids[key], city.ID)
+	}
+}
+
+func (repo *InMemoryCityRepository) remove(city domain.City) {
+	delete(repo.cities, city.ID)
+	repo.grid.remove(city)
+	for _, key := range city.LookupKeys() {
+		ids := slices.DeleteFunc(repo.ids[key], func(id string) bool { return id == city.ID })
+		if len(ids) == 0 {
+			delete(repo.ids, key)
+		} else {
+			repo.ids[key] = ids
+		}
+	}
+}
+
+// clone returns a copy of the city that shares no memory with it.
+func clone(city domain.City) domain.City {
+	city.Aliases = slices.Clone(city.Aliases)
+	if city.Elevation != nil {
+		elevation := *city.Elevation
+		city.Elevation = &elevation
+	}
+	return city
+}
+
+// metres returns a pointer to an elevation.
+func metres(elevation float64) *float64 {
+	return &elevation
+}
@@ -1,37 +1,387 @@
 package db
 
 import (
+	"errors"
+	"math"
+	"reflect"
 	"testing"
 
 	"github.com/softstone1/woc/domain"
 )
 
-func TestGetCity(t *testing.T) {
-	repo := NewInMemoryCityRepository()
-
-	// Test case 1: City found
-	cityName := "New York"
-	expectedCity := &domain.City{
-		Name:      "New York",
-		Latitude:  "40.7128",
-		Longitude: "-74.0060",
-	}
-	city, err := repo.GetCity(cityName)
-	if err != nil {
-		t.Errorf("Unexpected error: %v", err)
-	}
-	if city == nil {
-		t.Errorf("Expected city to be found, but it was not")
-	} else if *city != *expectedCity {
-		t.Errorf("Expected city %v, but got %v", expectedCity, city)
-	}
-
-	// Test case 2: City not found
-	unknownCityName := "Unknown"
-	_, err = repo.GetCity(unknownCityName)
-	if err == nil {
-		t.Errorf("Expected error, but got nil")
-	} else if err.Error() != "city not found" {
-		t.Errorf("Expected error message 'city not found', but got '%v'", err.Error())
-	}
+// testCityRepository is the contract every domain.CityRepository implementation must satisfy.
+// newRepo returns a repository holding the seed cities.
+func testCityRepository(t *testing.T, newRepo func(t *testing.T) domain.CityRepository) {
+	t.Run("GetCity", func(t *testing.T) {
+		repo := newRepo(t)
+
+		// Test case 1: City found
+		cityName := "New York"
+		expectedCity := &domain.City{
+			ID:          "new-york-us",
+			Name:        "New York",
+			CountryCode: "US",
+			Admin1:      "New York",
+			Latitude:    40.7128,
+			Longitude:   -74.0060,
+			Elevation:   metres(10),
+			Timezone:    "America/New_York",
+			Population:  8804190,
+			Aliases:     []string{"NYC", "New York City"},
+		}
+		city, err := repo.GetCity(cityName)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if city == nil {
+			t.Errorf("Expected city to be found, but it was not")
+		} else if !reflect.DeepEqual(city, expectedCity) {
+			t.Errorf("Expected city %v, but got %v", expectedCity, city)
+		}
+
+		// Test case 2: City found by its ID
+		city, err = repo.GetCity("new-york-us")
+		if err != nil || !reflect.DeepEqual(city, expectedCity) {
+			t.Errorf("Expected city %v by its ID, but got %v, %v", expectedCity, city, err)
+		}
+
+		// Test case 3: City not found
+		unknownCityName := "Unknown"
+		_, err = repo.GetCity(unknownCityName)
+		if err == nil {
+			t.Errorf("Expected error, but got nil")
+		} else if err.Error() != "city not found" {
+			t.Errorf("Expected error message 'city not found', but got '%v'", err.Error())
+		} else if !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("Expected error to be domain.ErrNotFound, but got '%v'", err)
+		}
+	})
+
+	t.Run("GetCityByNormalizedNameOrAlias", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.CreateCity(domain.City{ID: "zurich-ch", Name: "Zürich", Latitude: 47.3769, Longitude: 8.5417, Aliases: []string{"Zurigo"}}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		testCases := []struct {
+			lookup   string
+			expected string
+		}{
+			{lookup: "tokyo", expected: "Tokyo"},
+			{lookup: "  new   york ", expected: "New York"},
+			{lookup: "NYC", expected: "New York"},
+			{lookup: "new york city", expected: "New York"},
+			{lookup: "東京", expected: "Tokyo"},
+			{lookup: "Zurich", expected: "Zürich"},
+			{lookup: "ZÜRICH", expected: "Zürich"},
+			{lookup: "zurigo", expected: "Zürich"},
+		}
+		for _, tc := range testCases {
+			city, err := repo.GetCity(tc.lookup)
+			if err != nil {
+				t.Errorf("Looking up %q: unexpected error: %v", tc.lookup, err)
+			} else if city.Name != tc.expected {
+				t.Errorf("Looking up %q: expected %q, but got %q", tc.lookup, tc.expected, city.Name)
+			}
+		}
+	})
+
+	t.Run("SearchCities", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.CreateCity(domain.City{ID: "new-london-us", Name: "New London", Latitude: 41.3557, Longitude: -72.0995}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		testCases := []struct {
+			query    string
+			limit    int
+			expected []string
+		}{
+			{query: "lon", limit: 10, expected: []string{"London", "New London"}},
+			{query: "lon", limit: 1, expected: []string{"London"}},
+			{query: "NEW", limit: 10, expected: []string{"New York", "New London"}},
+			{query: "nyc", limit: 10, expected: []string{"New York"}},
+			{query: "Tokio", limit: 10, expected: []string{"Tokyo"}},
+			{query: "xyz", limit: 10, expected: []string{}},
+		}
+		for _, tc := range testCases {
+			cities, err := repo.SearchCities(tc.query, tc.limit)
+			if err != nil {
+				t.Errorf("Searching %q: unexpected error: %v", tc.query, err)
+				continue
+			}
+			names := make([]string, 0, len(cities))
+			for _, city := range cities {
+				names = append(names, city.Name)
+			}
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Errorf("Searching %q: expected %q, but got %q", tc.query, tc.expected, names)
+			}
+		}
+		cities, err := repo.SearchCities("new york", 1)
+		if err != nil || len(cities) != 1 || !reflect.DeepEqual(cities[0].Aliases, []string{"NYC", "New York City"}) {
+			t.Errorf("Expected search results to include aliases, but got %v, %v", cities, err)
+		}
+	})
+
+	t.Run("NearestAndWithinRadius", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, city := range []domain.City{
+			{ID: "reading-gb", Name: "Reading", Latitude: 51.4543, Longitude: -0.9781},
+			{ID: "suva-fj", Name: "Suva", Latitude: -18.1416, Longitude: 178.4419},
+		} {
+			if err := repo.CreateCity(city); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+		names := func(cities []domain.NearbyCity) []string {
+			names := make([]string, 0, len(cities))
+			for _, city := range cities {
+				names = append(names, city.City.Name)
+			}
+			return names
+		}
+		nearestCases := []struct {
+			latitude, longitude float64
+			k                   int
+			expected            []string
+		}{
+			{latitude: 51.5, longitude: -0.12, k: 2, expected: []string{"London", "Reading"}},
+			{latitude: 51.5, longitude: -0.12, k: 10, expected: []string{"London", "Reading", "Paris", "New York", "Tokyo", "Suva"}},
+			{latitude: -17, longitude: -179, k: 1, expected: []string{"Suva"}},
+		}
+		for _, tc := range nearestCases {
+			cities, err := repo.Nearest(tc.latitude, tc.longitude, tc.k)
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			} else if got := names(cities); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Nearest %d to %v,%v: expected %q, but got %q", tc.k, tc.latitude, tc.longitude, tc.expected, got)
+			}
+		}
+		radiusCases := []struct {
+			latitude, longitude, km float64
+			expected                []string
+		}{
+			{latitude: 51.5, longitude: -0.12, km: 100, expected: []string{"London", "Reading"}},
+			{latitude: 51.5, longitude: -0.12, km: 0.5, expected: []string{}},
+			// across the antimeridian
+			{latitude: -18.1, longitude: -179.9, km: 300, expected: []string{"Suva"}},
+		}
+		for _, tc := range radiusCases {
+			cities, err := repo.WithinRadius(tc.latitude, tc.longitude, tc.km)
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			} else if got := names(cities); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Within %g km of %v,%v: expected %q, but got %q", tc.km, tc.latitude, tc.longitude, tc.expected, got)
+			}
+		}
+		cities, err := repo.Nearest(51.5, -0.12, 1)
+		if err != nil || len(cities) != 1 || math.Abs(cities[0].DistanceKm-0.98) > 0.01 {
+			t.Errorf("Expected London at 0.98 km, but got %v, %v", cities, err)
+		}
+
+		// the index follows moved and deleted cities
+		if err := repo.UpdateCity("reading-gb", domain.City{ID: "oxford-gb", Name: "Oxford", Latitude: 51.752, Longitude: -1.2577}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := repo.DeleteCity("london-england-gb"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		cities, err = repo.WithinRadius(51.5, -0.12, 100)
+		if err != nil || !reflect.DeepEqual(names(cities), []string{"Oxford"}) {
+			t.Errorf("Expected only Oxford, but got %v, %v", cities, err)
+		}
+	})
+
+	t.Run("GetAllCities", func(t *testing.T) {
+		repo := newRepo(t)
+
+		cities, err := repo.GetAllCities()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		names := make(map[string]bool, len(cities))
+		for _, city := range cities {
+			names[city.Name] = true
+		}
+		for _, name := range []string{"Tokyo", "New York", "London", "Paris"} {
+			if !names[name] {
+				t.Errorf("Expected seed city %q, but got %v", name, cities)
+			}
+		}
+		if len(cities) != 4 {
+			t.Errorf("Expected 4 cities, but got %d", len(cities))
+		}
+	})
+
+	t.Run("CreateCity", func(t *testing.T) {
+		repo := newRepo(t)
+		berlin := domain.City{ID: "berlin-de", Name: "Berlin", CountryCode: "DE", Latitude: 52.5200, Longitude: 13.4050,
+			Timezone: "Europe/Berlin", Population: 3644826}
+
+		if err := repo.CreateCity(berlin); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		city, err := repo.GetCity("Berlin")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(*city, berlin) {
+			t.Errorf("Expected city %v, but got %v", berlin, city)
+		}
+		if err := repo.CreateCity(domain.City{ID: "berlin-de", Name: "Berlin", Latitude: 0, Longitude: 0}); !errors.Is(err, domain.ErrCityExists) {
+			t.Errorf("Expected domain.ErrCityExists, but got %v", err)
+		}
+		if city, err := repo.GetCity("berlin-de"); err != nil || city.Latitude != 52.52 {
+			t.Errorf("Expected a rejected city not to be stored, but got %v, %v", city, err)
+		}
+		// only the ID has to be unique, a name or alias may be shared
+		if err := repo.CreateCity(domain.City{ID: "nowhere", Name: "Nowhere", Latitude: 0, Longitude: 0, Aliases: []string{"nyc"}}); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if city, err := repo.GetCity("nyc"); err != nil || city.Name != "New York" {
+			t.Errorf("Expected the more populous New York by its alias, but got %v, %v", city, err)
+		}
+	})
+
+	t.Run("CreateCities", func(t *testing.T) {
+		repo := newRepo(t)
+		berlin := domain.City{ID: "berlin-de", Name: "Berlin", CountryCode: "DE", Latitude: 52.5200, Longitude: 13.4050, Aliases: []string{"BER"}}
+		hamburg := domain.City{ID: "hamburg-de", Name: "Hamburg", CountryCode: "DE", Latitude: 53.5511, Longitude: 9.9937}
+
+		created, err := repo.CreateCities([]domain.City{berlin, {ID: "tokyo-jp", Name: "Tokio", Latitude: 0, Longitude: 0}, hamburg})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if created != 2 {
+			t.Errorf("Expected 2 cities to be created, but got %d", created)
+		}
+		for name, expected := range map[string]domain.City{"ber": berlin, "Hamburg": hamburg} {
+			if city, err := repo.GetCity(name); err != nil || !reflect.DeepEqual(*city, expected) {
+				t.Errorf("Expected city %v, but got %v, %v", expected, city, err)
+			}
+		}
+		if city, err := repo.GetCity("tokyo-jp"); err != nil || city.Name != "Tokyo" {
+			t.Errorf("Expected a city whose ID is taken to be left unchanged, but got %v, %v", city, err)
+		}
+		if created, err := repo.CreateCities(nil); err != nil || created != 0 {
+			t.Errorf("Expected no cities to be created, but got %d, %v", created, err)
+		}
+	})
+
+	t.Run("CitiesSharingAName", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, city := range []domain.City{
+			{ID: "paris-tennessee-us", Name: "Paris", CountryCode: "US", Admin1: "Tennessee", Latitude: 36.302, Longitude: -88.3267, Population: 10156},
+			{ID: "paris-texas-us", Name: "Paris", CountryCode: "US", Admin1: "Texas", Latitude: 33.6609, Longitude: -95.5555, Population: 24782},
+		} {
+			if err := repo.CreateCity(city); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+		if city, err := repo.GetCity("paris"); err != nil || city.ID != "paris-ile-de-france-fr" {
+			t.Errorf("Expected the most populous Paris, but got %v, %v", city, err)
+		}
+		if city, err := repo.GetCity("paris-texas-us"); err != nil || city.Admin1 != "Texas" {
+			t.Errorf("Expected Paris, Texas by its ID, but got %v, %v", city, err)
+		}
+		cities, err := repo.FindCities("PARIS")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		ids := make([]string, 0, len(cities))
+		for _, city := range cities {
+			ids = append(ids, city.ID)
+		}
+		expected := []string{"paris-ile-de-france-fr", "paris-texas-us", "paris-tennessee-us"}
+		if !reflect.DeepEqual(ids, expected) {
+			t.Errorf("Expected %q, but got %q", expected, ids)
+		}
+		if cities, err := repo.FindCities("Unknown"); err != nil || len(cities) != 0 {
+			t.Errorf("Expected no cities, but got %v, %v", cities, err)
+		}
+
+		if err := repo.DeleteCity("paris-texas-us"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cities, err := repo.FindCities("Paris"); err != nil || len(cities) != 2 {
+			t.Errorf("Expected 2 cities, but got %v, %v", cities, err)
+		}
+	})
+
+	t.Run("UpdateCity", func(t *testing.T) {
+		repo := newRepo(t)
+		testCases := []struct {
+			name        string
+			city        domain.City
+			expectedErr error
+		}{
+			{name: "London", city: domain.City{ID: "london-england-gb", Name: "London", Latitude: 51.5072, Longitude: -0.1276}},
+			{name: "London", city: domain.City{ID: "london-england-gb", Name: "Greater London", Latitude: 51.5072, Longitude: -0.1276}},
+			{name: "Greater London", city: domain.City{ID: "paris-ile-de-france-fr", Name: "Paris", Latitude: 0, Longitude: 0}, expectedErr: domain.ErrCityExists},
+			{name: "Unknown", city: domain.City{ID: "unknown", Name: "Unknown", Latitude: 0, Longitude: 0}, expectedErr: domain.ErrCityNotFound},
+			{name: "nyc", city: domain.City{ID: "new-york-us", Name: "New York", Latitude: 40.7128, Longitude: -74.006, Aliases: []string{"Big Apple"}}},
+			{name: "tokyo-jp", city: domain.City{ID: "tokyo", Name: "Tokyo", Latitude: 35.6895, Longitude: 139.6917}},
+		}
+		for _, tc := range testCases {
+			err := repo.UpdateCity(tc.name, tc.city)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Updating %q to %v: expected error %v, but got %v", tc.name, tc.city, tc.expectedErr, err)
+				continue
+			}
+			if err != nil {
+				continue
+			}
+			city, err := repo.GetCity(tc.city.ID)
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			} else if !reflect.DeepEqual(*city, tc.city) {
+				t.Errorf("Expected city %v, but got %v", tc.city, city)
+			}
+		}
+		if _, err := repo.GetCity("London"); !errors.Is(err, domain.ErrCityNotFound) {
+			t.Errorf("Expected renamed city to be gone, but got %v", err)
+		}
+		if city, err := repo.GetCity("Paris"); err != nil || city.Latitude != 48.8566 {
+			t.Errorf("Expected Paris to be unchanged, but got %v, %v", city, err)
+		}
+		if _, err := repo.GetCity("NYC"); !errors.Is(err, domain.ErrCityNotFound) {
+			t.Errorf("Expected removed alias to be gone, but got %v", err)
+		}
+		if city, err := repo.GetCity("big apple"); err != nil || city.Name != "New York" {
+			t.Errorf("Expected New York by its new alias, but got %v, %v", city, err)
+		}
+		if _, err := repo.GetCity("tokyo-jp"); !errors.Is(err, domain.ErrCityNotFound) {
+			t.Errorf("Expected the old ID to be gone, but got %v", err)
+		}
+	})
+
+	t.Run("DeleteCity", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if err := repo.DeleteCity("東京"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := repo.GetCity("Tokyo"); !errors.Is(err, domain.ErrCityNotFound) {
+			t.Errorf("Expected domain.ErrCityNotFound after delete, but got %v", err)
+		}
+		if err := repo.DeleteCity("Tokyo"); !errors.Is(err, domain.ErrCityNotFound) {
+			t.Errorf("Expected domain.ErrCityNotFound, but got %v", err)
+		}
+		cities, err := repo.GetAllCities()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(cities) != 3 {
+			t.Errorf("Expected 3 cities, but got %d", len(cities))
+		}
+		// the deleted city's name and aliases are free again
+		if err := repo.CreateCity(domain.City{ID: "tokyo-jp", Name: "Tokyo", Latitude: 35.6895, Longitude: 139.6917, Aliases: []string{"東京"}}); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestInMemoryCityRepository(t *testing.T) {
+	testCityRepository(t, func(t *testing.T) domain.CityRepository {
+		return NewInMemoryCityRepository()
+	})
 }
@@ -0,0 +1,8 @@
+CREATE TABLE cities (
+    id        INTEGER PRIMARY KEY,
+    name      TEXT NOT NULL,
+    latitude  TEXT NOT NULL,
+    longitude TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_cities_name ON cities (name);
@@ -0,0 +1,5 @@
+INSERT INTO cities (name, latitude, longitude) VALUES
+    ('Tokyo', '35.6895', '139.6917'),
+    ('New York', '40.7128', '-74.0060'),
+    ('London', '51.5074', '-0.1278'),
+    ('Paris', '48.8566', '2.3522');
@@ -0,0 +1,16 @@
+-- Store coordinates as numbers rather than text. SQLite cannot change a column type in place,
+-- so the table is rebuilt and the existing rows are converted.
+CREATE TABLE cities_new (
+    id        INTEGER PRIMARY KEY,
+    name      TEXT NOT NULL,
+    latitude  REAL NOT NULL CHECK (latitude BETWEEN -90 AND 90),
+    longitude REAL NOT NULL CHECK (longitude BETWEEN -180 AND 180)
+);
+
+INSERT INTO cities_new (id, name, latitude, longitude)
+SELECT id, name, ROUND(CAST(latitude AS REAL), 4), ROUND(CAST(longitude AS REAL), 4) FROM cities;
+
+DROP TABLE cities;
+ALTER TABLE cities_new RENAME TO cities;
+
+CREATE UNIQUE INDEX idx_cities_name ON cities (name);
@@ -0,0 +1,13 @@
+-- Cities are looked up by their normalized name and aliases. The keys are computed by the
+-- application, which indexes cities without keys, such as the existing ones, when it opens the database.
+ALTER TABLE cities ADD COLUMN aliases TEXT NOT NULL DEFAULT '[]';
+
+CREATE TABLE city_lookup_keys (
+    key     TEXT PRIMARY KEY,
+    city_id INTEGER NOT NULL REFERENCES cities (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_city_lookup_keys_city_id ON city_lookup_keys (city_id);
+
+UPDATE cities SET aliases = '["東京"]' WHERE name = 'Tokyo';
+UPDATE cities SET aliases = '["NYC","New York City"]' WHERE name = 'New York';
@@ -0,0 +1,26 @@
+-- City coordinates are indexed in an R*Tree for nearby city queries. Triggers keep the index in
+-- step with the cities table.
+CREATE VIRTUAL TABLE city_locations USING rtree (
+    id,
+    min_latitude, max_latitude,
+    min_longitude, max_longitude
+);
+
+INSERT INTO city_locations (id, min_latitude, max_latitude, min_longitude, max_longitude)
+SELECT id, latitude, latitude, longitude, longitude FROM cities;
+
+CREATE TRIGGER cities_location_insert AFTER INSERT ON cities BEGIN
+    INSERT INTO city_locations (id, min_latitude, max_latitude, min_longitude, max_longitude)
+    VALUES (new.id, new.latitude, new.latitude, new.longitude, new.longitude);
+END;
+
+CREATE TRIGGER cities_location_update AFTER UPDATE OF latitude, longitude ON cities BEGIN
+    UPDATE city_locations
+    SET min_latitude = new.latitude, max_latitude = new.latitude,
+        min_longitude = new.longitude, max_longitude = new.longitude
+    WHERE id = new.id;
+END;
+
+CREATE TRIGGER cities_location_delete AFTER DELETE ON cities BEGIN
+    DELETE FROM city_locations WHERE id = old.id;
+END;
@@ -0,0 +1,37 @@
+-- Cities are keyed by a stable ID, the slug column, and may share their name with cities in other
+-- administrative areas or countries. Slugs are computed by the application, which assigns them to
+-- cities without one, such as the existing ones, when it opens the database.
+ALTER TABLE cities ADD COLUMN slug TEXT;
+ALTER TABLE cities ADD COLUMN country_code TEXT NOT NULL DEFAULT '';
+ALTER TABLE cities ADD COLUMN admin1 TEXT NOT NULL DEFAULT '';
+ALTER TABLE cities ADD COLUMN elevation REAL;
+ALTER TABLE cities ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
+ALTER TABLE cities ADD COLUMN population INTEGER NOT NULL DEFAULT 0;
+
+DROP INDEX idx_cities_name;
+CREATE UNIQUE INDEX idx_cities_slug ON cities (slug);
+CREATE INDEX idx_cities_name ON cities (name);
+
+-- A lookup key now finds every city of that name or alias.
+CREATE TABLE city_lookup_keys_new (
+    key     TEXT NOT NULL,
+    city_id INTEGER NOT NULL REFERENCES cities (id) ON DELETE CASCADE,
+    PRIMARY KEY (key, city_id)
+);
+
+INSERT INTO city_lookup_keys_new (key, city_id) SELECT key, city_id FROM city_lookup_keys;
+
+DROP TABLE city_lookup_keys;
+ALTER TABLE city_lookup_keys_new RENAME TO city_lookup_keys;
+
+CREATE INDEX idx_city_lookup_keys_city_id ON city_lookup_keys (city_id);
+
+-- The seed cities, unless they were changed since.
+UPDATE cities SET country_code = 'JP', admin1 = 'Tokyo', elevation = 44, timezone = 'Asia/Tokyo', population = 8336599
+WHERE name = 'Tokyo' AND latitude = 35.6895 AND longitude = 139.6917;
+UPDATE cities SET country_code = 'US', admin1 = 'New York', elevation = 10, timezone = 'America/New_York', population = 8804190
+WHERE name = 'New York' AND latitude = 40.7128 AND longitude = -74.006;
+UPDATE cities SET country_code = 'GB', admin1 = 'England', elevation = 25, timezone = 'Europe/London', population = 8961989
+WHERE name = 'London' AND latitude = 51.5074 AND longitude = -0.1278;
+UPDATE cities SET country_code = 'FR', admin1 = 'Île-de-France', elevation = 42, timezone = 'Europe/Paris', population = 2138551
+WHERE name = 'Paris' AND latitude = 48.8566 AND longitude = 2.3522;
@@ -0,0 +1,494 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/softstone1/woc/domain"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// migrations are applied in the order of their numeric prefix, each exactly once.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// SQLiteCityRepository is a CityRepository persisted in a SQLite database.
+type SQLiteCityRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteCityRepository opens the SQLite database at path, creating it if it does not exist,
+// and applies pending schema migrations.
+func NewSQLiteCityRepository(path string) (*SQLiteCityRepository, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("opening city database %s: %w", path, err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating city database %s: %w", path, err)
+	}
+	if err := assignIDs(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("assigning city IDs in %s: %w", path, err)
+	}
+	if err := indexLookupKeys(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("indexing city database %s: %w", path, err)
+	}
+	return &SQLiteCityRepository{
+		db: db,
+	}, nil
+}
+
+// Close closes the database.
+func (repo *SQLiteCityRepository) Close() error {
+	return repo.db.Close()
+}
+
+// cityColumns are the columns scanCity reads.
+const cityColumns = `cities.slug, cities.name, cities.country_code, cities.admin1, cities.latitude, cities.longitude,
+	cities.elevation, cities.timezone, cities.population, cities.aliases`
+
+// insertCity inserts a city with the slug, name, country code, administrative area, coordinates,
+// elevation, time zone, population and encoded aliases.
+const insertCity = `INSERT INTO cities (slug, name, country_code, admin1, latitude, longitude, elevation, timezone, population, aliases)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// lookupCity selects the city with the slug ?1, or else the most populous city with the lookup key ?2.
+const lookupCity = ` FROM cities WHERE slug = ?1 OR id IN (SELECT city_id FROM city_lookup_keys WHERE key = ?2)
+	ORDER BY slug = ?1 DESC, population DESC, slug LIMIT 1`
+
+// GetCity retrieves city information by ID, name or alias.
+func (repo *SQLiteCityRepository) GetCity(name string) (*domain.City, error) {
+	city, err := scanCity(repo.db.QueryRow(`SELECT `+cityColumns+lookupCity, name, domain.NormalizeCityName(name)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrCityNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("querying city %q: %w", name, err)
+	}
+	return &city, nil
+}
+
+// FindCities returns the cities with the name or alias, most populous first.
+func (repo *SQLiteCityRepository) FindCities(name string) ([]domain.City, error) {
+	return repo.queryCities(`SELECT `+cityColumns+` FROM city_lookup_keys
+		JOIN cities ON cities.id = city_lookup_keys.city_id WHERE city_lookup_keys.key = ?
+		ORDER BY cities.population DESC, cities.slug`, domain.NormalizeCityName(name))
+}
+
+// Returns all cities in the repository, ordered by name.
+func (repo *SQLiteCityRepository) GetAllCities() ([]domain.City, error) {
+	return repo.queryCities(`SELECT ` + cityColumns + ` FROM cities ORDER BY name, slug`)
+}
+
+// CreateCity adds a city unless its ID is taken.
+func (repo *SQLiteCityRepository) CreateCity(city domain.City) error {
+	aliases, err := encodeAliases(city.Aliases)
+	if err != nil {
+		return err
+	}
+	return repo.inTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(insertCity,
+			city.ID, city.Name, city.CountryCode, city.Admin1, city.Latitude, city.Longitude, city.Elevation, city.Timezone, city.Population, aliases)
+		if isUniqueViolation(err) {
+			return domain.ErrCityExists
+		}
+		if err != nil {
+			return fmt.Errorf("inserting city %q: %w", city.ID, err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		return insertLookupKeys(tx, id, city)
+	})
+}
+
+// CreateCities adds the cities whose ID is not taken in a single transaction.
+func (repo *SQLiteCityRepository) CreateCities(cities []domain.City) (int, error) {
+	created := 0
+	err := repo.inTx(func(tx *sql.Tx) error {
+		insert, err := tx.Prepare(insertCity + ` ON CONFLICT (slug) DO NOTHING`)
+		if err != nil {
+			return err
+		}
+		defer insert.Close()
+		for _, city := range cities {
+			aliases, err := encodeAliases(city.Aliases)
+			if err != nil {
+				return err
+			}
+			result, err := insert.Exec(
+				city.ID, city.Name, city.CountryCode, city.Admin1, city.Latitude, city.Longitude, city.Elevation, city.Timezone, city.Population, aliases)
+			if err != nil {
+				return fmt.Errorf("inserting city %q: %w", city.ID, err)
+			}
+			inserted, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if inserted == 0 {
+				// the ID is taken
+				continue
+			}
+			id, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+			if err := insertLookupKeys(tx, id, city); err != nil {
+				return err
+			}
+			created++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return created, nil
+}
+
+// UpdateCity replaces the city found by ID, name or alias, which may rename it or change its ID.
+func (repo *SQLiteCityRepository) UpdateCity(name string, city domain.City) error {
+	aliases, err := encodeAliases(city.Aliases)
+	if err != nil {
+		return err
+	}
+	return repo.inTx(func(tx *sql.Tx) error {
+		id, err := lookupCityID(tx, name)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE cities SET slug = ?, name = ?, country_code = ?, admin1 = ?, latitude = ?, longitude = ?,
+			elevation = ?, timezone = ?, population = ?, aliases = ? WHERE id = ?`,
+			city.ID, city.Name, city.CountryCode, city.Admin1, city.Latitude, city.Longitude,
+			city.Elevation, city.Timezone, city.Population, aliases, id)
+		if isUniqueViolation(err) {
+			return domain.ErrCityExists
+		}
+		if err != nil {
+			return fmt.Errorf("updating city %q: %w", name, err)
+		}
+		if _, err := tx.Exec(`DELETE FROM city_lookup_keys WHERE city_id = ?`, id); err != nil {
+			return err
+		}
+		return insertLookupKeys(tx, id, city)
+	})
+}
+
+// DeleteCity removes the city found by ID, name or alias.
+func (repo *SQLiteCityRepository) DeleteCity(name string) error {
+	return repo.inTx(func(tx *sql.Tx) error {
+		id, err := lookupCityID(tx, name)
+		if err != nil {
+			return err
+		}
+		// the lookup keys are removed by the foreign key cascade
+		if _, err := tx.Exec(`DELETE FROM cities WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("deleting city %q: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// SearchCities returns up to limit cities whose name or an alias matches query, best match first.
+func (repo *SQLiteCityRepository) SearchCities(query string, limit int) ([]domain.City, error) {
+	// fuzzy matching needs every key, the key table is small enough to rank in memory
+	rows, err := repo.db.Query(`SELECT key, city_id FROM city_lookup_keys`)
+	if err != nil {
+		return nil, fmt.Errorf("querying city lookup keys: %w", err)
+	}
+	defer rows.Close()
+	matches := domain.NewCityMatches[int64](query)
+	for rows.Next() {
+		var key string
+		var id int64
+		if err := rows.Scan(&key, &id); err != nil {
+			return nil, fmt.Errorf("scanning city lookup key: %w", err)
+		}
+		matches.Add(id, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	ids := matches.Top(limit)
+	cities := make([]domain.City, 0, len(ids))
+	for _, id := range ids {
+		city, err := scanCity(repo.db.QueryRow(`SELECT `+cityColumns+` FROM cities WHERE id = ?`, id))
+		if err != nil {
+			return nil, fmt.Errorf("querying city %d: %w", id, err)
+		}
+		cities = append(cities, city)
+	}
+	return cities, nil
+}
+
+// Nearest returns the k cities closest to the point, nearest first.
+func (repo *SQLiteCityRepository) Nearest(latitude, longitude float64, k int) ([]domain.NearbyCity, error) {
+	return nearest(k, func(km float64) ([]domain.NearbyCity, error) {
+		return repo.WithinRadius(latitude, longitude, km)
+	})
+}
+
+// WithinRadius returns the cities at most km kilometres from the point, nearest first.
+// The city_locations R*Tree narrows the candidates down to the bounding box of the circle.
+func (repo *SQLiteCityRepository) WithinRadius(latitude, longitude, km float64) ([]domain.NearbyCity, error) {
+	box := domain.NewBoundingBox(latitude, longitude, km)
+	nearby := []domain.NearbyCity{}
+	for _, r := range box.LongitudeRanges() {
+		rows, err := repo.db.Query(`SELECT `+cityColumns+` FROM city_locations
+			JOIN cities ON cities.id = city_locations.id
+			WHERE city_locations.max_latitude >= ? AND city_locations.min_latitude <= ?
+			AND city_locations.max_longitude >= ? AND city_locations.min_longitude <= ?`,
+			box.MinLatitude, box.MaxLatitude, r[0], r[1])
+		if err != nil {
+			return nil, fmt.Errorf("querying nearby cities: %w", err)
+		}
+		for rows.Next() {
+			city, err := scanCity(rows)
+			if err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scanning city: %w", err)
+			}
+			if distance := domain.DistanceKm(latitude, longitude, city.Latitude, city.Longitude); distance <= km {
+				nearby = append(nearby, domain.NearbyCity{City: city, DistanceKm: distance})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	domain.SortByDistance(nearby)
+	return nearby, nil
+}
+
+// queryCities returns the cities a query selecting cityColumns returns.
+func (repo *SQLiteCityRepository) queryCities(query string, args ...any) ([]domain.City, error) {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying cities: %w", err)
+	}
+	defer rows.Close()
+	var cities []domain.City
+	for rows.Next() {
+		city, err := scanCity(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning city: %w", err)
+		}
+		cities = append(cities, city)
+	}
+	return cities, rows.Err()
+}
+
+// inTx runs fn in a transaction, committing it if fn succeeds.
+func (repo *SQLiteCityRepository) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lookupCityID returns the row id of the city found by ID, name or alias.
+func lookupCityID(tx *sql.Tx, name string) (int64, error) {
+	var id int64
+	err := tx.QueryRow(`SELECT id`+lookupCity, name, domain.NormalizeCityName(name)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, domain.ErrCityNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("querying city %q: %w", name, err)
+	}
+	return id, nil
+}
+
+// insertLookupKeys stores the keys the city with the given id is found by.
+func insertLookupKeys(tx *sql.Tx, id int64, city domain.City) error {
+	for _, key := range city.LookupKeys() {
+		if _, err := tx.Exec(`INSERT INTO city_lookup_keys (key, city_id) VALUES (?, ?)`, key, id); err != nil {
+			return fmt.Errorf("indexing city %q: %w", city.ID, err)
+		}
+	}
+	return nil
+}
+
+// assignIDs gives the cities written before IDs were introduced the ID NewCityID derives, or where
+// that is taken, the derived ID followed by the row id.
+func assignIDs(db *sql.DB) error {
+	rows, err := db.Query(`SELECT id, name, admin1, country_code FROM cities WHERE slug IS NULL ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	type unassigned struct {
+		id   int64
+		slug string
+	}
+	var cities []unassigned
+	for rows.Next() {
+		var c unassigned
+		var name, admin1, countryCode string
+		if err := rows.Scan(&c.id, &name, &admin1, &countryCode); err != nil {
+			rows.Close()
+			return err
+		}
+		c.slug = domain.NewCityID(name, admin1, countryCode)
+		cities = append(cities, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, c := range cities {
+		_, err := db.Exec(`UPDATE cities SET slug = ? WHERE id = ?`, c.slug, c.id)
+		if isUniqueViolation(err) {
+			_, err = db.Exec(`UPDATE cities SET slug = ? WHERE id = ?`, fmt.Sprintf("%s-%d", c.slug, c.id), c.id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexLookupKeys stores the lookup keys of cities that have none, such as cities written before
+// aliases were introduced.
+func indexLookupKeys(db *sql.DB) error {
+	rows, err := db.Query(`SELECT cities.id, ` + cityColumns + ` FROM cities
+		WHERE NOT EXISTS (SELECT 1 FROM city_lookup_keys WHERE city_lookup_keys.city_id = cities.id)`)
+	if err != nil {
+		return err
+	}
+	type unindexed struct {
+		id   int64
+		city domain.City
+	}
+	var cities []unindexed
+	for rows.Next() {
+		var c unindexed
+		if c.city, err = scanCity(rows, &c.id); err != nil {
+			rows.Close()
+			return err
+		}
+		cities = append(cities, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, c := range cities {
+		for _, key := range c.city.LookupKeys() {
+			if _, err := db.Exec(`INSERT OR IGNORE INTO city_lookup_keys (key, city_id) VALUES (?, ?)`, key, c.id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scanCity reads a row selected with cityColumns, preceded by the extra destinations.
+func scanCity(row interface{ Scan(dest ...any) error }, extra ...any) (domain.City, error) {
+	var city domain.City
+	var aliases string
+	if err := row.Scan(append(extra, &city.ID, &city.Name, &city.CountryCode, &city.Admin1, &city.Latitude, &city.Longitude,
+		&city.Elevation, &city.Timezone, &city.Population, &aliases)...); err != nil {
+		return domain.City{}, err
+	}
+	if err := json.Unmarshal([]byte(aliases), &city.Aliases); err != nil {
+		return domain.City{}, fmt.Errorf("decoding aliases of city %q: %w", city.ID, err)
+	}
+	if len(city.Aliases) == 0 {
+		city.Aliases = nil
+	}
+	return city, nil
+}
+
+// encodeAliases returns the aliases as stored in the aliases column, a JSON array.
+func encodeAliases(aliases []string) (string, error) {
+	if aliases == nil {
+		aliases = []string{}
+	}
+	encoded, err := json.Marshal(aliases)
+	if err != nil {
+		return "", fmt.Errorf("encoding aliases: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// isUniqueViolation reports whether err is a violation of a unique index or primary key, such as
+// a duplicate city ID.
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+// migrate applies the embedded migrations that have not been applied yet.
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return err
+	}
+	// fs.Glob returns the names in lexical order, which the zero-padded prefixes keep numeric
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(path.Base(name), "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return fmt.Errorf("migration %s has no numeric version prefix", name)
+		}
+		var applied bool
+		if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = ?)`, version).Scan(&applied); err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+		script, err := migrations.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err := applyMigration(db, version, string(script)); err != nil {
+			return fmt.Errorf("applying migration %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration runs a migration script and records its version in a single transaction.
+func applyMigration(db *sql.DB, version int, script string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/softstone1/woc/domain"
+)
+
+// newTestSQLiteCityRepository opens the database at path and closes it when the test ends.
+func newTestSQLiteCityRepository(t *testing.T, path string) *SQLiteCityRepository {
+	repo, err := NewSQLiteCityRepository(path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening the database: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func TestSQLiteCityRepository(t *testing.T) {
+	testCityRepository(t, func(t *testing.T) domain.CityRepository {
+		return newTestSQLiteCityRepository(t, filepath.Join(t.TempDir(), "cities.db"))
+	})
+}
+
+func TestSQLiteCityRepository_Migrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cities.db")
+	repo, err := NewSQLiteCityRepository(path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening the database: %v", err)
+	}
+	// data written after the seed survives a restart and the seed is not applied twice
+	if _, err := repo.db.Exec(`INSERT INTO cities (name, latitude, longitude) VALUES ('Berlin', 52.52, 13.405), ('Berlin', 44.4695, -71.1851)`); err != nil {
+		t.Fatalf("Unexpected error inserting a city: %v", err)
+	}
+	repo.Close()
+
+	repo = newTestSQLiteCityRepository(t, path)
+	cities, err := repo.GetAllCities()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cities) != 6 {
+		t.Errorf("Expected the 4 seed cities and both Berlins, but got %v", cities)
+	}
+	// cities stored without an ID are given one when the database is opened
+	for id, latitude := range map[string]float64{"berlin": 52.52, "berlin-6": 44.4695} {
+		if city, err := repo.GetCity(id); err != nil || city.ID != id || city.Latitude != latitude {
+			t.Errorf("Expected Berlin at %v by the ID %q, but got %v, %v", latitude, id, city, err)
+		}
+	}
+	var migrationsApplied int
+	if err := repo.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&migrationsApplied); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	names, _ := migrations.ReadDir("migrations")
+	if migrationsApplied != len(names) {
+		t.Errorf("Expected %d applied migrations, but got %d", len(names), migrationsApplied)
+	}
+}
+
+func TestSQLiteCityRepository_NumericCoordinatesMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cities.db")
+	// a database created before coordinates were numeric
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening the database: %v", err)
+	}
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for version, name := range []string{"migrations/0001_create_cities.sql", "migrations/0002_seed_cities.sql"} {
+		script, err := migrations.ReadFile(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := applyMigration(db, version+1, string(script)); err != nil {
+			t.Fatalf("Unexpected error applying %s: %v", name, err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO cities (name, latitude, longitude) VALUES ('Berlin', '52.520008', '13.4050')`); err != nil {
+		t.Fatalf("Unexpected error inserting a city: %v", err)
+	}
+	db.Close()
+
+	repo := newTestSQLiteCityRepository(t, path)
+	testCases := []domain.City{
+		{ID: "berlin", Name: "Berlin", Latitude: 52.52, Longitude: 13.405},
+		{ID: "new-york-us", Name: "New York", CountryCode: "US", Admin1: "New York", Latitude: 40.7128, Longitude: -74.006,
+			Elevation: metres(10), Timezone: "America/New_York", Population: 8804190, Aliases: []string{"NYC", "New York City"}},
+	}
+	for _, expected := range testCases {
+		city, err := repo.GetCity(expected.Name)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		} else if !reflect.DeepEqual(*city, expected) {
+			t.Errorf("Expected city %v, but got %v", expected, city)
+		}
+	}
+	// cities stored before aliases existed are indexed when the database is opened
+	if city, err := repo.GetCity("nyc"); err != nil || city.Name != "New York" {
+		t.Errorf("Expected New York by its alias, but got %v, %v", city, err)
+	}
+	if err := repo.CreateCity(domain.City{ID: "nowhere", Name: "Nowhere", Latitude: 91, Longitude: 0}); err == nil {
+		t.Errorf("Expected out of range latitude to be rejected")
+	}
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// RequireAdmin lets a request through to next only if it carries the admin token as a bearer
+// credential. Without a configured token the wrapped routes are disabled.
+func RequireAdmin(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			respondWithProblem(w, r, forbiddenClass, "admin operations are disabled, set ADMIN_TOKEN to enable them")
+			return
+		}
+		credential, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(credential), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="woc"`)
+			respondWithProblem(w, r, unauthorizedClass, "missing or invalid bearer token")
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name                 string
+		token                string
+		authorization        string
+		expectedStatus       int
+		expectedAuthenticate string
+	}{
+		{name: "Valid Token", token: "secret", authorization: "Bearer secret", expectedStatus: http.StatusNoContent},
+		{name: "Wrong Token", token: "secret", authorization: "Bearer guess", expectedStatus: http.StatusUnauthorized, expectedAuthenticate: `Bearer realm="woc"`},
+		{name: "Missing Token", token: "secret", expectedStatus: http.StatusUnauthorized, expectedAuthenticate: `Bearer realm="woc"`},
+		{name: "Basic Credentials", token: "secret", authorization: "Basic c2VjcmV0", expectedStatus: http.StatusUnauthorized, expectedAuthenticate: `Bearer realm="woc"`},
+		{name: "No Token Configured", token: "", authorization: "Bearer ", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			next := func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}
+			req := httptest.NewRequest("DELETE", "/api/cities/London", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			RequireAdmin(tc.token, next)(recorder, req)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+			if got := recorder.Header().Get("WWW-Authenticate"); got != tc.expectedAuthenticate {
+				t.Errorf("Expected WWW-Authenticate %q, got %q", tc.expectedAuthenticate, got)
+			}
+			if tc.expectedStatus != http.StatusNoContent && recorder.Header().Get("Content-Type") != problemContentType {
+				t.Errorf("Expected problem details, got Content-Type %q", recorder.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
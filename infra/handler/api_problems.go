@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var methodNotAllowedClass = errorClass{http.StatusMethodNotAllowed, "/problems/method-not-allowed", http.StatusText(http.StatusMethodNotAllowed)}
+
+// NotFoundAPI answers /api paths no route matches, which would otherwise fall through to the
+// HTML home page.
+func NotFoundAPI(w http.ResponseWriter, r *http.Request) {
+	respondWithProblem(w, r, notFoundClass, fmt.Sprintf("no API route for %s", r.URL.Path))
+}
+
+// WithAPIProblems reports the 405 Method Not Allowed responses the mux itself writes for /api
+// paths as problem details, like the errors of the API handlers.
+func WithAPIProblems(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&problemWriter{ResponseWriter: w, r: r}, r)
+	})
+}
+
+// problemWriter replaces a plain text 405 with problem details, keeping the Allow header.
+type problemWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	replaced bool
+}
+
+func (w *problemWriter) WriteHeader(status int) {
+	if status == http.StatusMethodNotAllowed && w.Header().Get("Content-Type") != problemContentType {
+		w.replaced = true
+		respondWithProblem(w.ResponseWriter, w.r, methodNotAllowedClass, fmt.Sprintf("%s is not allowed on %s", w.r.Method, w.r.URL.Path))
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *problemWriter) Write(b []byte) (int, error) {
+	if w.replaced {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
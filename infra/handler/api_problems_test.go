@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithAPIProblems(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html></html>"))
+	})
+	mux.HandleFunc("GET /api/", NotFoundAPI)
+	mux.HandleFunc("GET /api/weather", func(w http.ResponseWriter, r *http.Request) {
+		respondWithJSON(w, http.StatusOK, map[string]string{"city": "London"})
+	})
+	handler := WithRequestID(WithAPIProblems(mux))
+
+	tests := []struct {
+		name                string
+		method              string
+		path                string
+		expectedStatus      int
+		expectedContentType string
+		expectedAllow       string
+		expectedBody        string
+	}{
+		{
+			name:                "API Route",
+			method:              "GET",
+			path:                "/api/weather",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `{"city": "London"}`,
+		},
+		{
+			name:                "Unknown API Path",
+			method:              "GET",
+			path:                "/api/wether",
+			expectedStatus:      http.StatusNotFound,
+			expectedContentType: "application/problem+json",
+			expectedBody: `{"type": "/problems/not-found", "title": "Resource not found", "status": 404,
+				"detail": "no API route for /api/wether", "instance": "/api/wether", "requestId": "req-1"}`,
+		},
+		{
+			name:                "Method Not Allowed",
+			method:              "DELETE",
+			path:                "/api/weather",
+			expectedStatus:      http.StatusMethodNotAllowed,
+			expectedContentType: "application/problem+json",
+			expectedAllow:       "GET, HEAD",
+			expectedBody: `{"type": "/problems/method-not-allowed", "title": "Method Not Allowed", "status": 405,
+				"detail": "DELETE is not allowed on /api/weather", "instance": "/api/weather", "requestId": "req-1"}`,
+		},
+		{
+			name:                "Web Page",
+			method:              "GET",
+			path:                "/weather/unknown",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        "<html></html>",
+		},
+		{
+			name:                "Web Page Method Not Allowed",
+			method:              "DELETE",
+			path:                "/weather",
+			expectedStatus:      http.StatusMethodNotAllowed,
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedAllow:       "GET, HEAD",
+			expectedBody:        "Method Not Allowed\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(tc.method, tc.path, nil)
+			request.Header.Set(RequestIDHeader, "req-1")
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+			if contentType := recorder.Header().Get("Content-Type"); contentType != tc.expectedContentType {
+				t.Errorf("Expected content type %q, got %q", tc.expectedContentType, contentType)
+			}
+			if allow := recorder.Header().Get("Allow"); allow != tc.expectedAllow {
+				t.Errorf("Expected Allow %q, got %q", tc.expectedAllow, allow)
+			}
+			body := recorder.Body.String()
+			if json.Valid([]byte(tc.expectedBody)) {
+				var buf1, buf2 bytes.Buffer
+				json.Compact(&buf1, []byte(tc.expectedBody))
+				json.Compact(&buf2, []byte(body))
+				body, tc.expectedBody = buf2.String(), buf1.String()
+			}
+			if body != tc.expectedBody {
+				t.Errorf("Expected body %q, got %q", tc.expectedBody, body)
+			}
+		})
+	}
+}
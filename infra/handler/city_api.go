@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/softstone1/woc/app"
+	"github.com/softstone1/woc/domain"
+)
+
+// maxBodyBytes bounds the size of JSON request bodies.
+const maxBodyBytes = 1 << 16
+
+type Cities struct {
+	cityService app.CityService
+}
+
+func NewCities(cityService app.CityService) *Cities {
+	return &Cities{
+		cityService: cityService,
+	}
+}
+
+// ListCitiesAPI returns all cities ordered by name.
+func (h *Cities) ListCitiesAPI(w http.ResponseWriter, r *http.Request) {
+	cities, err := h.cityService.GetAllCities()
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	if cities == nil {
+		cities = []domain.City{}
+	}
+	respondWithJSON(w, http.StatusOK, cities)
+}
+
+// SearchCitiesAPI returns the cities matching the q query parameter, best match first. Names and
+// aliases are matched by prefix and with a few typos tolerated; limit caps the number of results.
+func (h *Cities) SearchCitiesAPI(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		respondWithProblem(w, r, invalidInputClass, "missing q query parameter")
+		return
+	}
+	limit, err := parseLimit(r, domain.DefaultCitySearchLimit, domain.MaxCitySearchLimit)
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	cities, err := h.cityService.SearchCities(query, limit)
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, cities)
+}
+
+// NearbyCitiesAPI returns the cities near the lat and lon query parameters, nearest first, with
+// their distance in kilometres. With radius it returns every city within that many kilometres,
+// otherwise the limit nearest cities.
+func (h *Cities) NearbyCitiesAPI(w http.ResponseWriter, r *http.Request) {
+	latitude, err := parseDegrees(r, "lat")
+	if err != nil {
+		respondWithProblem(w, r, invalidInputClass, err.Error())
+		return
+	}
+	longitude, err := parseDegrees(r, "lon")
+	if err != nil {
+		respondWithProblem(w, r, invalidInputClass, err.Error())
+		return
+	}
+	var cities []domain.NearbyCity
+	if v := r.URL.Query().Get("radius"); v != "" {
+		if r.URL.Query().Has("limit") {
+			respondWithProblem(w, r, invalidInputClass, "radius and limit cannot be combined")
+			return
+		}
+		radius, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			respondWithProblem(w, r, invalidInputClass, fmt.Sprintf("radius must be a number of kilometres, got %q", v))
+			return
+		}
+		cities, err = h.cityService.CitiesWithinRadius(latitude, longitude, radius)
+		if err != nil {
+			respondWithAPIError(w, r, err)
+			return
+		}
+	} else {
+		limit, err := parseLimit(r, domain.DefaultNearestCities, domain.MaxNearestCities)
+		if err != nil {
+			respondWithAPIError(w, r, err)
+			return
+		}
+		cities, err = h.cityService.NearestCities(latitude, longitude, limit)
+		if err != nil {
+			respondWithAPIError(w, r, err)
+			return
+		}
+	}
+	if cities == nil {
+		cities = []domain.NearbyCity{}
+	}
+	respondWithJSON(w, http.StatusOK, cities)
+}
+
+// GetCityAPI returns the city with the ID or name in the path.
+func (h *Cities) GetCityAPI(w http.ResponseWriter, r *http.Request) {
+	city, err := h.cityService.GetCity(r.PathValue("name"))
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, city)
+}
+
+// CreateCityAPI adds the city in the request body and responds with its location.
+func (h *Cities) CreateCityAPI(w http.ResponseWriter, r *http.Request) {
+	var city domain.City
+	if err := decodeJSONBody(w, r, &city); err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	created, err := h.cityService.CreateCity(city)
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/api/cities/"+url.PathEscape(created.ID))
+	respondWithJSON(w, http.StatusCreated, created)
+}
+
+// UpdateCityAPI replaces the city with the ID or name in the path with the request body.
+// A body without a name or ID keeps the current one, another name renames the city.
+func (h *Cities) UpdateCityAPI(w http.ResponseWriter, r *http.Request) {
+	var city domain.City
+	if err := decodeJSONBody(w, r, &city); err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	updated, err := h.cityService.UpdateCity(r.PathValue("name"), city)
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, updated)
+}
+
+// DeleteCityAPI removes the city with the ID or name in the path.
+func (h *Cities) DeleteCityAPI(w http.ResponseWriter, r *http.Request) {
+	if err := h.cityService.DeleteCity(r.PathValue("name")); err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseLimit reads the optional limit query parameter, falling back to defaultLimit.
+func parseLimit(r *http.Request, defaultLimit, maxLimit int) (int, error) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultLimit, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxLimit {
+		return 0, fmt.Errorf("%w: limit must be an integer between 1 and %d", domain.ErrInvalidInput, maxLimit)
+	}
+	return n, nil
+}
+
+// decodeJSONBody decodes a single JSON object from the request body into v, rejecting unknown fields.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return fmt.Errorf("%w: request body must be at most %d bytes", domain.ErrInvalidInput, maxBytesErr.Limit)
+		}
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return err
+		}
+		return fmt.Errorf("%w: invalid JSON body: %w", domain.ErrInvalidInput, err)
+	}
+	if decoder.More() {
+		return fmt.Errorf("%w: request body must contain a single JSON object", domain.ErrInvalidInput)
+	}
+	return nil
+}
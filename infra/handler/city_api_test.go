@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+
+	"github.com/softstone1/woc/app"
+	"github.com/softstone1/woc/domain"
+)
+
+func TestCitiesAPI(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCityService := app.NewMockCityService(mockCtrl)
+	citiesHandler := NewCities(mockCityService)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/cities", citiesHandler.ListCitiesAPI)
+	mux.HandleFunc("GET /api/cities/search", citiesHandler.SearchCitiesAPI)
+	mux.HandleFunc("GET /api/cities/nearby", citiesHandler.NearbyCitiesAPI)
+	mux.HandleFunc("GET /api/cities/{name}", citiesHandler.GetCityAPI)
+	mux.HandleFunc("POST /api/cities", citiesHandler.CreateCityAPI)
+	mux.HandleFunc("PUT /api/cities/{name}", citiesHandler.UpdateCityAPI)
+	mux.HandleFunc("DELETE /api/cities/{name}", citiesHandler.DeleteCityAPI)
+
+	berlin := domain.City{Name: "Berlin", Latitude: 52.5200, Longitude: 13.4050}
+	// stored is berlin as the service returns it, with its ID and details
+	stored := domain.City{ID: "berlin-de", Name: "Berlin", CountryCode: "DE", Latitude: 52.5200, Longitude: 13.4050, Timezone: "Europe/Berlin"}
+	storedJSON := `{"id": "berlin-de", "name": "Berlin", "countryCode": "DE", "latitude": 52.52, "longitude": 13.405, "timezone": "Europe/Berlin"}`
+
+	tests := []struct {
+		name             string
+		method           string
+		target           string
+		body             string
+		setupMock        func()
+		expectedStatus   int
+		expectedLocation string
+		expectedBody     string
+	}{
+		{
+			name:   "List Cities",
+			method: "GET",
+			target: "/api/cities",
+			setupMock: func() {
+				mockCityService.EXPECT().GetAllCities().Return([]domain.City{stored}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[" + storedJSON + "]",
+		},
+		{
+			name:   "List No Cities",
+			method: "GET",
+			target: "/api/cities",
+			setupMock: func() {
+				mockCityService.EXPECT().GetAllCities().Return(nil, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[]`,
+		},
+		{
+			name:   "Nearest Cities",
+			method: "GET",
+			target: "/api/cities/nearby?lat=52.4&lon=13.1&limit=1",
+			setupMock: func() {
+				mockCityService.EXPECT().NearestCities(52.4, 13.1, 1).Return([]domain.NearbyCity{{City: stored, DistanceKm: 25.307}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"city": ` + storedJSON + `, "distanceKm": 25.307}]`,
+		},
+		{
+			name:   "Nearest Cities With Default Limit",
+			method: "GET",
+			target: "/api/cities/nearby?lat=52.4&lon=13.1",
+			setupMock: func() {
+				mockCityService.EXPECT().NearestCities(52.4, 13.1, domain.DefaultNearestCities).Return(nil, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[]`,
+		},
+		{
+			name:   "Cities Within Radius",
+			method: "GET",
+			target: "/api/cities/nearby?lat=52.4&lon=13.1&radius=30",
+			setupMock: func() {
+				mockCityService.EXPECT().CitiesWithinRadius(52.4, 13.1, 30.0).Return([]domain.NearbyCity{{City: stored, DistanceKm: 25.307}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"city": ` + storedJSON + `, "distanceKm": 25.307}]`,
+		},
+		{
+			name:           "Nearby Cities Without Longitude",
+			method:         "GET",
+			target:         "/api/cities/nearby?lat=52.4",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "missing lon query parameter", "instance": "/api/cities/nearby?lat=52.4"}`,
+		},
+		{
+			name:           "Nearby Cities With Radius And Limit",
+			method:         "GET",
+			target:         "/api/cities/nearby?lat=52.4&lon=13.1&radius=30&limit=2",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "radius and limit cannot be combined", "instance": "/api/cities/nearby?lat=52.4\u0026lon=13.1\u0026radius=30\u0026limit=2"}`,
+		},
+		{
+			name:           "Nearby Cities With Invalid Radius",
+			method:         "GET",
+			target:         "/api/cities/nearby?lat=52.4&lon=13.1&radius=far",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "radius must be a number of kilometres, got \"far\"", "instance": "/api/cities/nearby?lat=52.4\u0026lon=13.1\u0026radius=far"}`,
+		},
+		{
+			name:   "Search Cities",
+			method: "GET",
+			target: "/api/cities/search?q=nyc&limit=3",
+			setupMock: func() {
+				mockCityService.EXPECT().SearchCities("nyc", 3).
+					Return([]domain.City{{ID: "new-york-us", Name: "New York", Latitude: 40.7128, Longitude: -74.006, Aliases: []string{"NYC"}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"id": "new-york-us", "name": "New York", "latitude": 40.7128, "longitude": -74.006, "aliases": ["NYC"]}]`,
+		},
+		{
+			name:   "Search Cities With Default Limit",
+			method: "GET",
+			target: "/api/cities/search?q=xyz",
+			setupMock: func() {
+				mockCityService.EXPECT().SearchCities("xyz", domain.DefaultCitySearchLimit).Return([]domain.City{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[]`,
+		},
+		{
+			name:           "Search Cities Without Query",
+			method:         "GET",
+			target:         "/api/cities/search",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400,
+				"detail": "missing q query parameter", "instance": "/api/cities/search"}`,
+		},
+		{
+			name:           "Search Cities With Invalid Limit",
+			method:         "GET",
+			target:         "/api/cities/search?q=lon&limit=0",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400,
+				"detail": "invalid input: limit must be an integer between 1 and 50", "instance": "/api/cities/search?q=lon\u0026limit=0"}`,
+		},
+		{
+			name:   "Get City With Escaped Name",
+			method: "GET",
+			target: "/api/cities/New%20York",
+			setupMock: func() {
+				mockCityService.EXPECT().GetCity("New York").Return(&domain.City{ID: "new-york-us", Name: "New York", Latitude: 40.7128, Longitude: -74.0060}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id": "new-york-us", "name": "New York", "latitude": 40.7128, "longitude": -74.006}`,
+		},
+		{
+			name:   "Get Unknown City",
+			method: "GET",
+			target: "/api/cities/Unknown",
+			setupMock: func() {
+				mockCityService.EXPECT().GetCity("Unknown").Return(nil, domain.ErrCityNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody: `{"type": "/problems/not-found", "title": "Resource not found", "status": 404,
+				"detail": "city not found", "instance": "/api/cities/Unknown"}`,
+		},
+		{
+			name:   "Create City",
+			method: "POST",
+			target: "/api/cities",
+			body:   `{"name": "Berlin", "latitude": 52.52, "longitude": 13.405}`,
+			setupMock: func() {
+				mockCityService.EXPECT().CreateCity(berlin).Return(&stored, nil)
+			},
+			expectedStatus:   http.StatusCreated,
+			expectedLocation: "/api/cities/berlin-de",
+			expectedBody:     storedJSON,
+		},
+		{
+			name:   "Create Existing City",
+			method: "POST",
+			target: "/api/cities",
+			body:   `{"name": "Berlin", "latitude": 52.52, "longitude": 13.405}`,
+			setupMock: func() {
+				mockCityService.EXPECT().CreateCity(berlin).Return(nil, domain.ErrCityExists)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody: `{"type": "/problems/conflict", "title": "Resource already exists", "status": 409,
+				"detail": "city already exists", "instance": "/api/cities"}`,
+		},
+		{
+			name:           "Create City With Unknown Field",
+			method:         "POST",
+			target:         "/api/cities",
+			body:           `{"name": "Berlin", "lat": "52.5200"}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400,
+				"detail": "invalid input: invalid JSON body: json: unknown field \"lat\"", "instance": "/api/cities"}`,
+		},
+		{
+			name:           "Create City With Trailing Data",
+			method:         "POST",
+			target:         "/api/cities",
+			body:           `{"name": "Berlin", "latitude": 52.52, "longitude": 13.405} {}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400,
+				"detail": "invalid input: request body must contain a single JSON object", "instance": "/api/cities"}`,
+		},
+		{
+			name:   "Update City",
+			method: "PUT",
+			target: "/api/cities/berlin-de",
+			body:   `{"countryCode": "DE", "latitude": "52.52", "longitude": 13.405, "timezone": "Europe/Berlin"}`,
+			setupMock: func() {
+				mockCityService.EXPECT().UpdateCity("berlin-de", domain.City{CountryCode: "DE", Latitude: 52.52, Longitude: 13.405, Timezone: "Europe/Berlin"}).
+					Return(&stored, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   storedJSON,
+		},
+		{
+			name:   "Delete City",
+			method: "DELETE",
+			target: "/api/cities/Berlin",
+			setupMock: func() {
+				mockCityService.EXPECT().DeleteCity("Berlin").Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMock()
+
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+			if location := recorder.Header().Get("Location"); location != tc.expectedLocation {
+				t.Errorf("Expected Location %q, got %q", tc.expectedLocation, location)
+			}
+			if tc.expectedBody == "" {
+				if recorder.Body.Len() != 0 {
+					t.Errorf("Expected empty body, got %q", recorder.Body.String())
+				}
+				return
+			}
+			var buf1, buf2 bytes.Buffer
+			json.Compact(&buf1, []byte(tc.expectedBody))
+			json.Compact(&buf2, recorder.Body.Bytes())
+
+			if buf1.String() != buf2.String() {
+				t.Errorf("Expected body %q, got %q", buf1.String(), buf2.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/softstone1/woc/domain"
+	"github.com/softstone1/woc/infra/importer"
+)
+
+// maxImportBytes bounds the size of city import files, small enough for the upload and the import
+// to finish within the server's read and handler timeouts.
+const maxImportBytes = 8 << 20
+
+// ImportCitiesAPI imports the cities of the file in the request body and returns a summary.
+// The format query parameter is geonames, csv or geojson. min_population skips smaller cities
+// and dry_run=true only reports what would be imported. The CSV columns or GeoJSON properties
+// holding the city fields are named with name_field, latitude_field, longitude_field,
+// country_code_field, admin1_field, elevation_field, timezone_field, population_field and aliases_field.
+// Files over maxImportBytes, such as the GeoNames dumps, are imported with the woc cities import
+// command instead.
+func (h *Cities) ImportCitiesAPI(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("format") == "" {
+		respondWithProblem(w, r, invalidInputClass, "missing format query parameter")
+		return
+	}
+	format, err := importer.ParseFormat(q.Get("format"))
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	opts, err := parseImportOptions(r)
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	records, err := importer.Read(http.MaxBytesReader(w, r.Body, maxImportBytes), format, parseImportFields(r), nil)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			err = fmt.Errorf("%w: request body must be at most %d bytes", domain.ErrInvalidInput, maxBytesErr.Limit)
+		}
+		respondWithAPIError(w, r, err)
+		return
+	}
+	report, err := h.cityService.ImportCities(records, opts)
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+// parseImportOptions reads the optional min_population and dry_run query parameters.
+func parseImportOptions(r *http.Request) (domain.ImportOptions, error) {
+	var opts domain.ImportOptions
+	q := r.URL.Query()
+	if v := q.Get("min_population"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("%w: min_population must be a non-negative integer", domain.ErrInvalidInput)
+		}
+		opts.MinPopulation = n
+	}
+	if v := q.Get("dry_run"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("%w: dry_run must be true or false", domain.ErrInvalidInput)
+		}
+		opts.DryRun = dryRun
+	}
+	return opts, nil
+}
+
+// parseImportFields reads the field name query parameters, falling back to importer.DefaultFields.
+func parseImportFields(r *http.Request) importer.Fields {
+	fields := importer.DefaultFields
+	q := r.URL.Query()
+	for param, field := range map[string]*string{
+		"name_field":         &fields.Name,
+		"latitude_field":     &fields.Latitude,
+		"longitude_field":    &fields.Longitude,
+		"country_code_field": &fields.CountryCode,
+		"admin1_field":       &fields.Admin1,
+		"elevation_field":    &fields.Elevation,
+		"timezone_field":     &fields.Timezone,
+		"population_field":   &fields.Population,
+		"aliases_field":      &fields.Aliases,
+	} {
+		if v := q.Get(param); v != "" {
+			*field = v
+		}
+	}
+	return fields
+}
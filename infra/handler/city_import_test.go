@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+
+	"github.com/softstone1/woc/app"
+	"github.com/softstone1/woc/domain"
+)
+
+func TestImportCitiesAPI(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCityService := app.NewMockCityService(mockCtrl)
+	citiesHandler := NewCities(mockCityService)
+
+	berlin := domain.CityRecord{Position: "line 2", City: domain.City{Name: "Berlin", Latitude: 52.52, Longitude: 13.405, Population: 3644826}}
+
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "Import CSV",
+			query: "format=csv&min_population=15000",
+			body:  "name,latitude,longitude,population\nBerlin,52.52,13.405,3644826\n",
+			setupMock: func() {
+				mockCityService.EXPECT().ImportCities([]domain.CityRecord{berlin}, domain.ImportOptions{MinPopulation: 15000}).
+					Return(&domain.ImportReport{Read: 1, Imported: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"read": 1, "dryRun": false, "imported": 1, "existing": 0, "duplicates": 0,
+				"belowMinPopulation": 0, "invalid": 0}`,
+		},
+		{
+			name:  "Dry Run With Field Mapping",
+			query: "format=csv&dry_run=true&name_field=city&latitude_field=lat&longitude_field=lng&population_field=pop",
+			body:  "city,lat,lng,pop\nBerlin,52.52,13.405,3644826\n",
+			setupMock: func() {
+				mockCityService.EXPECT().ImportCities([]domain.CityRecord{berlin}, domain.ImportOptions{DryRun: true}).
+					Return(&domain.ImportReport{Read: 1, DryRun: true, Existing: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"read": 1, "dryRun": true, "imported": 0, "existing": 1, "duplicates": 0,
+				"belowMinPopulation": 0, "invalid": 0}`,
+		},
+		{
+			name:           "Missing Format",
+			query:          "",
+			body:           "name,latitude,longitude\n",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "missing format query parameter", "instance": "/api/cities/import"}`,
+		},
+		{
+			name:           "Unknown Format",
+			query:          "format=xml",
+			body:           "<cities/>",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "invalid input: unknown import format \"xml\", use geonames, csv or geojson", "instance": "/api/cities/import?format=xml"}`,
+		},
+		{
+			name:           "Invalid Minimum Population",
+			query:          "format=csv&min_population=-1",
+			body:           "name,latitude,longitude\n",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "invalid input: min_population must be a non-negative integer", "instance": "/api/cities/import?format=csv\u0026min_population=-1"}`,
+		},
+		{
+			name:           "Missing Column",
+			query:          "format=csv",
+			body:           "name,lat,lng\nBerlin,52.52,13.405\n",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "invalid input: csv header has no \"latitude\" column", "instance": "/api/cities/import?format=csv"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			target := "/api/cities/import"
+			if tc.query != "" {
+				target += "?" + tc.query
+			}
+			request := httptest.NewRequest("POST", target, strings.NewReader(tc.body))
+			recorder := httptest.NewRecorder()
+			tc.setupMock()
+
+			citiesHandler.ImportCitiesAPI(recorder, request)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+			var buf1, buf2 bytes.Buffer
+			json.Compact(&buf1, []byte(tc.expectedBody))
+			json.Compact(&buf2, recorder.Body.Bytes())
+			if buf1.String() != buf2.String() {
+				t.Errorf("Expected body %q, got %q", buf1.String(), buf2.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/softstone1/woc/domain"
+)
+
+// SearchCities is the handler for the city type-ahead. It renders the cities matching the city
+// query parameter as datalist options, and no options for an empty query.
+func (h *Cities) SearchCities(w http.ResponseWriter, r *http.Request) {
+	var cities []domain.City
+	if query := r.URL.Query().Get("city"); domain.NormalizeCityName(query) != "" {
+		var err error
+		cities, err = h.cityService.SearchCities(query, domain.DefaultCitySearchLimit)
+		if err != nil {
+			respondWithError(w, r, err)
+			return
+		}
+	}
+	if err := tmpl.ExecuteTemplate(w, "city_options.gohtml", cities); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	"github.com/softstone1/woc/app"
+	"github.com/softstone1/woc/domain"
+)
+
+func TestSearchCities(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCityService := app.NewMockCityService(mockCtrl)
+	citiesHandler := NewCities(mockCityService)
+	now = func() time.Time { return time.Date(2024, 5, 1, 14, 5, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "Matching Cities",
+			query: "?city=lon",
+			mockSetup: func() {
+				mockCityService.EXPECT().SearchCities("lon", domain.DefaultCitySearchLimit).
+					Return([]domain.City{{Name: "London", CountryCode: "GB", Timezone: "Europe/London"}, {Name: "Londonderry"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "\n<option value=\"London, GB\">\U0001F1EC\U0001F1E7 London, GB · Wed 15:05 BST</option>\n<option value=\"Londonderry\">Londonderry</option>\n",
+		},
+		{
+			name:  "Names Are Escaped",
+			query: "?city=st",
+			mockSetup: func() {
+				mockCityService.EXPECT().SearchCities("st", domain.DefaultCitySearchLimit).
+					Return([]domain.City{{Name: `St. John's "Town"`}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "\n<option value=\"St. John&#39;s &#34;Town&#34;\">St. John&#39;s &#34;Town&#34;</option>\n",
+		},
+		{
+			name:           "Empty Query",
+			query:          "?city=+",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "\n",
+		},
+		{
+			name:  "Search Failure",
+			query: "?city=lon",
+			mockSetup: func() {
+				mockCityService.EXPECT().SearchCities("lon", domain.DefaultCitySearchLimit).Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Internal Server Error\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mockSetup != nil {
+				tc.mockSetup()
+			}
+			req := httptest.NewRequest("GET", "/cities/search"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			citiesHandler.SearchCities(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, rr.Code)
+			}
+			if body := rr.Body.String(); body != tc.expectedBody {
+				t.Errorf("Expected body %q, got %q", tc.expectedBody, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/softstone1/woc/domain"
+)
+
+// problemContentType is the media type of RFC 7807 problem details.
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document returned by the /api routes.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// errorClass describes how a class of errors is reported over HTTP.
+type errorClass struct {
+	status      int
+	problemType string
+	title       string
+}
+
+var (
+	notFoundClass            = errorClass{http.StatusNotFound, "/problems/not-found", "Resource not found"}
+	invalidInputClass        = errorClass{http.StatusBadRequest, "/problems/invalid-input", "Invalid input"}
+	conflictClass            = errorClass{http.StatusConflict, "/problems/conflict", "Resource already exists"}
+	unauthorizedClass        = errorClass{http.StatusUnauthorized, "/problems/unauthorized", "Admin credential required"}
+	forbiddenClass           = errorClass{http.StatusForbidden, "/problems/forbidden", "Operation disabled"}
+	rateLimitedClass         = errorClass{http.StatusTooManyRequests, "/problems/rate-limited", "Rate limited by weather provider"}
+	upstreamTimeoutClass     = errorClass{http.StatusGatewayTimeout, "/problems/upstream-timeout", "Weather provider timed out"}
+	upstreamUnavailableClass = errorClass{http.StatusBadGateway, "/problems/upstream-unavailable", "Weather provider unavailable"}
+	providerUnavailableClass = errorClass{http.StatusServiceUnavailable, "/problems/provider-unavailable", "Weather provider temporarily disabled"}
+	internalClass            = errorClass{http.StatusInternalServerError, "about:blank", http.StatusText(http.StatusInternalServerError)}
+)
+
+// classifyError maps domain errors to the way they are reported to the caller.
+func classifyError(err error) errorClass {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return notFoundClass
+	case errors.Is(err, domain.ErrInvalidInput):
+		return invalidInputClass
+	case errors.Is(err, domain.ErrAlreadyExists):
+		return conflictClass
+	case errors.Is(err, domain.ErrRateLimited):
+		return rateLimitedClass
+	case errors.Is(err, domain.ErrUpstreamTimeout), errors.Is(err, context.DeadlineExceeded):
+		return upstreamTimeoutClass
+	case errors.Is(err, domain.ErrUpstreamUnavailable):
+		return upstreamUnavailableClass
+	case errors.Is(err, domain.ErrProviderUnavailable):
+		return providerUnavailableClass
+	default:
+		return internalClass
+	}
+}
+
+// errorStatus maps domain errors to the HTTP status code returned to the caller.
+func errorStatus(err error) int {
+	return classifyError(err).status
+}
+
+// errorDetail returns the detail reported to the caller for err. Errors about the request itself
+// are reported as they are; upstream and internal errors can carry provider URLs and other
+// internals, so they are logged with the request id and reported by their class title alone.
+func errorDetail(r *http.Request, class errorClass, err error) string {
+	switch class {
+	case notFoundClass, invalidInputClass, conflictClass:
+		return err.Error()
+	}
+	slog.Error("request failed", "requestId", RequestIDFromContext(r.Context()), "method", r.Method, "path", r.URL.Path, "error", err)
+	return ""
+}
+
+// reportProviderErrors sets the message of each failed provider of a consensus to its class title.
+// The errors themselves can carry provider URLs, so they are logged with the request id instead.
+func reportProviderErrors(r *http.Request, providers []domain.ProviderWeather) {
+	for i, p := range providers {
+		if p.Err == nil {
+			continue
+		}
+		slog.Warn("weather provider failed", "requestId", RequestIDFromContext(r.Context()), "provider", p.Provider, "error", p.Err)
+		providers[i].Error = classifyError(p.Err).title
+	}
+}
+
+// respondWithError writes err with the status code matching its domain error.
+func respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	class := classifyError(err)
+	http.Error(w, cmp.Or(errorDetail(r, class, err), class.title), class.status)
+}
+
+// respondWithAPIError writes err as problem details with the status code matching its domain error.
+func respondWithAPIError(w http.ResponseWriter, r *http.Request, err error) {
+	class := classifyError(err)
+	respondWithProblem(w, r, class, errorDetail(r, class, err))
+}
+
+// respondWithProblem writes a problem details document for the request.
+func respondWithProblem(w http.ResponseWriter, r *http.Request, class errorClass, detail string) {
+	problem := Problem{
+		Type:      class.problemType,
+		Title:     class.title,
+		Status:    class.status,
+		Detail:    detail,
+		Instance:  r.URL.RequestURI(),
+		RequestID: RequestIDFromContext(r.Context()),
+	}
+	response, err := json.Marshal(problem)
+	if err != nil {
+		http.Error(w, "failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(class.status)
+	w.Write(response)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/softstone1/woc/domain"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "City Not Found", err: domain.ErrCityNotFound, expectedStatus: http.StatusNotFound},
+		{name: "Invalid Input", err: fmt.Errorf("%w: bad latitude", domain.ErrInvalidInput), expectedStatus: http.StatusBadRequest},
+		{name: "Rate Limited", err: fmt.Errorf("%w: status 429", domain.ErrRateLimited), expectedStatus: http.StatusTooManyRequests},
+		{name: "Upstream Unavailable", err: fmt.Errorf("%w: status 503", domain.ErrUpstreamUnavailable), expectedStatus: http.StatusBadGateway},
+		{name: "Upstream Timeout", err: fmt.Errorf("%w: %w", domain.ErrUpstreamTimeout, context.DeadlineExceeded), expectedStatus: http.StatusGatewayTimeout},
+		{name: "Provider Unavailable", err: fmt.Errorf("%w: circuit breaker open", domain.ErrProviderUnavailable), expectedStatus: http.StatusServiceUnavailable},
+		{name: "Deadline Exceeded", err: fmt.Errorf("aborted: %w", context.DeadlineExceeded), expectedStatus: http.StatusGatewayTimeout},
+		{name: "Unclassified", err: errors.New("boom"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if status := errorStatus(tc.err); status != tc.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, status)
+			}
+		})
+	}
+}
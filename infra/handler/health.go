@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/softstone1/woc/domain"
+)
+
+const (
+	healthStatusOK       = "ok"
+	healthStatusDegraded = "degraded"
+)
+
+type Health struct {
+	components map[string]domain.HealthReporter
+	cacheStats func() any
+}
+
+// HealthOption configures optional parts of the health report.
+type HealthOption func(*Health)
+
+// WithCacheStats reports the counters returned by stats under "cache".
+func WithCacheStats(stats func() any) HealthOption {
+	return func(h *Health) {
+		h.cacheStats = stats
+	}
+}
+
+// NewHealth creates a health handler reporting on the given named components.
+func NewHealth(components map[string]domain.HealthReporter, opts ...HealthOption) *Health {
+	h := &Health{
+		components: components,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+type healthResponse struct {
+	Status     string                   `json:"status"`
+	Components map[string]domain.Health `json:"components"`
+	Cache      any                      `json:"cache,omitempty"`
+}
+
+// GetHealth reports the health of every component. It is a liveness check and responds with 200
+// even when a component is unhealthy: an open circuit breaker is served from the cache or another
+// provider, and restarting the process would not bring the provider back.
+func (h *Health) GetHealth(w http.ResponseWriter, r *http.Request) {
+	resp := healthResponse{
+		Status:     healthStatusOK,
+		Components: make(map[string]domain.Health, len(h.components)),
+	}
+	for name, component := range h.components {
+		health := component.Health()
+		if !health.Healthy {
+			resp.Status = healthStatusDegraded
+		}
+		resp.Components[name] = health
+	}
+	if h.cacheStats != nil {
+		resp.Cache = h.cacheStats()
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/softstone1/woc/domain"
+)
+
+type staticHealth domain.Health
+
+func (s staticHealth) Health() domain.Health {
+	return domain.Health(s)
+}
+
+func TestGetHealth(t *testing.T) {
+	tests := []struct {
+		name           string
+		components     map[string]domain.HealthReporter
+		opts           []HealthOption
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "No Components",
+			components:     map[string]domain.HealthReporter{},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"ok","components":{}}`,
+		},
+		{
+			name: "Healthy",
+			components: map[string]domain.HealthReporter{
+				"weatherProvider": staticHealth{Healthy: true, State: "closed"},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"ok","components":{"weatherProvider":{"healthy":true,"state":"closed"}}}`,
+		},
+		{
+			name: "Degraded",
+			components: map[string]domain.HealthReporter{
+				"weatherProvider": staticHealth{Healthy: false, State: "open", Detail: "open since 2024-05-01T12:00:00Z"},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"degraded","components":{"weatherProvider":{"healthy":false,"state":"open","detail":"open since 2024-05-01T12:00:00Z"}}}`,
+		},
+		{
+			name: "Cache Stats",
+			components: map[string]domain.HealthReporter{
+				"weatherProvider": staticHealth{Healthy: true, State: "closed"},
+			},
+			opts: []HealthOption{WithCacheStats(func() any {
+				return map[string]int{"hits": 3, "misses": 1}
+			})},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"ok","components":{"weatherProvider":{"healthy":true,"state":"closed"}},"cache":{"hits":3,"misses":1}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/health", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			NewHealth(tc.components, tc.opts...).GetHealth(rr, req)
+
+			if status := rr.Code; status != tc.expectedStatus {
+				t.Errorf("Expected status code %v, but got %v", tc.expectedStatus, status)
+			}
+			if body := strings.TrimSpace(rr.Body.String()); body != tc.expectedBody {
+				t.Errorf("Expected body %v, but got %v", tc.expectedBody, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithRequestID(t *testing.T) {
+	tests := []struct {
+		name       string
+		incomingID string
+	}{
+		{name: "Generated Request ID", incomingID: ""},
+		{name: "Propagated Request ID", incomingID: "abc-123"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var seenID string
+			handler := WithRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seenID = RequestIDFromContext(r.Context())
+				respondWithProblem(w, r, notFoundClass, "city not found")
+			}))
+
+			req, err := http.NewRequest("GET", "/api/weather?city=Atlantis", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.incomingID != "" {
+				req.Header.Set(RequestIDHeader, tc.incomingID)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if seenID == "" {
+				t.Fatalf("Expected request id in context, got none")
+			}
+			if tc.incomingID != "" && seenID != tc.incomingID {
+				t.Errorf("Expected request id %q, got %q", tc.incomingID, seenID)
+			}
+			if header := rr.Header().Get(RequestIDHeader); header != seenID {
+				t.Errorf("Expected response header %q, got %q", seenID, header)
+			}
+
+			var problem Problem
+			if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("Unexpected error decoding problem: %v", err)
+			}
+			expected := Problem{
+				Type:      "/problems/not-found",
+				Title:     "Resource not found",
+				Status:    http.StatusNotFound,
+				Detail:    "city not found",
+				Instance:  "/api/weather?city=Atlantis",
+				RequestID: seenID,
+			}
+			if problem != expected {
+				t.Errorf("Expected problem %+v, got %+v", expected, problem)
+			}
+		})
+	}
+}
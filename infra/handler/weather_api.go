@@ -3,10 +3,23 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/softstone1/woc/app"
+	"github.com/softstone1/woc/domain"
+)
+
+const (
+	// defaultForecastHours is the forecast horizon used when the hours query parameter is omitted
+	defaultForecastHours = 48
+	// defaultForecastDays is the forecast horizon used when the days query parameter is omitted
+	defaultForecastDays = 7
+	// consensusMode merges the current weather of all providers
+	consensusMode = "consensus"
 )
 
 type Weather struct {
@@ -20,22 +33,228 @@ func NewWeather(weatherService app.WeatherService) *Weather {
 }
 
 // GetWeatherByCityAPI returns weather information for a given city.
+// With mode=consensus it returns the weather merged across all providers, aggregated with the
+// median or, with aggregation=mean, the mean, together with each provider's reading.
 func (h *Weather) GetWeatherByCityAPI(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), time.Second*10)
 	defer cancel()
-	cityName := r.URL.Query().Get("city")
+	cityName := cityQuery(r)
 	if cityName == "" {
-		http.Error(w, "missing city query parameter", http.StatusBadRequest)
+		respondWithProblem(w, r, invalidInputClass, "missing city query parameter")
 		return
 	}
-	weather, err := h.weatherService.GetWeatherByCity(ctx, cityName)
+	units, err := parseUnits(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondWithAPIError(w, r, err)
+		return
+	}
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "":
+	case consensusMode:
+		aggregation, err := domain.ParseAggregation(r.URL.Query().Get("aggregation"))
+		if err != nil {
+			respondWithAPIError(w, r, err)
+			return
+		}
+		consensus, err := h.weatherService.GetConsensusWeatherByCity(ctx, cityName, units, aggregation)
+		if err != nil {
+			respondWithAPIError(w, r, err)
+			return
+		}
+		reportProviderErrors(r, consensus.Providers)
+		respondWithJSON(w, http.StatusOK, consensus)
+		return
+	default:
+		respondWithProblem(w, r, invalidInputClass, fmt.Sprintf("unknown mode %q", mode))
+		return
+	}
+	weather, err := h.weatherService.GetWeatherByCity(ctx, cityName, units)
+	if err != nil {
+		respondWithAPIError(w, r, err)
 		return
 	}
 	respondWithJSON(w, http.StatusOK, weather)
 }
 
+// GetForecastByCityAPI returns the hourly forecast for a given city.
+func (h *Weather) GetForecastByCityAPI(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*10)
+	defer cancel()
+	cityName := cityQuery(r)
+	if cityName == "" {
+		respondWithProblem(w, r, invalidInputClass, "missing city query parameter")
+		return
+	}
+	hours := defaultForecastHours
+	if v := r.URL.Query().Get("hours"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > domain.MaxForecastHours {
+			respondWithProblem(w, r, invalidInputClass, fmt.Sprintf("hours must be an integer between 1 and %d", domain.MaxForecastHours))
+			return
+		}
+		hours = n
+	}
+	units, err := parseUnits(r)
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	forecast, err := h.weatherService.GetForecastByCity(ctx, cityName, hours, units)
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, forecast)
+}
+
+// GetDailyForecastByCityAPI returns the daily forecast summaries for a given city.
+func (h *Weather) GetDailyForecastByCityAPI(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*10)
+	defer cancel()
+	cityName := cityQuery(r)
+	if cityName == "" {
+		respondWithProblem(w, r, invalidInputClass, "missing city query parameter")
+		return
+	}
+	days, err := parseDays(r)
+	if err != nil {
+		respondWithProblem(w, r, invalidInputClass, err.Error())
+		return
+	}
+	units, err := parseUnits(r)
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	forecast, err := h.weatherService.GetDailyForecastByCity(ctx, cityName, days, units)
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, forecast)
+}
+
+// GetWeatherByCoordinatesAPI returns the current weather at the lat and lon query parameters, which
+// need not be a known city, together with the nearest known city.
+func (h *Weather) GetWeatherByCoordinatesAPI(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*10)
+	defer cancel()
+	latitude, err := parseDegrees(r, "lat")
+	if err != nil {
+		respondWithProblem(w, r, invalidInputClass, err.Error())
+		return
+	}
+	longitude, err := parseDegrees(r, "lon")
+	if err != nil {
+		respondWithProblem(w, r, invalidInputClass, err.Error())
+		return
+	}
+	units, err := parseUnits(r)
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	weather, err := h.weatherService.GetWeatherByCoordinates(ctx, latitude, longitude, units)
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, weather)
+}
+
+// batchRequest is the body of a POST batch weather request.
+type batchRequest struct {
+	Cities []string `json:"cities"`
+}
+
+// batchResult is the outcome for one city of a batch, its weather or the problem that kept it from
+// being fetched.
+type batchResult struct {
+	City    string          `json:"city"`
+	Weather *domain.Weather `json:"weather,omitempty"`
+	Error   *Problem        `json:"error,omitempty"`
+}
+
+// GetWeatherByCitiesAPI returns the current weather of every city query parameter, as in
+// city=Tokyo&city=London, or for a POST of the cities in a {"cities": [...]} body. Every city gets
+// its own result or error, so a city that cannot be found or fetched does not fail the batch.
+func (h *Weather) GetWeatherByCitiesAPI(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*10)
+	defer cancel()
+	cityNames := r.URL.Query()["city"]
+	if r.Method == http.MethodPost {
+		var body batchRequest
+		if err := decodeJSONBody(w, r, &body); err != nil {
+			respondWithAPIError(w, r, err)
+			return
+		}
+		cityNames = body.Cities
+	}
+	units, err := parseUnits(r)
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	results, err := h.weatherService.GetWeatherByCities(ctx, cityNames, units)
+	if err != nil {
+		respondWithAPIError(w, r, err)
+		return
+	}
+	response := make([]batchResult, len(results))
+	for i, result := range results {
+		response[i] = batchResult{City: result.City, Weather: result.Weather}
+		if result.Err != nil {
+			class := classifyError(result.Err)
+			response[i].Error = &Problem{Type: class.problemType, Title: class.title, Status: class.status, Detail: errorDetail(r, class, result.Err)}
+		}
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// cityQuery reads the city query parameter, narrowed down to a country by the optional country
+// query parameter, so city=Paris&country=US asks for "Paris, US".
+func cityQuery(r *http.Request) string {
+	city := r.URL.Query().Get("city")
+	if country := strings.TrimSpace(r.URL.Query().Get("country")); city != "" && country != "" {
+		return city + ", " + country
+	}
+	return city
+}
+
+// parseDegrees reads a required coordinate query parameter in decimal degrees.
+// The range is validated by the service.
+func parseDegrees(r *http.Request, name string) (float64, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, fmt.Errorf("missing %s query parameter", name)
+	}
+	degrees, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a decimal number, got %q", name, v)
+	}
+	return degrees, nil
+}
+
+// parseDays reads the optional days query parameter, falling back to defaultForecastDays.
+func parseDays(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("days")
+	if v == "" {
+		return defaultForecastDays, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > domain.MaxForecastDays {
+		return 0, fmt.Errorf("days must be an integer between 1 and %d", domain.MaxForecastDays)
+	}
+	return n, nil
+}
+
+// parseUnits reads the units query parameter ("metric" or "imperial") and the optional
+// temperature_unit, wind_speed_unit and precipitation_unit overrides.
+func parseUnits(r *http.Request) (domain.Units, error) {
+	q := r.URL.Query()
+	return domain.ParseUnits(q.Get("units"), q.Get("temperature_unit"), q.Get("wind_speed_unit"), q.Get("precipitation_unit"))
+}
+
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, err := json.Marshal(payload)
 	if err != nil {
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"go.uber.org/mock/gomock"
 
@@ -24,6 +27,7 @@ func TestGetWeatherByCityAPI(t *testing.T) {
 	tests := []struct {
 		name           string
 		city           string
+		extraQuery     string
 		setupMock      func()
 		expectedStatus int
 		expectedBody   string
@@ -33,35 +37,187 @@ func TestGetWeatherByCityAPI(t *testing.T) {
 			city: "London",
 			setupMock: func() {
 				mockWeatherService.EXPECT().
-					GetWeatherByCity(gomock.Any(), "London").
-					Return(&domain.Weather{City: "London", Temperature: 6.7, WindSpeed: 5.5}, nil)
+					GetWeatherByCity(gomock.Any(), "London", domain.Metric).
+					Return(&domain.Weather{
+						City:                "London",
+						Time:                time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC),
+						Temperature:         6.7,
+						WindSpeed:           5.5,
+						ApparentTemperature: 4.2,
+						Humidity:            81,
+						Precipitation:       0.3,
+						SurfacePressure:     1010.1,
+						SeaLevelPressure:    1012.5,
+						CloudCover:          90,
+						WindDirection:       240,
+						WindGusts:           14.8,
+						WeatherCode:         61,
+						Description:         "Slight rain",
+						Icon:                "rain",
+						Units:               domain.Metric.Labels(),
+					}, nil)
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"city": "London", "temperature": 6.7, "windSpeed": 5.5}`,
+			expectedBody: `{"city": "London", "time": "2024-05-01T14:00:00Z", "temperature": 6.7, "windSpeed": 5.5,
+				"apparentTemperature": 4.2, "humidity": 81, "precipitation": 0.3, "surfacePressure": 1010.1, "seaLevelPressure": 1012.5,
+				"cloudCover": 90, "windDirection": 240, "windGusts": 14.8,
+				"weatherCode": 61, "description": "Slight rain", "icon": "rain",
+				"units": {"temperature": "°C", "windSpeed": "km/h", "precipitation": "mm"}}`,
+		},
+		{
+			name:       "Imperial Units",
+			city:       "London",
+			extraQuery: "&units=imperial&wind_speed_unit=kn",
+			setupMock: func() {
+				units := domain.Units{Temperature: domain.Fahrenheit, WindSpeed: domain.Knots, Precipitation: domain.Inches}
+				mockWeatherService.EXPECT().
+					GetWeatherByCity(gomock.Any(), "London", units).
+					Return(&domain.Weather{City: "London", Time: time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC), Temperature: 44.1, WindSpeed: 3, Units: units.Labels()}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"city": "London", "time": "2024-05-01T14:00:00Z", "temperature": 44.1, "windSpeed": 3,
+				"apparentTemperature": 0, "humidity": 0, "precipitation": 0, "surfacePressure": 0, "seaLevelPressure": 0,
+				"cloudCover": 0, "windDirection": 0, "windGusts": 0,
+				"weatherCode": 0, "description": "", "icon": "",
+				"units": {"temperature": "°F", "windSpeed": "kn", "precipitation": "in"}}`,
+		},
+		{
+			name: "Stale Weather",
+			city: "London",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetWeatherByCity(gomock.Any(), "London", domain.Metric).
+					Return(&domain.Weather{City: "London", Time: time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC), Temperature: 6.7, Units: domain.Metric.Labels(), Stale: true, AgeSeconds: 420}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"city": "London", "time": "2024-05-01T14:00:00Z", "temperature": 6.7, "windSpeed": 0,
+				"apparentTemperature": 0, "humidity": 0, "precipitation": 0, "surfacePressure": 0, "seaLevelPressure": 0,
+				"cloudCover": 0, "windDirection": 0, "windGusts": 0,
+				"weatherCode": 0, "description": "", "icon": "",
+				"units": {"temperature": "°C", "windSpeed": "km/h", "precipitation": "mm"},
+				"stale": true, "ageSeconds": 420}`,
+		},
+		{
+			name:           "Invalid Units",
+			city:           "London",
+			extraQuery:     "&units=kelvin",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "invalid input: unknown unit system \"kelvin\"", "instance": "/api/weather?city=London\u0026units=kelvin"}`,
 		},
 		{
 			name:           "City Missing",
 			city:           "",
 			setupMock:      func() {},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   "missing city query parameter\n",
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "missing city query parameter", "instance": "/api/weather?city="}`,
 		},
 		{
 			name: "Service Error",
 			city: "Unknown",
 			setupMock: func() {
 				mockWeatherService.EXPECT().
-					GetWeatherByCity(gomock.Any(), "Unknown").
+					GetWeatherByCity(gomock.Any(), "Unknown", domain.Metric).
 					Return(nil, errors.New("city not found"))
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   "city not found\n",
+			expectedBody:   `{"type": "about:blank", "title": "Internal Server Error", "status": 500, "instance": "/api/weather?city=Unknown"}`,
+		},
+		{
+			name: "City Not Found",
+			city: "Atlantis",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetWeatherByCity(gomock.Any(), "Atlantis", domain.Metric).
+					Return(nil, domain.ErrCityNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type": "/problems/not-found", "title": "Resource not found", "status": 404, "detail": "city not found", "instance": "/api/weather?city=Atlantis"}`,
+		},
+		{
+			name: "Upstream Unavailable",
+			city: "London",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetWeatherByCity(gomock.Any(), "London", domain.Metric).
+					Return(nil, fmt.Errorf("%w: open-meteo returned status 503", domain.ErrUpstreamUnavailable))
+			},
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `{"type": "/problems/upstream-unavailable", "title": "Weather provider unavailable", "status": 502, "instance": "/api/weather?city=London"}`,
+		},
+		{
+			name:       "Consensus Mode",
+			city:       "London",
+			extraQuery: "&mode=consensus&aggregation=mean",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetConsensusWeatherByCity(gomock.Any(), "London", domain.Metric, domain.AggregateMean).
+					Return(&domain.ConsensusWeather{
+						Aggregation: domain.AggregateMean,
+						Weather:     domain.Weather{City: "London", Time: time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC), Temperature: 7, Units: domain.Metric.Labels()},
+						Spread:      map[string]domain.FieldSpread{"temperature": {Min: 6, Max: 8, Range: 2, StdDev: 1}},
+						Providers: []domain.ProviderWeather{
+							{Provider: "open-meteo", Weather: &domain.Weather{City: "London", Time: time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC), Temperature: 6, Units: domain.Metric.Labels()}},
+							{Provider: "met-norway", Weather: &domain.Weather{City: "London", Time: time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC), Temperature: 8, Units: domain.Metric.Labels()}},
+							{Provider: "backup", Err: fmt.Errorf("%w: Get \"https://backup.example.com/v1/current?key=secret\": connection refused", domain.ErrUpstreamUnavailable)},
+						},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"aggregation": "mean",
+				"weather": {"city": "London", "time": "2024-05-01T14:00:00Z", "temperature": 7, "windSpeed": 0,
+					"apparentTemperature": 0, "humidity": 0, "precipitation": 0, "surfacePressure": 0, "seaLevelPressure": 0,
+					"cloudCover": 0, "windDirection": 0, "windGusts": 0, "weatherCode": 0, "description": "", "icon": "",
+					"units": {"temperature": "°C", "windSpeed": "km/h", "precipitation": "mm"}},
+				"spread": {"temperature": {"min": 6, "max": 8, "range": 2, "stdDev": 1}},
+				"providers": [
+					{"provider": "open-meteo", "weather": {"city": "London", "time": "2024-05-01T14:00:00Z", "temperature": 6, "windSpeed": 0,
+						"apparentTemperature": 0, "humidity": 0, "precipitation": 0, "surfacePressure": 0, "seaLevelPressure": 0,
+						"cloudCover": 0, "windDirection": 0, "windGusts": 0, "weatherCode": 0, "description": "", "icon": "",
+						"units": {"temperature": "°C", "windSpeed": "km/h", "precipitation": "mm"}}},
+					{"provider": "met-norway", "weather": {"city": "London", "time": "2024-05-01T14:00:00Z", "temperature": 8, "windSpeed": 0,
+						"apparentTemperature": 0, "humidity": 0, "precipitation": 0, "surfacePressure": 0, "seaLevelPressure": 0,
+						"cloudCover": 0, "windDirection": 0, "windGusts": 0, "weatherCode": 0, "description": "", "icon": "",
+						"units": {"temperature": "°C", "windSpeed": "km/h", "precipitation": "mm"}}},
+					{"provider": "backup", "error": "Weather provider unavailable"}]}`,
+		},
+		{
+			name:       "City Narrowed Down By Country",
+			city:       "Paris",
+			extraQuery: "&country=US",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetWeatherByCity(gomock.Any(), "Paris, US", domain.Metric).
+					Return(&domain.Weather{City: "Paris", CountryCode: "US", Timezone: "America/Chicago",
+						Time: time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC), Temperature: 24.1, Units: domain.Metric.Labels()}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"city": "Paris", "countryCode": "US", "timezone": "America/Chicago", "time": "2024-05-01T14:00:00Z", "temperature": 24.1,
+				"windSpeed": 0, "apparentTemperature": 0, "humidity": 0, "precipitation": 0, "surfacePressure": 0, "seaLevelPressure": 0,
+				"cloudCover": 0, "windDirection": 0, "windGusts": 0, "weatherCode": 0, "description": "", "icon": "",
+				"units": {"temperature": "°C", "windSpeed": "km/h", "precipitation": "mm"}}`,
+		},
+		{
+			name:           "Unknown Mode",
+			city:           "London",
+			extraQuery:     "&mode=ensemble",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "unknown mode \"ensemble\"", "instance": "/api/weather?city=London\u0026mode=ensemble"}`,
+		},
+		{
+			name:           "Unknown Aggregation",
+			city:           "London",
+			extraQuery:     "&mode=consensus&aggregation=mode",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "invalid input: unknown aggregation \"mode\"", "instance": "/api/weather?city=London\u0026mode=consensus\u0026aggregation=mode"}`,
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			request, err := http.NewRequest("GET", "/weather?city="+tc.city, nil)
+			request, err := http.NewRequest("GET", "/api/weather?city="+tc.city+tc.extraQuery, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -76,6 +232,13 @@ func TestGetWeatherByCityAPI(t *testing.T) {
 			if recorder.Code != tc.expectedStatus {
 				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, recorder.Code)
 			}
+			expectedContentType := "application/json"
+			if tc.expectedStatus != http.StatusOK {
+				expectedContentType = "application/problem+json"
+			}
+			if contentType := recorder.Header().Get("Content-Type"); contentType != expectedContentType {
+				t.Errorf("Expected content type %q, got %q", expectedContentType, contentType)
+			}
 
 			// Normalize JSON strings by removing spaces for comparison
 			expectedBody := tc.expectedBody
@@ -85,6 +248,432 @@ func TestGetWeatherByCityAPI(t *testing.T) {
 			json.Compact(&buf1, []byte(expectedBody))
 			json.Compact(&buf2, []byte(actualBody))
 
+			if buf1.String() != buf2.String() {
+				t.Errorf("Expected body %q, got %q", buf1.String(), buf2.String())
+			}
+			// provider errors are logged, not returned
+			if strings.Contains(actualBody, "backup.example.com") {
+				t.Errorf("Expected no provider URL in the body, got %q", actualBody)
+			}
+		})
+	}
+}
+
+func TestGetForecastByCityAPI(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWeatherService := app.NewMockWeatherService(mockCtrl)
+	weatherHandler := NewWeather(mockWeatherService)
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "Valid City With Hours",
+			query: "city=London&hours=2",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetForecastByCity(gomock.Any(), "London", 2, domain.Metric).
+					Return(&domain.Forecast{City: "London", Units: domain.Metric.Labels(), Hourly: []domain.HourlyWeather{
+						{Time: time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC), Temperature: 6.7, WindSpeed: 5.5},
+						{Time: time.Date(2024, 5, 1, 15, 0, 0, 0, time.UTC), Temperature: 7.1, WindSpeed: 4.9},
+					}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"city": "London", "units": {"temperature": "°C", "windSpeed": "km/h", "precipitation": "mm"}, "hourly": [
+				{"time": "2024-05-01T14:00:00Z", "temperature": 6.7, "windSpeed": 5.5},
+				{"time": "2024-05-01T15:00:00Z", "temperature": 7.1, "windSpeed": 4.9}
+			]}`,
+		},
+		{
+			name:  "Default Hours",
+			query: "city=London",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetForecastByCity(gomock.Any(), "London", defaultForecastHours, domain.Metric).
+					Return(&domain.Forecast{City: "London", Units: domain.Metric.Labels(), Hourly: []domain.HourlyWeather{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"city": "London", "units": {"temperature": "°C", "windSpeed": "km/h", "precipitation": "mm"}, "hourly": []}`,
+		},
+		{
+			name:           "City Missing",
+			query:          "hours=2",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "missing city query parameter", "instance": "/api/forecast?hours=2"}`,
+		},
+		{
+			name:           "Invalid Hours",
+			query:          "city=London&hours=abc",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "hours must be an integer between 1 and 360", "instance": "/api/forecast?city=London\u0026hours=abc"}`,
+		},
+		{
+			name:           "Hours Out Of Range",
+			query:          "city=London&hours=1000",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "hours must be an integer between 1 and 360", "instance": "/api/forecast?city=London\u0026hours=1000"}`,
+		},
+		{
+			name:  "Service Error",
+			query: "city=Unknown",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetForecastByCity(gomock.Any(), "Unknown", defaultForecastHours, domain.Metric).
+					Return(nil, errors.New("city not found"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"type": "about:blank", "title": "Internal Server Error", "status": 500, "instance": "/api/forecast?city=Unknown"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "/api/forecast?"+tc.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := httptest.NewRecorder()
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+
+			weatherHandler.GetForecastByCityAPI(recorder, request)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+			expectedContentType := "application/json"
+			if tc.expectedStatus != http.StatusOK {
+				expectedContentType = "application/problem+json"
+			}
+			if contentType := recorder.Header().Get("Content-Type"); contentType != expectedContentType {
+				t.Errorf("Expected content type %q, got %q", expectedContentType, contentType)
+			}
+
+			var buf1, buf2 bytes.Buffer
+			json.Compact(&buf1, []byte(tc.expectedBody))
+			json.Compact(&buf2, recorder.Body.Bytes())
+
+			if buf1.String() != buf2.String() {
+				t.Errorf("Expected body %q, got %q", buf1.String(), buf2.String())
+			}
+		})
+	}
+}
+
+func TestGetDailyForecastByCityAPI(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWeatherService := app.NewMockWeatherService(mockCtrl)
+	weatherHandler := NewWeather(mockWeatherService)
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "Valid City With Days",
+			query: "city=London&days=1&units=imperial",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetDailyForecastByCity(gomock.Any(), "London", 1, domain.Imperial).
+					Return(&domain.DailyForecast{City: "London", Units: domain.Imperial.Labels(), Daily: []domain.DailyWeather{{
+						Date:                        time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+						WeatherCode:                 61,
+						Description:                 "Slight rain",
+						Icon:                        "rain",
+						TemperatureMin:              8.1,
+						TemperatureMax:              14.3,
+						PrecipitationSum:            2.4,
+						PrecipitationProbabilityMax: 70,
+						Sunrise:                     time.Date(2024, 5, 1, 5, 30, 0, 0, time.UTC),
+						Sunset:                      time.Date(2024, 5, 1, 20, 22, 0, 0, time.UTC),
+					}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"city": "London", "units": {"temperature": "°F", "windSpeed": "mph", "precipitation": "in"}, "daily": [{
+				"date": "2024-05-01T00:00:00Z", "weatherCode": 61, "description": "Slight rain", "icon": "rain",
+				"temperatureMin": 8.1, "temperatureMax": 14.3,
+				"precipitationSum": 2.4, "precipitationProbabilityMax": 70,
+				"sunrise": "2024-05-01T05:30:00Z", "sunset": "2024-05-01T20:22:00Z"
+			}]}`,
+		},
+		{
+			name:  "Default Days",
+			query: "city=London",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetDailyForecastByCity(gomock.Any(), "London", defaultForecastDays, domain.Metric).
+					Return(&domain.DailyForecast{City: "London", Units: domain.Metric.Labels(), Daily: []domain.DailyWeather{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"city": "London", "units": {"temperature": "°C", "windSpeed": "km/h", "precipitation": "mm"}, "daily": []}`,
+		},
+		{
+			name:           "City Missing",
+			query:          "days=3",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "missing city query parameter", "instance": "/api/forecast/daily?days=3"}`,
+		},
+		{
+			name:           "Days Out Of Range",
+			query:          "city=London&days=17",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "days must be an integer between 1 and 16", "instance": "/api/forecast/daily?city=London\u0026days=17"}`,
+		},
+		{
+			name:  "Service Error",
+			query: "city=Unknown",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetDailyForecastByCity(gomock.Any(), "Unknown", defaultForecastDays, domain.Metric).
+					Return(nil, errors.New("city not found"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"type": "about:blank", "title": "Internal Server Error", "status": 500, "instance": "/api/forecast/daily?city=Unknown"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "/api/forecast/daily?"+tc.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := httptest.NewRecorder()
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+
+			weatherHandler.GetDailyForecastByCityAPI(recorder, request)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+			expectedContentType := "application/json"
+			if tc.expectedStatus != http.StatusOK {
+				expectedContentType = "application/problem+json"
+			}
+			if contentType := recorder.Header().Get("Content-Type"); contentType != expectedContentType {
+				t.Errorf("Expected content type %q, got %q", expectedContentType, contentType)
+			}
+
+			var buf1, buf2 bytes.Buffer
+			json.Compact(&buf1, []byte(tc.expectedBody))
+			json.Compact(&buf2, recorder.Body.Bytes())
+
+			if buf1.String() != buf2.String() {
+				t.Errorf("Expected body %q, got %q", buf1.String(), buf2.String())
+			}
+		})
+	}
+}
+
+func TestGetWeatherByCoordinatesAPI(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWeatherService := app.NewMockWeatherService(mockCtrl)
+	weatherHandler := NewWeather(mockWeatherService)
+	elevation := 43.0
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "Valid Coordinates",
+			query: "lat=48.9&lon=2.35",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetWeatherByCoordinates(gomock.Any(), 48.9, 2.35, domain.Metric).
+					Return(&domain.LocationWeather{
+						Latitude:    48.9,
+						Longitude:   2.35,
+						NearestCity: &domain.NearbyCity{City: domain.City{ID: "paris-ile-de-france-fr", Name: "Paris", Latitude: 48.8566, Longitude: 2.3522}, DistanceKm: 4.829},
+						Weather: &domain.Weather{City: "48.9,2.35", Timezone: "Europe/Paris", Elevation: &elevation,
+							Time: time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC), Temperature: 18.2, Units: domain.Metric.Labels()},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"latitude": 48.9, "longitude": 2.35,
+				"nearestCity": {"city": {"id": "paris-ile-de-france-fr", "name": "Paris", "latitude": 48.8566, "longitude": 2.3522}, "distanceKm": 4.829},
+				"weather": {"city": "48.9,2.35", "timezone": "Europe/Paris", "elevation": 43,
+					"time": "2024-05-01T14:00:00Z", "temperature": 18.2, "windSpeed": 0,
+					"apparentTemperature": 0, "humidity": 0, "precipitation": 0, "surfacePressure": 0, "seaLevelPressure": 0,
+					"cloudCover": 0, "windDirection": 0, "windGusts": 0, "weatherCode": 0, "description": "", "icon": "",
+					"units": {"temperature": "°C", "windSpeed": "km/h", "precipitation": "mm"}}}`,
+		},
+		{
+			name:           "Latitude Missing",
+			query:          "lon=2.35",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "missing lat query parameter", "instance": "/api/weather/coords?lon=2.35"}`,
+		},
+		{
+			name:           "Longitude Not A Number",
+			query:          "lat=48.9&lon=east",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "lon must be a decimal number, got \"east\"", "instance": "/api/weather/coords?lat=48.9\u0026lon=east"}`,
+		},
+		{
+			name:  "Coordinates Out Of Range",
+			query: "lat=95&lon=2.35",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetWeatherByCoordinates(gomock.Any(), 95.0, 2.35, domain.Metric).
+					Return(nil, fmt.Errorf("%w: latitude must be between -90 and 90, got 95", domain.ErrInvalidInput))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400, "detail": "invalid input: latitude must be between -90 and 90, got 95", "instance": "/api/weather/coords?lat=95\u0026lon=2.35"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "/api/weather/coords?"+tc.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := httptest.NewRecorder()
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+
+			weatherHandler.GetWeatherByCoordinatesAPI(recorder, request)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+
+			var buf1, buf2 bytes.Buffer
+			json.Compact(&buf1, []byte(tc.expectedBody))
+			json.Compact(&buf2, recorder.Body.Bytes())
+
+			if buf1.String() != buf2.String() {
+				t.Errorf("Expected body %q, got %q", buf1.String(), buf2.String())
+			}
+		})
+	}
+}
+
+func TestGetWeatherByCitiesAPI(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWeatherService := app.NewMockWeatherService(mockCtrl)
+	weatherHandler := NewWeather(mockWeatherService)
+
+	tokyo := &domain.Weather{City: "Tokyo", Time: time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC), Temperature: 21, Units: domain.Metric.Labels()}
+	tokyoJSON := `{"city": "Tokyo", "time": "2024-05-01T14:00:00Z", "temperature": 21, "windSpeed": 0,
+		"apparentTemperature": 0, "humidity": 0, "precipitation": 0, "surfacePressure": 0, "seaLevelPressure": 0,
+		"cloudCover": 0, "windDirection": 0, "windGusts": 0, "weatherCode": 0, "description": "", "icon": "",
+		"units": {"temperature": "°C", "windSpeed": "km/h", "precipitation": "mm"}}`
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "Cities In The Query",
+			method: "GET",
+			target: "/api/weather/batch?city=Tokyo&city=Atlantis&city=London",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetWeatherByCities(gomock.Any(), []string{"Tokyo", "Atlantis", "London"}, domain.Metric).
+					Return([]domain.CityWeather{
+						{City: "Tokyo", Weather: tokyo},
+						{City: "Atlantis", Err: domain.ErrCityNotFound},
+						{City: "London", Err: fmt.Errorf("%w: open-meteo returned status 503", domain.ErrUpstreamUnavailable)},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `[{"city": "Tokyo", "weather": ` + tokyoJSON + `},
+				{"city": "Atlantis", "error": {"type": "/problems/not-found", "title": "Resource not found", "status": 404, "detail": "city not found"}},
+				{"city": "London", "error": {"type": "/problems/upstream-unavailable", "title": "Weather provider unavailable", "status": 502}}]`,
+		},
+		{
+			name:   "Cities In The Body",
+			method: "POST",
+			target: "/api/weather/batch?units=imperial",
+			body:   `{"cities": ["Tokyo"]}`,
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetWeatherByCities(gomock.Any(), []string{"Tokyo"}, domain.Imperial).
+					Return([]domain.CityWeather{{City: "Tokyo", Weather: tokyo}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"city": "Tokyo", "weather": ` + tokyoJSON + `}]`,
+		},
+		{
+			name:   "No Cities",
+			method: "GET",
+			target: "/api/weather/batch",
+			setupMock: func() {
+				mockWeatherService.EXPECT().
+					GetWeatherByCities(gomock.Any(), nil, domain.Metric).
+					Return(nil, fmt.Errorf("%w: between 1 and 50 cities must be given, got 0", domain.ErrInvalidInput))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400,
+				"detail": "invalid input: between 1 and 50 cities must be given, got 0", "instance": "/api/weather/batch"}`,
+		},
+		{
+			name:           "Malformed Body",
+			method:         "POST",
+			target:         "/api/weather/batch",
+			body:           `{"cities": "Tokyo"}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"type": "/problems/invalid-input", "title": "Invalid input", "status": 400,
+				"detail": "invalid input: invalid JSON body: json: cannot unmarshal string into Go struct field batchRequest.cities of type []string", "instance": "/api/weather/batch"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMock()
+
+			request := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			recorder := httptest.NewRecorder()
+			weatherHandler.GetWeatherByCitiesAPI(recorder, request)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+
+			var buf1, buf2 bytes.Buffer
+			json.Compact(&buf1, []byte(tc.expectedBody))
+			json.Compact(&buf2, recorder.Body.Bytes())
+
 			if buf1.String() != buf2.String() {
 				t.Errorf("Expected body %q, got %q", buf1.String(), buf2.String())
 			}
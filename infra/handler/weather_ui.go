@@ -3,24 +3,37 @@ package handler
 import (
 	"context"
 	"embed"
+	"html/template"
 	"net/http"
-	"text/template"
 	"time"
+
+	"github.com/softstone1/woc/domain"
 )
+
 var (
 	//go:embed templates/*.gohtml
-	FS embed.FS
-	tmpl = template.Must(template.ParseFS(FS, "templates/*.gohtml"))
+	FS   embed.FS
+	tmpl = template.Must(template.New("").Funcs(template.FuncMap{
+		"flag":      domain.CountryFlag,
+		"localTime": localTime,
+	}).ParseFS(FS, "templates/*.gohtml"))
+	// now returns the current time, the local time of a city is shown relative to it
+	now = time.Now
 )
 
-// Home is the handler for the home page
-func (h *Weather) Home(w http.ResponseWriter, r *http.Request) {
-	cities, err := h.weatherService.GetAllCities()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+// localTime returns the current time in the IANA time zone, such as "Mon 15:04 CEST", or an empty
+// string if the zone is unknown.
+func localTime(timezone string) string {
+	location, ok := domain.City{Timezone: timezone}.Location()
+	if !ok {
+		return ""
 	}
-	if err := tmpl.ExecuteTemplate(w, "home.gohtml", cities); err != nil {
+	return now().In(location).Format("Mon 15:04 MST")
+}
+
+// Home is the handler for the home page. Cities are suggested as the user types, see Cities.SearchCities.
+func (h *Weather) Home(w http.ResponseWriter, r *http.Request) {
+	if err := tmpl.ExecuteTemplate(w, "home.gohtml", nil); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
@@ -30,17 +43,52 @@ func (h *Weather) GetWeatherByCity(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), time.Second*10)
 	defer cancel()
 
-	cityName := r.URL.Query().Get("city")
+	cityName := cityQuery(r)
 	if cityName == "" {
 		http.Error(w, "missing city query parameter", http.StatusBadRequest)
 		return
 	}
-	weather, err := h.weatherService.GetWeatherByCity(ctx, cityName)
+	units, err := parseUnits(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondWithError(w, r, err)
+		return
+	}
+	weather, err := h.weatherService.GetWeatherByCity(ctx, cityName, units)
+	if err != nil {
+		respondWithError(w, r, err)
 		return
 	}
 	if err := tmpl.ExecuteTemplate(w, "weather.gohtml", weather); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// GetDailyForecastByCity is the handler for the daily forecast table
+func (h *Weather) GetDailyForecastByCity(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*10)
+	defer cancel()
+
+	cityName := cityQuery(r)
+	if cityName == "" {
+		http.Error(w, "missing city query parameter", http.StatusBadRequest)
+		return
+	}
+	days, err := parseDays(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	units, err := parseUnits(r)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+	forecast, err := h.weatherService.GetDailyForecastByCity(ctx, cityName, days, units)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+	if err := tmpl.ExecuteTemplate(w, "daily.gohtml", forecast); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/softstone1/woc/app"
 	"github.com/softstone1/woc/domain"
@@ -19,60 +20,219 @@ func TestHome(t *testing.T) {
 	mockWeatherService := app.NewMockWeatherService(mockCtrl)
 	weatherHandler := NewWeather(mockWeatherService)
 
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(weatherHandler.Home).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	// the city is picked with the type-ahead instead of a list of all cities
+	body := rr.Body.String()
+	for _, fragment := range []string{`id="city-select"`, `list="city-options"`, `hx-get="/cities/search"`} {
+		if !strings.Contains(body, fragment) {
+			t.Errorf("Expected body to contain %q, but it did not", fragment)
+		}
+	}
+}
+
+func TestGetWeatherByCity(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWeatherService := app.NewMockWeatherService(mockCtrl)
+	weatherHandler := NewWeather(mockWeatherService)
+	now = func() time.Time { return time.Date(2024, 5, 1, 14, 5, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
 	tests := []struct {
 		name           string
+		city           string
+		extraQuery     string
 		mockSetup      func()
 		expectedStatus int
 		expectedBody   string
 	}{
 		{
-			name: "Successful Home Load",
+			name: "Valid City Request",
+			city: "London",
 			mockSetup: func() {
-				mockWeatherService.EXPECT().GetAllCities().Return([]domain.City{
-					{
-						Name:      "New York",
-						Latitude:  "40.7128",
-						Longitude: "-74.0060",
-					},
-					{
-						Name:      "London",
-						Latitude:  "51.5074",
-						Longitude: "-0.1278",
-					},
-					{
-						Name:      "Paris",
-						Latitude:  "48.8566",
-						Longitude: "2.3522",
-					},
-					{
-						Name:      "Tokyo",
-						Latitude:  "35.6895",
-						Longitude: "139.6917",
-					},
-				}, nil)
+				mockWeatherService.EXPECT().
+					GetWeatherByCity(gomock.Any(), "London", domain.Metric).
+					Return(&domain.Weather{
+						City:                "London",
+						Time:                time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC),
+						Temperature:         15.5,
+						ApparentTemperature: 14.9,
+						Humidity:            62,
+						SurfacePressure:     1013.9,
+						SeaLevelPressure:    1016.2,
+						CloudCover:          25,
+						WindDirection:       270,
+						WindGusts:           9.4,
+						WeatherCode:         1,
+						Description:         "Mainly clear",
+						Icon:                "mostly-clear",
+						Units:               domain.Metric.Labels(),
+					}, nil)
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   "New York,London,Paris,Tokyo",
+			expectedBody: "<div>\n    <h2>Weather for London</h2>\n    <p class=\"icon-mostly-clear\">Mainly clear</p>\n" +
+				"    <p>As of: 2024-05-01 14:00 UTC</p>\n    <p>Temperature: 15.5°C (feels like 14.9°C)</p>\n" +
+				"    <p>Windspeed: 0 km/h from 270°, gusts 9.4 km/h</p>\n    <p>Humidity: 62%</p>\n" +
+				"    <p>Precipitation: 0 mm</p>\n    <p>Pressure: 1016.2 hPa</p>\n    <p>Cloud cover: 25%</p>\n</div>",
 		},
 		{
-			name: "Failed Home Load",
+			name:       "Imperial Units Request",
+			city:       "London",
+			extraQuery: "&units=imperial",
 			mockSetup: func() {
-				mockWeatherService.EXPECT().GetAllCities().Return(nil, errors.New("database error"))
+				mockWeatherService.EXPECT().
+					GetWeatherByCity(gomock.Any(), "London", domain.Imperial).
+					Return(&domain.Weather{
+						City:                "London",
+						Time:                time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC),
+						Temperature:         59.9,
+						WindSpeed:           4.1,
+						ApparentTemperature: 58.8,
+						Humidity:            62,
+						Precipitation:       0.02,
+						SurfacePressure:     1013.9,
+						SeaLevelPressure:    1016.2,
+						CloudCover:          25,
+						WindDirection:       270,
+						WindGusts:           5.8,
+						WeatherCode:         1,
+						Description:         "Mainly clear",
+						Icon:                "mostly-clear",
+						Units:               domain.Imperial.Labels(),
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: "<div>\n    <h2>Weather for London</h2>\n    <p class=\"icon-mostly-clear\">Mainly clear</p>\n" +
+				"    <p>As of: 2024-05-01 14:00 UTC</p>\n    <p>Temperature: 59.9°F (feels like 58.8°F)</p>\n" +
+				"    <p>Windspeed: 4.1 mph from 270°, gusts 5.8 mph</p>\n    <p>Humidity: 62%</p>\n" +
+				"    <p>Precipitation: 0.02 in</p>\n    <p>Pressure: 1016.2 hPa</p>\n    <p>Cloud cover: 25%</p>\n</div>",
+		},
+		{
+			name: "Stale Weather Request",
+			city: "London",
+			mockSetup: func() {
+				mockWeatherService.EXPECT().
+					GetWeatherByCity(gomock.Any(), "London", domain.Metric).
+					Return(&domain.Weather{
+						City:        "London",
+						Time:        time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC),
+						Temperature: 15.5,
+						Description: "Mainly clear",
+						Icon:        "mostly-clear",
+						Units:       domain.Metric.Labels(),
+						Stale:       true,
+						AgeSeconds:  420,
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: "<div>\n    <h2>Weather for London</h2>\n" +
+				"    <p class=\"badge badge-stale\">Stale: weather provider unavailable, last updated 420s ago</p>\n" +
+				"    <p class=\"icon-mostly-clear\">Mainly clear</p>\n" +
+				"    <p>As of: 2024-05-01 14:00 UTC</p>\n    <p>Temperature: 15.5°C (feels like 0°C)</p>\n" +
+				"    <p>Windspeed: 0 km/h from 0°, gusts 0 km/h</p>\n    <p>Humidity: 0%</p>\n" +
+				"    <p>Precipitation: 0 mm</p>\n    <p>Pressure: 0 hPa</p>\n    <p>Cloud cover: 0%</p>\n</div>",
+		},
+		{
+			name:       "City With Country And Time Zone",
+			city:       "Paris",
+			extraQuery: "&country=FR",
+			mockSetup: func() {
+				mockWeatherService.EXPECT().
+					GetWeatherByCity(gomock.Any(), "Paris, FR", domain.Metric).
+					Return(&domain.Weather{
+						City:        "Paris",
+						CountryCode: "FR",
+						Timezone:    "Europe/Paris",
+						Time:        time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC),
+						Temperature: 18.2,
+						Units:       domain.Metric.Labels(),
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: "<div>\n    <h2>Weather for \U0001F1EB\U0001F1F7 Paris</h2>\n    <p class=\"icon-\"></p>\n" +
+				"    <p>As of: 2024-05-01 14:00 UTC</p>\n    <p>Local time: Wed 16:05 CEST</p>\n" +
+				"    <p>Temperature: 18.2°C (feels like 0°C)</p>\n" +
+				"    <p>Windspeed: 0 km/h from 0°, gusts 0 km/h</p>\n    <p>Humidity: 0%</p>\n" +
+				"    <p>Precipitation: 0 mm</p>\n    <p>Pressure: 0 hPa</p>\n    <p>Cloud cover: 0%</p>\n</div>",
+		},
+		{
+			name: "City Name Is Escaped",
+			city: "Atlantis",
+			mockSetup: func() {
+				mockWeatherService.EXPECT().
+					GetWeatherByCity(gomock.Any(), "Atlantis", domain.Metric).
+					Return(&domain.Weather{
+						City:        "<script>alert(1)</script>",
+						Time:        time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC),
+						Temperature: 18.2,
+						Units:       domain.Metric.Labels(),
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: "<div>\n    <h2>Weather for &lt;script&gt;alert(1)&lt;/script&gt;</h2>\n    <p class=\"icon-\"></p>\n" +
+				"    <p>As of: 2024-05-01 14:00 UTC</p>\n" +
+				"    <p>Temperature: 18.2°C (feels like 0°C)</p>\n" +
+				"    <p>Windspeed: 0 km/h from 0°, gusts 0 km/h</p>\n    <p>Humidity: 0%</p>\n" +
+				"    <p>Precipitation: 0 mm</p>\n    <p>Pressure: 0 hPa</p>\n    <p>Cloud cover: 0%</p>\n</div>",
+		},
+		{
+			name:           "Invalid Units Request",
+			city:           "London",
+			extraQuery:     "&temperature_unit=kelvin",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid input: unknown temperature unit \"kelvin\"",
+		},
+		{
+			name:           "City Missing in Request",
+			city:           "",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "missing city query parameter",
+		},
+		{
+			name: "Weather Service Error",
+			city: "Unknown",
+			mockSetup: func() {
+				mockWeatherService.EXPECT().
+					GetWeatherByCity(gomock.Any(), "Unknown", domain.Metric).
+					Return(nil, errors.New("city not found"))
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   "database error",
+			expectedBody:   "Internal Server Error",
+		},
+		{
+			name: "City Not Found",
+			city: "Atlantis",
+			mockSetup: func() {
+				mockWeatherService.EXPECT().
+					GetWeatherByCity(gomock.Any(), "Atlantis", domain.Metric).
+					Return(nil, domain.ErrCityNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "city not found",
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			req, err := http.NewRequest("GET", "/", nil)
+			req, err := http.NewRequest("GET", "/weather?city="+tc.city+tc.extraQuery, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
 
 			rr := httptest.NewRecorder()
-			handler := http.HandlerFunc(weatherHandler.Home)
+			handler := http.HandlerFunc(weatherHandler.GetWeatherByCity)
 			if tc.mockSetup != nil {
 				tc.mockSetup()
 			}
@@ -82,27 +242,15 @@ func TestHome(t *testing.T) {
 				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, rr.Code)
 			}
 
-			//Only check for city names if the status code is 200 (OK)
-			if rr.Code == http.StatusOK {
-				// Check that the body contains the city names, rather than being equal to them
-				body := rr.Body.String()
-				for _, city := range []string{"New York", "London", "Paris", "Tokyo"} {
-					if !strings.Contains(body, city) {
-						t.Errorf("Expected body to contain %q, but it did not", city)
-					}
-				}
-			} else if rr.Code == http.StatusInternalServerError {
-				// Check for an error message in the body
-				body := rr.Body.String()
-				if !strings.Contains(body, "error") {
-					t.Errorf("Expected body to contain %q, but it did not", "error")
-				}
+			body := strings.TrimSuffix(rr.Body.String(), "\n")
+			if body != tc.expectedBody {
+				t.Errorf("Expected body %q, got %q", tc.expectedBody, body)
 			}
 		})
 	}
 }
 
-func TestGetWeatherByCity(t *testing.T) {
+func TestGetDailyForecastByCity(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
@@ -111,51 +259,72 @@ func TestGetWeatherByCity(t *testing.T) {
 
 	tests := []struct {
 		name           string
-		city           string
+		query          string
 		mockSetup      func()
 		expectedStatus int
-		expectedBody   string
+		expectedParts  []string
 	}{
 		{
-			name: "Valid City Request",
-			city: "London",
+			name:  "Valid City Request",
+			query: "city=London",
 			mockSetup: func() {
 				mockWeatherService.EXPECT().
-					GetWeatherByCity(gomock.Any(), "London").
-					Return(&domain.Weather{City: "London", Temperature: 15.5}, nil)
+					GetDailyForecastByCity(gomock.Any(), "London", defaultForecastDays, domain.Metric).
+					Return(&domain.DailyForecast{City: "London", Units: domain.Metric.Labels(), Daily: []domain.DailyWeather{{
+						Date:                        time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+						WeatherCode:                 61,
+						Description:                 "Slight rain",
+						Icon:                        "rain",
+						TemperatureMin:              8.1,
+						TemperatureMax:              14.3,
+						PrecipitationSum:            2.4,
+						PrecipitationProbabilityMax: 70,
+						Sunrise:                     time.Date(2024, 5, 1, 5, 30, 0, 0, time.UTC),
+						Sunset:                      time.Date(2024, 5, 1, 20, 22, 0, 0, time.UTC),
+					}}}, nil)
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   "<div>\n    <h2>Weather for London</h2>\n    <p>Temperature: 15.5°C</p>\n    <p>Windspeed: 0 km/h</p>\n</div>", 
+			expectedParts: []string{
+				"1-day forecast for London",
+				"<td>Wed 01 May</td>",
+				`<td class="icon-rain">Slight rain</td>`,
+				"<td>8.1°C</td>",
+				"<td>14.3°C</td>",
+				"<td>2.4 mm</td>",
+				"<td>70%</td>",
+				"<td>05:30</td>",
+				"<td>20:22</td>",
+			},
 		},
 		{
 			name:           "City Missing in Request",
-			city:           "",
+			query:          "",
 			mockSetup:      func() {},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   "missing city query parameter",
+			expectedParts:  []string{"missing city query parameter"},
 		},
 		{
-			name: "Weather Service Error",
-			city: "Unknown",
+			name:  "Weather Service Error",
+			query: "city=Unknown",
 			mockSetup: func() {
 				mockWeatherService.EXPECT().
-					GetWeatherByCity(gomock.Any(), "Unknown").
+					GetDailyForecastByCity(gomock.Any(), "Unknown", defaultForecastDays, domain.Metric).
 					Return(nil, errors.New("city not found"))
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   "city not found",
+			expectedParts:  []string{"Internal Server Error"},
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			req, err := http.NewRequest("GET", "/weather?city="+tc.city, nil)
+			req, err := http.NewRequest("GET", "/forecast/daily?"+tc.query, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
 
 			rr := httptest.NewRecorder()
-			handler := http.HandlerFunc(weatherHandler.GetWeatherByCity)
+			handler := http.HandlerFunc(weatherHandler.GetDailyForecastByCity)
 			if tc.mockSetup != nil {
 				tc.mockSetup()
 			}
@@ -165,9 +334,11 @@ func TestGetWeatherByCity(t *testing.T) {
 				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, rr.Code)
 			}
 
-			body := strings.TrimSuffix(rr.Body.String(), "\n")
-			if body != tc.expectedBody {
-				t.Errorf("Expected body %q, got %q", tc.expectedBody, body)
+			body := rr.Body.String()
+			for _, part := range tc.expectedParts {
+				if !strings.Contains(body, part) {
+					t.Errorf("Expected body to contain %q, but it did not: %q", part, body)
+				}
 			}
 		})
 	}
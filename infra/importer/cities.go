@@ -0,0 +1,459 @@
+// Package importer reads cities from GeoNames, CSV and GeoJSON files.
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/softstone1/woc/domain"
+)
+
+// Format is the format of a city file.
+type Format string
+
+const (
+	// FormatGeoNames is the tab-separated format of the GeoNames cities dumps, such as cities15000.txt.
+	FormatGeoNames Format = "geonames"
+	// FormatCSV is a CSV file with a header row, its columns named by Fields.
+	FormatCSV Format = "csv"
+	// FormatGeoJSON is a GeoJSON FeatureCollection of Point features, their properties named by Fields.
+	FormatGeoJSON Format = "geojson"
+)
+
+// aliasSeparator separates the aliases in a CSV column or a GeoJSON string property.
+const aliasSeparator = ";"
+
+// GeoNames columns, see https://download.geonames.org/export/dump/readme.txt
+const (
+	geoNamesName = 1 + iota
+	geoNamesASCIIName
+	geoNamesAlternateNames
+	geoNamesLatitude
+	geoNamesLongitude
+	geoNamesCountryCode = 8
+	geoNamesAdmin1      = 10
+	geoNamesPopulation  = 14
+	geoNamesElevation   = 15
+	// geoNamesDEM is the elevation of a digital elevation model, for places without a surveyed one
+	geoNamesDEM      = 16
+	geoNamesTimezone = 17
+	geoNamesColumns  = 19
+	// geoNamesNoData is the DEM value of places the model does not cover
+	geoNamesNoData = "-9999"
+	// geoNamesNoAdmin1 is the admin1 code of places outside any first-level administrative area
+	geoNamesNoAdmin1 = "00"
+)
+
+// Admin1Names maps GeoNames admin1 codes qualified by the country code, such as "FR.11", to the
+// names of the administrative areas, such as "Île-de-France".
+type Admin1Names map[string]string
+
+// ReadAdmin1Names reads the GeoNames admin1CodesASCII.txt dump, which names the administrative
+// areas the cities dumps only give codes for.
+func ReadAdmin1Names(r io.Reader) (Admin1Names, error) {
+	names := Admin1Names{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		columns := strings.Split(text, "\t")
+		if len(columns) < 2 || columns[0] == "" || strings.TrimSpace(columns[1]) == "" {
+			return nil, fmt.Errorf("%w: line %d of the admin1 codes is not a code followed by a name", domain.ErrInvalidInput, line)
+		}
+		names[columns[0]] = strings.TrimSpace(columns[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading admin1 codes: %w", err)
+	}
+	return names, nil
+}
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(name)); format {
+	case FormatGeoNames, FormatCSV, FormatGeoJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("%w: unknown import format %q, use %s, %s or %s", domain.ErrInvalidInput, name, FormatGeoNames, FormatCSV, FormatGeoJSON)
+}
+
+// FormatFromPath returns the format of a file by its extension: .txt and .tsv are GeoNames dumps,
+// .csv is CSV and .geojson and .json are GeoJSON.
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".txt", ".tsv":
+		return FormatGeoNames, nil
+	case ".csv":
+		return FormatCSV, nil
+	case ".geojson", ".json":
+		return FormatGeoJSON, nil
+	}
+	return "", fmt.Errorf("%w: cannot tell the import format of %s from its extension", domain.ErrInvalidInput, path)
+}
+
+// Fields names the CSV columns or GeoJSON properties holding the city fields. All but Name,
+// Latitude and Longitude are optional, an empty name or a missing column leaves them unset.
+type Fields struct {
+	Name        string
+	Latitude    string
+	Longitude   string
+	CountryCode string
+	Admin1      string
+	Elevation   string
+	Timezone    string
+	Population  string
+	Aliases     string
+}
+
+// DefaultFields are the column and property names used unless others are given.
+var DefaultFields = Fields{
+	Name:        "name",
+	Latitude:    "latitude",
+	Longitude:   "longitude",
+	CountryCode: "country_code",
+	Admin1:      "admin1",
+	Elevation:   "elevation",
+	Timezone:    "timezone",
+	Population:  "population",
+	Aliases:     "aliases",
+}
+
+// Read reads the cities of a file. A record that cannot be read is returned with its Err set,
+// a file that cannot be read at all fails with an error. The admin1 names, which may be nil, name
+// the administrative areas of a GeoNames dump.
+func Read(r io.Reader, format Format, fields Fields, admin1 Admin1Names) ([]domain.CityRecord, error) {
+	switch format {
+	case FormatGeoNames:
+		return readGeoNames(r, admin1)
+	case FormatCSV:
+		return readCSV(r, fields)
+	case FormatGeoJSON:
+		return readGeoJSON(r, fields)
+	}
+	return nil, fmt.Errorf("%w: unknown import format %q", domain.ErrInvalidInput, format)
+}
+
+func readGeoNames(r io.Reader, admin1 Admin1Names) ([]domain.CityRecord, error) {
+	var records []domain.CityRecord
+	scanner := bufio.NewScanner(r)
+	// the alternate names make some lines long
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		record := domain.CityRecord{Position: fmt.Sprintf("line %d", line)}
+		columns := strings.Split(text, "\t")
+		if len(columns) < geoNamesColumns {
+			record.Err = fmt.Errorf("%w: expected %d tab-separated columns, got %d", domain.ErrInvalidInput, geoNamesColumns, len(columns))
+			records = append(records, record)
+			continue
+		}
+		record.City.Name = columns[geoNamesName]
+		// the ASCII name lets cities with accents be typed on any keyboard; the alternate names are
+		// left out, many of them are shared by unrelated places
+		record.City.Aliases = []string{columns[geoNamesASCIIName]}
+		record.City.CountryCode = columns[geoNamesCountryCode]
+		// the admin1 column holds a code, such as "11" for Île-de-France, named by the admin1 dump.
+		// An unnamed code is not shown, it only tells apart the IDs of namesakes in a country.
+		code := columns[geoNamesAdmin1]
+		if name, ok := admin1[record.City.CountryCode+"."+code]; ok {
+			record.City.Admin1 = name
+		} else if code != "" && code != geoNamesNoAdmin1 {
+			record.City.ID = domain.NewCityID(record.City.Name, code, record.City.CountryCode)
+		}
+		record.City.Timezone = columns[geoNamesTimezone]
+		record.City.Latitude, record.Err = parseDegrees("latitude", columns[geoNamesLatitude])
+		if record.Err == nil {
+			record.City.Longitude, record.Err = parseDegrees("longitude", columns[geoNamesLongitude])
+		}
+		if record.Err == nil {
+			record.City.Population, record.Err = parsePopulation(columns[geoNamesPopulation])
+		}
+		if elevation := columns[geoNamesElevation]; record.Err == nil && elevation != "" {
+			record.City.Elevation, record.Err = parseElevation(elevation)
+		} else if dem := columns[geoNamesDEM]; record.Err == nil && dem != "" && dem != geoNamesNoData {
+			record.City.Elevation, record.Err = parseElevation(dem)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading geonames file: %w", err)
+	}
+	return records, nil
+}
+
+func readCSV(r io.Reader, fields Fields) ([]domain.CityRecord, error) {
+	reader := csv.NewReader(r)
+	// rows with missing columns are reported per record
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: csv file is empty", domain.ErrInvalidInput)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading csv header: %w", domain.ErrInvalidInput, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		if i == 0 {
+			// spreadsheet exports start with a byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	column := func(name string, required bool) (int, error) {
+		if i, ok := columns[strings.ToLower(name)]; ok && name != "" {
+			return i, nil
+		}
+		if required {
+			return 0, fmt.Errorf("%w: csv header has no %q column", domain.ErrInvalidInput, name)
+		}
+		return -1, nil
+	}
+	nameColumn, err := column(fields.Name, true)
+	if err != nil {
+		return nil, err
+	}
+	latitudeColumn, err := column(fields.Latitude, true)
+	if err != nil {
+		return nil, err
+	}
+	longitudeColumn, err := column(fields.Longitude, true)
+	if err != nil {
+		return nil, err
+	}
+	countryCodeColumn, _ := column(fields.CountryCode, false)
+	admin1Column, _ := column(fields.Admin1, false)
+	elevationColumn, _ := column(fields.Elevation, false)
+	timezoneColumn, _ := column(fields.Timezone, false)
+	populationColumn, _ := column(fields.Population, false)
+	aliasesColumn, _ := column(fields.Aliases, false)
+
+	var records []domain.CityRecord
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			records = append(records, domain.CityRecord{
+				Position: fmt.Sprintf("line %d", parseErr.Line),
+				Err:      fmt.Errorf("%w: %w", domain.ErrInvalidInput, parseErr.Err),
+			})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading csv file: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		record := domain.CityRecord{Position: fmt.Sprintf("line %d", line)}
+		value := func(i int) string {
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		if len(row) < len(header) {
+			record.Err = fmt.Errorf("%w: expected %d columns, got %d", domain.ErrInvalidInput, len(header), len(row))
+			records = append(records, record)
+			continue
+		}
+		record.City.Name = value(nameColumn)
+		record.City.CountryCode = value(countryCodeColumn)
+		record.City.Admin1 = value(admin1Column)
+		record.City.Timezone = value(timezoneColumn)
+		record.City.Aliases = splitAliases(value(aliasesColumn))
+		record.City.Latitude, record.Err = parseDegrees("latitude", value(latitudeColumn))
+		if record.Err == nil {
+			record.City.Longitude, record.Err = parseDegrees("longitude", value(longitudeColumn))
+		}
+		if record.Err == nil {
+			record.City.Population, record.Err = parsePopulation(value(populationColumn))
+		}
+		if elevation := value(elevationColumn); record.Err == nil && elevation != "" {
+			record.City.Elevation, record.Err = parseElevation(elevation)
+		}
+		records = append(records, record)
+	}
+}
+
+// featureCollection is the part of a GeoJSON FeatureCollection the importer reads.
+type featureCollection struct {
+	Type     string `json:"type"`
+	Features []struct {
+		Geometry *struct {
+			Type string `json:"type"`
+			// Coordinates are nested arrays for geometries other than points
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+		Properties map[string]any `json:"properties"`
+	} `json:"features"`
+}
+
+func readGeoJSON(r io.Reader, fields Fields) ([]domain.CityRecord, error) {
+	var collection featureCollection
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("%w: invalid geojson: %w", domain.ErrInvalidInput, err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("%w: geojson must be a FeatureCollection, got %q", domain.ErrInvalidInput, collection.Type)
+	}
+	records := make([]domain.CityRecord, 0, len(collection.Features))
+	for i, feature := range collection.Features {
+		record := domain.CityRecord{Position: fmt.Sprintf("feature %d", i+1)}
+		records = append(records, record)
+		geometry := feature.Geometry
+		if geometry == nil || geometry.Type != "Point" {
+			records[i].Err = fmt.Errorf("%w: feature geometry must be a Point", domain.ErrInvalidInput)
+			continue
+		}
+		var position []float64
+		if err := json.Unmarshal(geometry.Coordinates, &position); err != nil || len(position) < 2 {
+			records[i].Err = fmt.Errorf("%w: point coordinates must be a longitude and a latitude", domain.ErrInvalidInput)
+			continue
+		}
+		name, _ := feature.Properties[fields.Name].(string)
+		aliases, err := propertyAliases(feature.Properties[fields.Aliases])
+		if err != nil {
+			records[i].Err = err
+			continue
+		}
+		population, err := propertyPopulation(feature.Properties[fields.Population])
+		if err != nil {
+			records[i].Err = err
+			continue
+		}
+		elevation, err := propertyElevation(feature.Properties[fields.Elevation])
+		if err != nil {
+			records[i].Err = err
+			continue
+		}
+		countryCode, _ := feature.Properties[fields.CountryCode].(string)
+		admin1, _ := feature.Properties[fields.Admin1].(string)
+		timezone, _ := feature.Properties[fields.Timezone].(string)
+		// GeoJSON positions are longitude first, the optional third value is the elevation
+		if elevation == nil && len(position) > 2 {
+			elevation = &position[2]
+		}
+		records[i].City = domain.City{
+			Name:        strings.TrimSpace(name),
+			CountryCode: countryCode,
+			Admin1:      admin1,
+			Latitude:    position[1],
+			Longitude:   position[0],
+			Elevation:   elevation,
+			Timezone:    timezone,
+			Population:  population,
+			Aliases:     aliases,
+		}
+	}
+	return records, nil
+}
+
+// propertyAliases reads an aliases property, an array of strings or a string of aliases
+// separated by aliasSeparator.
+func propertyAliases(value any) ([]string, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return splitAliases(value), nil
+	case []any:
+		aliases := make([]string, 0, len(value))
+		for _, alias := range value {
+			s, ok := alias.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: aliases must be strings", domain.ErrInvalidInput)
+			}
+			aliases = append(aliases, s)
+		}
+		return aliases, nil
+	}
+	return nil, fmt.Errorf("%w: aliases must be a string or an array of strings", domain.ErrInvalidInput)
+}
+
+// propertyPopulation reads a population property, a whole number or a numeric string.
+func propertyPopulation(value any) (int, error) {
+	switch value := value.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		if value < 0 || value != math.Trunc(value) {
+			return 0, fmt.Errorf("%w: population must be a whole number, got %g", domain.ErrInvalidInput, value)
+		}
+		return int(value), nil
+	case string:
+		return parsePopulation(value)
+	}
+	return 0, fmt.Errorf("%w: population must be a number", domain.ErrInvalidInput)
+}
+
+// propertyElevation reads an elevation property in metres, a number or a numeric string.
+func propertyElevation(value any) (*float64, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		return &value, nil
+	case string:
+		if strings.TrimSpace(value) == "" {
+			return nil, nil
+		}
+		return parseElevation(value)
+	}
+	return nil, fmt.Errorf("%w: elevation must be a number", domain.ErrInvalidInput)
+}
+
+// splitAliases splits a list of aliases separated by aliasSeparator. Blank aliases are dropped.
+func splitAliases(s string) []string {
+	var aliases []string
+	for _, alias := range strings.Split(s, aliasSeparator) {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
+
+func parseDegrees(field, s string) (float64, error) {
+	degrees, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be a decimal number, got %q", domain.ErrInvalidInput, field, s)
+	}
+	return degrees, nil
+}
+
+// parseElevation parses an elevation in metres.
+func parseElevation(s string) (*float64, error) {
+	elevation, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: elevation must be a number of metres, got %q", domain.ErrInvalidInput, s)
+	}
+	return &elevation, nil
+}
+
+// parsePopulation parses a population, an empty value is 0.
+func parsePopulation(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	population, err := strconv.Atoi(s)
+	if err != nil || population < 0 {
+		return 0, fmt.Errorf("%w: population must be a whole number, got %q", domain.ErrInvalidInput, s)
+	}
+	return population, nil
+}
@@ -0,0 +1,214 @@
+package importer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/softstone1/woc/domain"
+)
+
+// geoNamesLine returns a cities15000.txt line with the given fields, the columns in more and the
+// others left empty.
+func geoNamesLine(name, asciiName, latitude, longitude, population string, more map[int]string) string {
+	columns := make([]string, geoNamesColumns)
+	columns[0] = "2988507"
+	columns[geoNamesName] = name
+	columns[geoNamesASCIIName] = asciiName
+	columns[geoNamesAlternateNames] = "Lutetia,Paname,Parigi"
+	columns[geoNamesLatitude] = latitude
+	columns[geoNamesLongitude] = longitude
+	columns[geoNamesPopulation] = population
+	for i, value := range more {
+		columns[i] = value
+	}
+	return strings.Join(columns, "\t")
+}
+
+func TestRead(t *testing.T) {
+	elevation := func(metres float64) *float64 { return &metres }
+	testCases := []struct {
+		name            string
+		format          Format
+		fields          Fields
+		admin1          Admin1Names
+		input           string
+		expectedRecords []domain.CityRecord
+		// expectedErrs lists which records fail to be read
+		expectedErrs []bool
+	}{
+		{
+			name:   "GeoNames",
+			format: FormatGeoNames,
+			admin1: Admin1Names{"CH.ZH": "Zurich"},
+			input: strings.Join([]string{
+				geoNamesLine("Zürich", "Zurich", "47.36667", "8.55", "341730", map[int]string{
+					geoNamesCountryCode: "CH", geoNamesAdmin1: "ZH", geoNamesDEM: "428", geoNamesTimezone: "Europe/Zurich",
+				}),
+				"",
+				geoNamesLine("Paris", "Paris", "48.85341", "2.3488", "2138551", map[int]string{
+					geoNamesCountryCode: "FR", geoNamesAdmin1: "11", geoNamesElevation: "42", geoNamesDEM: "61", geoNamesTimezone: "Europe/Paris",
+				}),
+				geoNamesLine("Nowhere", "Nowhere", "north", "2.3488", "1", map[int]string{geoNamesDEM: geoNamesNoData}),
+				"2988507\tParis",
+			}, "\n"),
+			expectedRecords: []domain.CityRecord{
+				{Position: "line 1", City: domain.City{Name: "Zürich", CountryCode: "CH", Admin1: "Zurich", Latitude: 47.36667, Longitude: 8.55,
+					Elevation: elevation(428), Timezone: "Europe/Zurich", Population: 341730, Aliases: []string{"Zurich"}}},
+				{Position: "line 3", City: domain.City{ID: "paris-11-fr", Name: "Paris", CountryCode: "FR", Latitude: 48.85341, Longitude: 2.3488,
+					Elevation: elevation(42), Timezone: "Europe/Paris", Population: 2138551, Aliases: []string{"Paris"}}},
+				{Position: "line 4"},
+				{Position: "line 5"},
+			},
+			expectedErrs: []bool{false, false, true, true},
+		},
+		{
+			name:   "CSV",
+			format: FormatCSV,
+			fields: DefaultFields,
+			input: "\ufeffName,Latitude,Longitude,Population,Aliases,Country_Code,Admin1,Elevation,Timezone\n" +
+				"Berlin,52.52,13.405,3644826,BER; Berlin City,DE,Berlin,34,Europe/Berlin\n" +
+				"\"Washington, D.C.\",38.9072,-77.0369,,,US,,,\n" +
+				"Atlantis,0,0,many,,,,,\n" +
+				"Short,1\n",
+			expectedRecords: []domain.CityRecord{
+				{Position: "line 2", City: domain.City{Name: "Berlin", CountryCode: "DE", Admin1: "Berlin", Latitude: 52.52, Longitude: 13.405,
+					Elevation: elevation(34), Timezone: "Europe/Berlin", Population: 3644826, Aliases: []string{"BER", "Berlin City"}}},
+				{Position: "line 3", City: domain.City{Name: "Washington, D.C.", CountryCode: "US", Latitude: 38.9072, Longitude: -77.0369}},
+				{Position: "line 4", City: domain.City{Name: "Atlantis"}},
+				{Position: "line 5"},
+			},
+			expectedErrs: []bool{false, false, true, true},
+		},
+		{
+			name:   "CSV with column mapping",
+			format: FormatCSV,
+			fields: Fields{Name: "city", Latitude: "lat", Longitude: "lng"},
+			input:  "city,lat,lng,population\nBerlin,52.52,13.405,3644826\n",
+			expectedRecords: []domain.CityRecord{
+				{Position: "line 2", City: domain.City{Name: "Berlin", Latitude: 52.52, Longitude: 13.405}},
+			},
+			expectedErrs: []bool{false},
+		},
+		{
+			name:   "CSV with malformed row",
+			format: FormatCSV,
+			fields: DefaultFields,
+			input:  "name,latitude,longitude\nx\"y,1,2\nBerlin,52.52,13.405\n",
+			expectedRecords: []domain.CityRecord{
+				{Position: "line 2"},
+				{Position: "line 3", City: domain.City{Name: "Berlin", Latitude: 52.52, Longitude: 13.405}},
+			},
+			expectedErrs: []bool{true, false},
+		},
+		{
+			name:   "GeoJSON",
+			format: FormatGeoJSON,
+			fields: DefaultFields,
+			input: `{"type": "FeatureCollection", "features": [
+				{"type": "Feature", "geometry": {"type": "Point", "coordinates": [13.405, 52.52, 34]},
+					"properties": {"name": "Berlin", "country_code": "DE", "population": 3644826, "aliases": ["BER"]}},
+				{"type": "Feature", "geometry": {"type": "Point", "coordinates": [-0.1278, 51.5074, 0]},
+					"properties": {"name": "London", "timezone": "Europe/London", "elevation": "25", "population": "8961989", "aliases": "LDN; Londres"}},
+				{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]},
+					"properties": {"name": "Road"}},
+				{"type": "Feature", "geometry": {"type": "Point", "coordinates": [0, 0]},
+					"properties": {"name": "Atlantis", "population": 1.5}}
+			]}`,
+			expectedRecords: []domain.CityRecord{
+				{Position: "feature 1", City: domain.City{Name: "Berlin", CountryCode: "DE", Latitude: 52.52, Longitude: 13.405,
+					Elevation: elevation(34), Population: 3644826, Aliases: []string{"BER"}}},
+				{Position: "feature 2", City: domain.City{Name: "London", Latitude: 51.5074, Longitude: -0.1278,
+					Elevation: elevation(25), Timezone: "Europe/London", Population: 8961989, Aliases: []string{"LDN", "Londres"}}},
+				{Position: "feature 3"},
+				{Position: "feature 4"},
+			},
+			expectedErrs: []bool{false, false, true, true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			records, err := Read(strings.NewReader(tc.input), tc.format, tc.fields, tc.admin1)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(records) != len(tc.expectedRecords) {
+				t.Fatalf("Expected %d records, but got %d: %v", len(tc.expectedRecords), len(records), records)
+			}
+			for i, record := range records {
+				if (record.Err != nil) != tc.expectedErrs[i] {
+					t.Errorf("Record %d: expected error %v, but got %v", i, tc.expectedErrs[i], record.Err)
+				}
+				if record.Err != nil {
+					if !errors.Is(record.Err, domain.ErrInvalidInput) {
+						t.Errorf("Record %d: expected domain.ErrInvalidInput, but got %v", i, record.Err)
+					}
+					if record.Position != tc.expectedRecords[i].Position {
+						t.Errorf("Record %d: expected position %q, but got %q", i, tc.expectedRecords[i].Position, record.Position)
+					}
+					continue
+				}
+				if !reflect.DeepEqual(record, tc.expectedRecords[i]) {
+					t.Errorf("Record %d: expected %+v, but got %+v", i, tc.expectedRecords[i], record)
+				}
+			}
+		})
+	}
+}
+
+func TestRead_InvalidFile(t *testing.T) {
+	testCases := []struct {
+		name   string
+		format Format
+		input  string
+	}{
+		{name: "CSV without header", format: FormatCSV, input: ""},
+		{name: "CSV without latitude column", format: FormatCSV, input: "name,lat,longitude\nBerlin,52.52,13.405\n"},
+		{name: "malformed GeoJSON", format: FormatGeoJSON, input: `{"type": "FeatureCollection", "features": [`},
+		{name: "GeoJSON feature", format: FormatGeoJSON, input: `{"type": "Feature", "geometry": {"type": "Point", "coordinates": [0, 0]}}`},
+		{name: "unknown format", format: "xml", input: "<cities/>"},
+	}
+	for _, tc := range testCases {
+		if _, err := Read(strings.NewReader(tc.input), tc.format, DefaultFields, nil); !errors.Is(err, domain.ErrInvalidInput) {
+			t.Errorf("%s: expected domain.ErrInvalidInput, but got %v", tc.name, err)
+		}
+	}
+}
+
+func TestReadAdmin1Names(t *testing.T) {
+	input := "FR.11\tÎle-de-France\tIle-de-France\t3012874\n\nCH.ZH\tZurich\tZurich\t2657895\n"
+	names, err := ReadAdmin1Names(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := Admin1Names{"FR.11": "Île-de-France", "CH.ZH": "Zurich"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, but got %v", expected, names)
+	}
+
+	if _, err := ReadAdmin1Names(strings.NewReader("FR.11\n")); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("Expected domain.ErrInvalidInput, but got %v", err)
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected Format
+	}{
+		{path: "cities15000.txt", expected: FormatGeoNames},
+		{path: "/data/sites.CSV", expected: FormatCSV},
+		{path: "cities.geojson", expected: FormatGeoJSON},
+		{path: "cities.json", expected: FormatGeoJSON},
+	}
+	for _, tc := range testCases {
+		if format, err := FormatFromPath(tc.path); err != nil || format != tc.expected {
+			t.Errorf("%s: expected %q, but got %q, %v", tc.path, tc.expected, format, err)
+		}
+	}
+	if _, err := FormatFromPath("cities.xlsx"); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("Expected domain.ErrInvalidInput, but got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"errors"
+	"expvar"
 	"fmt"
 	"log"
 	"log/slog"
@@ -16,7 +17,6 @@ import (
 	"github.com/gorilla/handlers"
 	"github.com/softstone1/woc/config"
 	"github.com/softstone1/woc/infra/handler"
-
 )
 
 const (
@@ -31,27 +31,37 @@ type Mux struct {
 	cfg            config.Env
 	httpHandler    http.Handler
 	weatherHandler *handler.Weather
+	healthHandler  *handler.Health
+	citiesHandler  *handler.Cities
 }
 
 // NewMux creates a new mux server and registers routes with the handlers.
-// It also wraps the mux with logging and recovery middlewares
-func NewMux(cfg config.Env, h *handler.Weather) (*Mux, error) {
+// It also wraps the mux with logging, request id and recovery middlewares
+func NewMux(cfg config.Env, h *handler.Weather, hh *handler.Health, ch *handler.Cities) (*Mux, error) {
 	if h == nil {
 		return nil, errors.New("handler is required")
 	}
+	if hh == nil {
+		return nil, errors.New("health handler is required")
+	}
+	if ch == nil {
+		return nil, errors.New("cities handler is required")
+	}
 	mux := http.NewServeMux()
 	// Register routes
-	registerRoutes(mux, h)
+	registerRoutes(mux, h, hh, ch, cfg.AdminToken())
 	// Setup profiling routes
 	if cfg.EnableProfiling() {
 		setupProfiling(mux)
 	}
-	// wrap with logging and recovery middlewares
-	wrappedMux := handlers.LoggingHandler(log.Writer(), handlers.RecoveryHandler()(mux))
+	// wrap with logging, request id and recovery middlewares, and report /api routing errors as problem details
+	wrappedMux := handlers.LoggingHandler(log.Writer(), handler.WithRequestID(handlers.RecoveryHandler()(handler.WithAPIProblems(mux))))
 	return &Mux{
 		cfg:            cfg,
 		httpHandler:    wrappedMux,
 		weatherHandler: h,
+		healthHandler:  hh,
+		citiesHandler:  ch,
 	}, nil
 }
 
@@ -102,11 +112,29 @@ func (s *Mux) Run(ctx context.Context) error {
 	return nil
 }
 
-// registerRoutes registers routes with the mux
-func registerRoutes(mux *http.ServeMux, h *handler.Weather) {
+// registerRoutes registers routes with the mux. City writes require the admin token.
+func registerRoutes(mux *http.ServeMux, h *handler.Weather, hh *handler.Health, ch *handler.Cities, adminToken string) {
 	mux.HandleFunc("GET /", h.Home)
+	mux.HandleFunc("GET /health", hh.GetHealth)
 	mux.HandleFunc("GET /weather", h.GetWeatherByCity)
+	mux.HandleFunc("GET /forecast/daily", h.GetDailyForecastByCity)
+	mux.HandleFunc("GET /cities/search", ch.SearchCities)
+	// unknown /api paths are not served the home page
+	mux.HandleFunc("GET /api/", handler.NotFoundAPI)
 	mux.HandleFunc("GET /api/weather", h.GetWeatherByCityAPI)
+	mux.HandleFunc("GET /api/weather/coords", h.GetWeatherByCoordinatesAPI)
+	mux.HandleFunc("GET /api/weather/batch", h.GetWeatherByCitiesAPI)
+	mux.HandleFunc("POST /api/weather/batch", h.GetWeatherByCitiesAPI)
+	mux.HandleFunc("GET /api/forecast", h.GetForecastByCityAPI)
+	mux.HandleFunc("GET /api/forecast/daily", h.GetDailyForecastByCityAPI)
+	mux.HandleFunc("GET /api/cities", ch.ListCitiesAPI)
+	mux.HandleFunc("GET /api/cities/search", ch.SearchCitiesAPI)
+	mux.HandleFunc("GET /api/cities/nearby", ch.NearbyCitiesAPI)
+	mux.HandleFunc("GET /api/cities/{name}", ch.GetCityAPI)
+	mux.HandleFunc("POST /api/cities", handler.RequireAdmin(adminToken, ch.CreateCityAPI))
+	mux.HandleFunc("POST /api/cities/import", handler.RequireAdmin(adminToken, ch.ImportCitiesAPI))
+	mux.HandleFunc("PUT /api/cities/{name}", handler.RequireAdmin(adminToken, ch.UpdateCityAPI))
+	mux.HandleFunc("DELETE /api/cities/{name}", handler.RequireAdmin(adminToken, ch.DeleteCityAPI))
 }
 
 func setupProfiling(mux *http.ServeMux) {
@@ -122,4 +150,7 @@ func setupProfiling(mux *http.ServeMux) {
 	mux.Handle("GET /debug/pprof/heap", pprof.Handler("heap"))
 	mux.Handle("GET /debug/pprof/threadcreate", pprof.Handler("threadcreate"))
 	mux.Handle("GET /debug/pprof/block", pprof.Handler("block"))
+
+	// Runtime and application counters, such as the weather cache hit ratio
+	mux.Handle("GET /debug/vars", expvar.Handler())
 }